@@ -3,6 +3,7 @@ module github.com/rashidmailru/kabobfood
 go 1.25.4
 
 require (
+	github.com/andybalholm/brotli v1.2.0
 	github.com/caarlos0/env/v10 v10.0.0
 	github.com/getsentry/sentry-go v0.40.0
 	github.com/gin-contrib/cors v1.7.0
@@ -16,6 +17,9 @@ require (
 	github.com/redis/go-redis/v9 v9.17.2
 	go.uber.org/zap v1.27.1
 	golang.org/x/crypto v0.45.0
+	golang.org/x/image v0.25.0
+	golang.org/x/sync v0.18.0
+	golang.org/x/text v0.31.0
 )
 
 require (
@@ -56,9 +60,7 @@ require (
 	golang.org/x/arch v0.20.0 // indirect
 	golang.org/x/mod v0.29.0 // indirect
 	golang.org/x/net v0.47.0 // indirect
-	golang.org/x/sync v0.18.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
-	golang.org/x/text v0.31.0 // indirect
 	golang.org/x/tools v0.38.0 // indirect
 	google.golang.org/protobuf v1.36.9 // indirect
 )
@@ -0,0 +1,115 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/rashidmailru/kabobfood/internal/audit"
+	"github.com/rashidmailru/kabobfood/internal/menu"
+)
+
+// ErrNoDraft is returned when publishing without a saved draft.
+var ErrNoDraft = errors.New("there is no menu draft to publish")
+
+const menuVersionsLimit = 50
+
+// GetDraft returns the saved draft or, when there is none, a new one copied
+// from the live menu. The new draft is not stored until SaveDraft.
+func (s *MenuService) GetDraft(ctx context.Context) (*menu.Draft, error) {
+	draft, err := s.repo.GetDraft(ctx)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return draft, err
+	}
+	live, err := s.repo.LiveSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.repo.CurrentVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &menu.Draft{BaseVersion: version, BaseRevision: live.Revision(), Snapshot: live}, nil
+}
+
+// SaveDraft validates and stores the workspace. A saved draft keeps the
+// version and revision it was started from; a new one is based on the live menu.
+func (s *MenuService) SaveDraft(ctx context.Context, snapshot menu.Snapshot) (*menu.Draft, error) {
+	if err := menu.NormalizeDraft(&snapshot); err != nil {
+		return nil, err
+	}
+	current, err := s.GetDraft(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SaveDraft(ctx, menu.Draft{BaseVersion: current.BaseVersion, BaseRevision: current.BaseRevision, Snapshot: snapshot})
+}
+
+// DiscardDraft drops the saved draft.
+func (s *MenuService) DiscardDraft(ctx context.Context) error {
+	return s.repo.DeleteDraft(ctx)
+}
+
+// PreviewDraft returns the menu customers would see after publishing and the
+// changes against the live menu.
+func (s *MenuService) PreviewDraft(ctx context.Context) (*menu.Preview, error) {
+	draft, err := s.GetDraft(ctx)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.repo.ListAllProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live, err := s.repo.LiveSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &menu.Preview{
+		BaseVersion: draft.BaseVersion,
+		Menu:        menu.PreviewMenu(draft.Snapshot, all, time.Now()),
+		Changes:     menu.DiffDraft(live, draft.Snapshot),
+	}, nil
+}
+
+// PublishDraft applies the saved draft atomically as a new menu version.
+func (s *MenuService) PublishDraft(ctx context.Context) (*menu.MenuVersion, error) {
+	draft, err := s.repo.GetDraft(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNoDraft
+	}
+	if err != nil {
+		return nil, err
+	}
+	live, err := s.repo.LiveSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	version, err := s.repo.PublishDraft(ctx, *draft)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, audit.ActionPublish, audit.EntityMenu, version.Version, nil, map[string]any{"changes": menu.DiffDraft(live, draft.Snapshot)})
+	s.invalidateCache(ctx)
+	return version, nil
+}
+
+// ListVersions returns the latest menu versions, newest first.
+func (s *MenuService) ListVersions(ctx context.Context) ([]menu.MenuVersion, error) {
+	return s.repo.ListVersions(ctx, menuVersionsLimit)
+}
+
+// RollbackMenu publishes the content of an earlier version as a new one; a
+// zero version means the one before the current. Unknown versions return
+// pgx.ErrNoRows.
+func (s *MenuService) RollbackMenu(ctx context.Context, version int64) (*menu.MenuVersion, error) {
+	restored, err := s.repo.RestoreVersion(ctx, version)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, audit.ActionRollback, audit.EntityMenu, restored.Version, nil, restored)
+	s.invalidateCache(ctx)
+	return restored, nil
+}
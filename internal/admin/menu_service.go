@@ -2,31 +2,45 @@ package admin
 
 import (
 	"context"
+	"errors"
 
-	"github.com/redis/go-redis/v9"
-
+	"github.com/rashidmailru/kabobfood/internal/audit"
+	"github.com/rashidmailru/kabobfood/internal/cache"
 	"github.com/rashidmailru/kabobfood/internal/menu"
+	"github.com/rashidmailru/kabobfood/internal/storage"
+)
+
+const (
+	defaultProductPageSize = 50
+	maxProductPageSize     = 500
+)
+
+var (
+	// ErrInvalidReorder is returned for empty reorder lists or repeated ids.
+	ErrInvalidReorder = errors.New("reorder list must be non-empty and contain each id once")
+	// ErrInvalidStock is returned for negative stock quantities.
+	ErrInvalidStock = errors.New("stock must be >= 0")
 )
 
-// MenuService wraps menu repo with cache invalidation.
+// MenuService wraps menu repo with cache invalidation, audit records and
+// product image storage.
 type MenuService struct {
-	repo  *menu.Repository
-	cache *redis.Client
+	repo   *menu.Repository
+	cache  *cache.Store
+	audit  *audit.Service
+	images storage.Storage
 }
 
-// NewMenuService builds admin menu service.
-func NewMenuService(repo *menu.Repository, cache *redis.Client) *MenuService {
-	return &MenuService{repo: repo, cache: cache}
+// NewMenuService builds admin menu service; auditLog and images are optional.
+func NewMenuService(repo *menu.Repository, store *cache.Store, auditLog *audit.Service, images storage.Storage) *MenuService {
+	return &MenuService{repo: repo, cache: store, audit: auditLog, images: images}
 }
 
 func (s *MenuService) invalidateCache(ctx context.Context) {
 	if s.cache == nil {
 		return
 	}
-	keys := []string{"menu:v1"}
-	for _, key := range keys {
-		_ = s.cache.Del(ctx, key).Err()
-	}
+	s.cache.Delete(ctx, menu.MenuCacheKey, menu.SearchCacheKey)
 }
 
 // CreateCategory creates a category and invalidates cache.
@@ -35,54 +49,250 @@ func (s *MenuService) CreateCategory(ctx context.Context, cat menu.Category) (*m
 	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, audit.ActionCreate, audit.EntityCategory, created.ID, nil, created)
 	s.invalidateCache(ctx)
 	return created, nil
 }
 
 // UpdateCategory updates category.
 func (s *MenuService) UpdateCategory(ctx context.Context, cat menu.Category) (*menu.Category, error) {
+	before, err := s.repo.GetCategory(ctx, cat.ID)
+	if err != nil {
+		return nil, err
+	}
 	updated, err := s.repo.UpdateCategory(ctx, cat)
 	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, audit.ActionUpdate, audit.EntityCategory, updated.ID, before, updated)
 	s.invalidateCache(ctx)
 	return updated, nil
 }
 
-// DeleteCategory removes category.
-func (s *MenuService) DeleteCategory(ctx context.Context, id int64) error {
-	if err := s.repo.DeleteCategory(ctx, id); err != nil {
-		return err
+// ArchiveCategory hides a category from the public menu.
+func (s *MenuService) ArchiveCategory(ctx context.Context, id int64) (*menu.Category, error) {
+	before, err := s.repo.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := s.repo.ArchiveCategory(ctx, id)
+	if err != nil {
+		return nil, err
 	}
+	s.audit.Record(ctx, audit.ActionArchive, audit.EntityCategory, id, before, archived)
 	s.invalidateCache(ctx)
-	return nil
+	return archived, nil
+}
+
+// RestoreCategory brings an archived category back.
+func (s *MenuService) RestoreCategory(ctx context.Context, id int64) (*menu.Category, error) {
+	before, err := s.repo.GetCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	restored, err := s.repo.RestoreCategory(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, audit.ActionRestore, audit.EntityCategory, id, before, restored)
+	s.invalidateCache(ctx)
+	return restored, nil
 }
 
 // CreateProduct creates product.
 func (s *MenuService) CreateProduct(ctx context.Context, product menu.Product) (*menu.Product, error) {
+	if err := menu.NormalizeAttributes(&product); err != nil {
+		return nil, err
+	}
 	created, err := s.repo.InsertProduct(ctx, product)
 	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, audit.ActionCreate, audit.EntityProduct, created.ID, nil, created)
 	s.invalidateCache(ctx)
 	return created, nil
 }
 
 // UpdateProduct updates product values.
 func (s *MenuService) UpdateProduct(ctx context.Context, product menu.Product) (*menu.Product, error) {
+	if err := menu.NormalizeAttributes(&product); err != nil {
+		return nil, err
+	}
+	before, err := s.repo.GetProduct(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
 	updated, err := s.repo.UpdateProduct(ctx, product)
 	if err != nil {
 		return nil, err
 	}
+	s.replacedImage(ctx, before, updated)
+	s.audit.Record(ctx, audit.ActionUpdate, audit.EntityProduct, updated.ID, before, updated)
+	s.invalidateCache(ctx)
+	return updated, nil
+}
+
+// SetAvailability updates stock and the stop-list. A nil stock stops counting
+// the product; a nil UnavailableUntil takes it off the stop-list.
+func (s *MenuService) SetAvailability(ctx context.Context, id int64, a menu.Availability) (*menu.Product, error) {
+	if a.Stock != nil && *a.Stock < 0 {
+		return nil, ErrInvalidStock
+	}
+	before, err := s.repo.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.SetAvailability(ctx, id, a)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, audit.ActionUpdate, audit.EntityProduct, id, before, updated)
 	s.invalidateCache(ctx)
 	return updated, nil
 }
 
-// DeleteProduct removes product.
-func (s *MenuService) DeleteProduct(ctx context.Context, id int64) error {
-	if err := s.repo.DeleteProduct(ctx, id); err != nil {
+// GetBundle returns the slots of a product; regular products have none.
+func (s *MenuService) GetBundle(ctx context.Context, id int64) ([]menu.BundleSlot, error) {
+	if _, err := s.repo.GetProduct(ctx, id); err != nil {
+		return nil, err
+	}
+	bundles, err := s.repo.GetBundleSlots(ctx, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	if bundles[id] == nil {
+		return []menu.BundleSlot{}, nil
+	}
+	return bundles[id], nil
+}
+
+// SetBundle replaces the slots of a product, making it a bundle. An empty list
+// turns it back into a regular product.
+func (s *MenuService) SetBundle(ctx context.Context, id int64, slots []menu.BundleSlot) ([]menu.BundleSlot, error) {
+	if err := menu.NormalizeBundle(id, slots); err != nil {
+		return nil, err
+	}
+	before, err := s.GetBundle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.SetBundle(ctx, id, slots)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, audit.ActionUpdate, audit.EntityProduct, id, map[string]any{"bundle": before}, map[string]any{"bundle": updated})
+	s.invalidateCache(ctx)
+	return updated, nil
+}
+
+// ArchiveProduct hides a product from the menu and new orders.
+func (s *MenuService) ArchiveProduct(ctx context.Context, id int64) (*menu.Product, error) {
+	before, err := s.repo.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := s.repo.ArchiveProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, audit.ActionArchive, audit.EntityProduct, id, before, archived)
+	s.invalidateCache(ctx)
+	return archived, nil
+}
+
+// RestoreProduct brings an archived product back.
+func (s *MenuService) RestoreProduct(ctx context.Context, id int64) (*menu.Product, error) {
+	before, err := s.repo.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	restored, err := s.repo.RestoreProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, audit.ActionRestore, audit.EntityProduct, id, before, restored)
+	s.invalidateCache(ctx)
+	return restored, nil
+}
+
+// ListCategories returns every category, hidden and archived ones included unless
+// archived narrows the result.
+func (s *MenuService) ListCategories(ctx context.Context, archived *bool) ([]menu.Category, error) {
+	return s.repo.ListCategories(ctx, archived)
+}
+
+// ListProducts returns a page of products matching params, hidden ones included.
+func (s *MenuService) ListProducts(ctx context.Context, params menu.AdminProductParams) (*menu.ProductPage, error) {
+	params = clampProductPage(params)
+	products, total, err := s.repo.ListProducts(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return &menu.ProductPage{Products: products, Total: total, Limit: params.Limit, Offset: params.Offset}, nil
+}
+
+// ReorderCategories applies several sort_order changes atomically.
+func (s *MenuService) ReorderCategories(ctx context.Context, updates []menu.SortOrderUpdate) error {
+	if err := validateReorder(updates); err != nil {
+		return err
+	}
+	changes, err := s.repo.ReorderCategories(ctx, updates)
+	if err != nil {
+		return err
+	}
+	s.recordReorder(ctx, audit.EntityCategory, changes)
+	s.invalidateCache(ctx)
+	return nil
+}
+
+// ReorderProducts applies several sort_order changes atomically.
+func (s *MenuService) ReorderProducts(ctx context.Context, updates []menu.SortOrderUpdate) error {
+	if err := validateReorder(updates); err != nil {
+		return err
+	}
+	changes, err := s.repo.ReorderProducts(ctx, updates)
+	if err != nil {
 		return err
 	}
+	s.recordReorder(ctx, audit.EntityProduct, changes)
 	s.invalidateCache(ctx)
 	return nil
 }
+
+func (s *MenuService) recordReorder(ctx context.Context, entityType string, changes []menu.SortOrderChange) {
+	for _, ch := range changes {
+		if ch.Before == ch.After {
+			continue
+		}
+		s.audit.Record(ctx, audit.ActionReorder, entityType, ch.ID,
+			map[string]int{"sort_order": ch.Before}, map[string]int{"sort_order": ch.After})
+	}
+}
+
+// clampProductPage applies the default page size and bounds limit and offset.
+func clampProductPage(params menu.AdminProductParams) menu.AdminProductParams {
+	if params.Limit <= 0 {
+		params.Limit = defaultProductPageSize
+	}
+	if params.Limit > maxProductPageSize {
+		params.Limit = maxProductPageSize
+	}
+	if params.Offset < 0 {
+		params.Offset = 0
+	}
+	return params
+}
+
+func validateReorder(updates []menu.SortOrderUpdate) error {
+	if len(updates) == 0 {
+		return ErrInvalidReorder
+	}
+	seen := make(map[int64]struct{}, len(updates))
+	for _, u := range updates {
+		if _, dup := seen[u.ID]; dup || u.ID <= 0 {
+			return ErrInvalidReorder
+		}
+		seen[u.ID] = struct{}{}
+	}
+	return nil
+}
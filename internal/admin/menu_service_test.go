@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rashidmailru/kabobfood/internal/menu"
+)
+
+func TestValidateReorder(t *testing.T) {
+	tests := []struct {
+		name    string
+		updates []menu.SortOrderUpdate
+		wantErr bool
+	}{
+		{name: "empty list", updates: nil, wantErr: true},
+		{name: "empty slice", updates: []menu.SortOrderUpdate{}, wantErr: true},
+		{name: "duplicate id", updates: []menu.SortOrderUpdate{{ID: 1, SortOrder: 1}, {ID: 1, SortOrder: 2}}, wantErr: true},
+		{name: "zero id", updates: []menu.SortOrderUpdate{{ID: 0, SortOrder: 1}}, wantErr: true},
+		{name: "negative id", updates: []menu.SortOrderUpdate{{ID: 2, SortOrder: 1}, {ID: -3, SortOrder: 2}}, wantErr: true},
+		{name: "valid", updates: []menu.SortOrderUpdate{{ID: 2, SortOrder: 1}, {ID: 3, SortOrder: 1}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateReorder(tc.updates)
+			if tc.wantErr && !errors.Is(err, ErrInvalidReorder) {
+				t.Fatalf("expected ErrInvalidReorder, got %v", err)
+			}
+			if !tc.wantErr && err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+		})
+	}
+}
+
+func TestClampProductPage(t *testing.T) {
+	tests := []struct {
+		name                  string
+		limit, offset         int
+		wantLimit, wantOffset int
+	}{
+		{name: "defaults", wantLimit: defaultProductPageSize},
+		{name: "negative", limit: -1, offset: -5, wantLimit: defaultProductPageSize},
+		{name: "within bounds", limit: 20, offset: 40, wantLimit: 20, wantOffset: 40},
+		{name: "too large", limit: maxProductPageSize + 1, wantLimit: maxProductPageSize},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := clampProductPage(menu.AdminProductParams{Query: "plov", Limit: tc.limit, Offset: tc.offset})
+			if got.Limit != tc.wantLimit || got.Offset != tc.wantOffset || got.Query != "plov" {
+				t.Fatalf("got %+v, want limit %d offset %d", got, tc.wantLimit, tc.wantOffset)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/rashidmailru/kabobfood/internal/audit"
+	"github.com/rashidmailru/kabobfood/internal/menu"
+)
+
+// ErrImportInvalid is returned when an import has rows that failed validation;
+// nothing is written and the result lists the errors.
+var ErrImportInvalid = errors.New("import contains invalid rows")
+
+// ImportOptions controls a menu import.
+type ImportOptions struct {
+	Format            string
+	DryRun            bool
+	DeactivateMissing bool
+}
+
+// ImportResult is the diff of an import and whether it was written.
+type ImportResult struct {
+	*menu.ImportPlan
+	DryRun  bool `json:"dry_run"`
+	Applied bool `json:"applied"`
+}
+
+// ExportMenu writes live categories and products, hidden ones included, in format.
+func (s *MenuService) ExportMenu(ctx context.Context, w io.Writer, format string) error {
+	live := false
+	categories, err := s.repo.ListCategories(ctx, &live)
+	if err != nil {
+		return err
+	}
+	all, err := s.repo.ListAllProducts(ctx)
+	if err != nil {
+		return err
+	}
+	products := all[:0]
+	for _, p := range all {
+		if p.ArchivedAt == nil {
+			products = append(products, p)
+		}
+	}
+	return menu.WriteRows(w, format, menu.ExportRows(categories, products))
+}
+
+// ImportMenu diffs the file against the stored menu and, unless DryRun is set,
+// applies it in one transaction. Any invalid row rejects the whole import.
+func (s *MenuService) ImportMenu(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	rows, readErrs, err := menu.ReadRows(r, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.repo.ListCategories(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.repo.ListAllProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := menu.PlanImport(rows, categories, products, opts.DeactivateMissing)
+	plan.Errors = append(readErrs, plan.Errors...)
+	sort.SliceStable(plan.Errors, func(i, j int) bool { return plan.Errors[i].Line < plan.Errors[j].Line })
+	result := &ImportResult{ImportPlan: plan, DryRun: opts.DryRun}
+	if len(plan.Errors) > 0 {
+		return result, ErrImportInvalid
+	}
+	if opts.DryRun || plan.Empty() {
+		return result, nil
+	}
+
+	if err := s.repo.ApplyImport(ctx, plan); err != nil {
+		return nil, err
+	}
+	result.Applied = true
+	for _, ch := range plan.Created {
+		s.audit.Record(ctx, audit.ActionCreate, audit.EntityProduct, ch.After.ID, nil, ch.After)
+	}
+	for _, ch := range plan.Updated {
+		s.replacedImage(ctx, ch.Before, &ch.After)
+		s.audit.Record(ctx, audit.ActionUpdate, audit.EntityProduct, ch.After.ID, ch.Before, ch.After)
+	}
+	for _, ch := range plan.Deactivated {
+		s.audit.Record(ctx, audit.ActionUpdate, audit.EntityProduct, ch.After.ID, ch.Before, ch.After)
+	}
+	s.invalidateCache(ctx)
+	return result, nil
+}
@@ -2,10 +2,79 @@ package admin
 
 import "time"
 
+// Staff roles stored in admin_users.role.
+const (
+	RoleOwner         = "owner"
+	RoleManager       = "manager"
+	RoleOperator      = "operator"
+	RoleKitchen       = "kitchen"
+	RoleCourier       = "courier"
+	RoleContentEditor = "content_editor"
+)
+
 // User represents admin/operator credential.
 type User struct {
-	ID           int64     `json:"id"`
-	Username     string    `json:"username"`
-	PasswordHash string    `json:"-"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID                 int64      `json:"id"`
+	Username           string     `json:"username"`
+	PasswordHash       string     `json:"-"`
+	Role               string     `json:"role"`
+	MustChangePassword bool       `json:"must_change_password"`
+	DisabledAt         *time.Time `json:"disabled_at,omitempty"`
+	TokenVersion       int64      `json:"-"`
+	TOTPSecret         string     `json:"-"`
+	TOTPEnabledAt      *time.Time `json:"totp_enabled_at,omitempty"`
+	TOTPLastStep       int64      `json:"-"`
+	MustEnrollTOTP     bool       `json:"must_enroll_totp"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// Disabled reports whether the account is switched off.
+func (u User) Disabled() bool {
+	return u.DisabledAt != nil
+}
+
+// TOTPEnabled reports whether the second factor is active.
+func (u User) TOTPEnabled() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != ""
+}
+
+// CreateUserInput is used by owners to add staff accounts.
+type CreateUserInput struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+// UpdateUserInput changes role, resets password or 2FA, or toggles the account; nil fields are kept.
+type UpdateUserInput struct {
+	Role      *string `json:"role"`
+	Password  *string `json:"password"`
+	Disabled  *bool   `json:"disabled"`
+	ResetTOTP bool    `json:"reset_totp"`
+}
+
+// ChangePasswordInput is the self-service password change request.
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// LoginInput carries credentials and, for accounts with 2FA, one of the second factors.
+type LoginInput struct {
+	Username     string `json:"username" binding:"required"`
+	Password     string `json:"password" binding:"required"`
+	OTP          string `json:"otp"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// TOTPEnrollment is returned when enrollment starts; the secret is shown once.
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// SecurityPolicy holds staff-wide security settings.
+type SecurityPolicy struct {
+	Require2FA bool `json:"require_2fa"`
 }
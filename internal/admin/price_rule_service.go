@@ -0,0 +1,64 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/rashidmailru/kabobfood/internal/audit"
+	"github.com/rashidmailru/kabobfood/internal/cache"
+	"github.com/rashidmailru/kabobfood/internal/menu"
+	"github.com/rashidmailru/kabobfood/internal/pricing"
+)
+
+// PriceRuleService manages scheduled prices and happy hours.
+type PriceRuleService struct {
+	repo  *pricing.Repository
+	cache *cache.Store
+	audit *audit.Service
+}
+
+// NewPriceRuleService builds the service; auditLog is optional.
+func NewPriceRuleService(repo *pricing.Repository, store *cache.Store, auditLog *audit.Service) *PriceRuleService {
+	return &PriceRuleService{repo: repo, cache: store, audit: auditLog}
+}
+
+// invalidate drops the cached menu and search results, which carry evaluated prices.
+func (s *PriceRuleService) invalidate(ctx context.Context) {
+	if s.cache == nil {
+		return
+	}
+	s.cache.Delete(ctx, menu.MenuCacheKey, menu.SearchCacheKey)
+}
+
+// List returns rules matching params; ended rules only when params.Expired is set.
+func (s *PriceRuleService) List(ctx context.Context, params pricing.ListParams) ([]pricing.Rule, error) {
+	return s.repo.List(ctx, params)
+}
+
+// Create validates and stores a rule; errors wrapping pricing.ErrInvalidRule
+// describe the problem.
+func (s *PriceRuleService) Create(ctx context.Context, rule pricing.Rule) (*pricing.Rule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	created, err := s.repo.Insert(ctx, rule)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, audit.ActionCreate, audit.EntityPriceRule, created.ID, nil, created)
+	s.invalidate(ctx)
+	return created, nil
+}
+
+// Delete removes a rule; unknown ids give pgx.ErrNoRows.
+func (s *PriceRuleService) Delete(ctx context.Context, id int64) error {
+	before, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.ActionDelete, audit.EntityPriceRule, id, before, nil)
+	s.invalidate(ctx)
+	return nil
+}
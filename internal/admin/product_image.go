@@ -0,0 +1,149 @@
+package admin
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rashidmailru/kabobfood/internal/audit"
+	"github.com/rashidmailru/kabobfood/internal/imaging"
+	"github.com/rashidmailru/kabobfood/internal/menu"
+	"github.com/rashidmailru/kabobfood/internal/storage"
+)
+
+var (
+	// ErrUnsupportedImage is returned when the uploaded bytes are not JPEG, PNG, WebP or GIF.
+	ErrUnsupportedImage = errors.New("image must be jpeg, png, webp or gif")
+	// ErrImagesDisabled is returned when no storage backend is configured.
+	ErrImagesDisabled = errors.New("image storage is not configured")
+)
+
+// imageExtensions maps sniffed content types to stored file extensions.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// SetProductImage stores data as the product image together with resized
+// variants and removes the previous files when they live in the same storage.
+// The type is sniffed from the bytes; the client-supplied Content-Type is ignored.
+func (s *MenuService) SetProductImage(ctx context.Context, id int64, data []byte) (*menu.Product, error) {
+	if s.images == nil {
+		return nil, ErrImagesDisabled
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+	variants, err := imaging.Render(data, imaging.DefaultVariants)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnsupportedImage, err)
+	}
+	before, err := s.repo.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	base := fmt.Sprintf("products/%d/%s", id, hex.EncodeToString(sum[:8]))
+	objects := []storage.Object{{Key: base + ext, ContentType: contentType, Data: data}}
+	for _, v := range variants {
+		objects = append(objects, storage.Object{Key: base + "-" + v.Name + ".jpg", ContentType: v.ContentType, Data: v.Data})
+	}
+	keep := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		if err := s.images.Put(ctx, obj); err != nil {
+			s.deleteKeys(ctx, keep)
+			return nil, err
+		}
+		keep[obj.Key] = true
+	}
+
+	images := &menu.ProductImages{
+		Thumbnail: s.images.URL(base + "-" + imaging.Thumbnail + ".jpg"),
+		Medium:    s.images.URL(base + "-" + imaging.Medium + ".jpg"),
+		Large:     s.images.URL(base + "-" + imaging.Large + ".jpg"),
+	}
+	updated, err := s.repo.SetProductImage(ctx, id, s.images.URL(base+ext), images)
+	if err != nil {
+		s.deleteKeys(ctx, keep)
+		return nil, err
+	}
+	s.removeImages(ctx, before, keep)
+	s.audit.Record(ctx, audit.ActionUpdate, audit.EntityProduct, id, before, updated)
+	s.invalidateCache(ctx)
+	return updated, nil
+}
+
+// DeleteProductImage clears the product image and removes the stored files.
+func (s *MenuService) DeleteProductImage(ctx context.Context, id int64) (*menu.Product, error) {
+	before, err := s.repo.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.SetProductImage(ctx, id, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	s.removeImages(ctx, before, nil)
+	s.audit.Record(ctx, audit.ActionUpdate, audit.EntityProduct, id, before, updated)
+	s.invalidateCache(ctx)
+	return updated, nil
+}
+
+// replacedImage removes the files of before once an update points image_url elsewhere.
+func (s *MenuService) replacedImage(ctx context.Context, before, after *menu.Product) {
+	if s.images == nil || before.ImageURL == after.ImageURL {
+		return
+	}
+	keep := map[string]bool{}
+	if key, ok := s.images.Key(after.ImageURL); ok {
+		keep[key] = true
+	}
+	s.removeImages(ctx, before, keep)
+}
+
+// removeImages deletes the files of p that this storage owns, except keep.
+// While a menu version or the draft uses the original, it and its resized
+// copies all stay, since a rollback or publish restores them; each copy is
+// also kept while referenced on its own. Failures only leave orphaned files,
+// so they are ignored.
+func (s *MenuService) removeImages(ctx context.Context, p *menu.Product, keep map[string]bool) {
+	if s.images == nil || p == nil {
+		return
+	}
+	var urls []string
+	if p.ImageURL != "" {
+		if referenced, err := s.repo.ImageReferenced(ctx, p.ImageURL); err != nil || referenced {
+			return
+		}
+		urls = append(urls, p.ImageURL)
+	}
+	if p.Images != nil {
+		for _, url := range []string{p.Images.Thumbnail, p.Images.Medium, p.Images.Large} {
+			if url == "" {
+				continue
+			}
+			if referenced, err := s.repo.ImageReferenced(ctx, url); err == nil && !referenced {
+				urls = append(urls, url)
+			}
+		}
+	}
+	for _, url := range urls {
+		if key, ok := s.images.Key(url); ok && !keep[key] {
+			_ = s.images.Delete(ctx, key)
+		}
+	}
+}
+
+func (s *MenuService) deleteKeys(ctx context.Context, keys map[string]bool) {
+	for key := range keys {
+		_ = s.images.Delete(ctx, key)
+	}
+}
@@ -0,0 +1,72 @@
+package admin
+
+import "strings"
+
+// Permissions granted to staff roles and carried in admin JWTs.
+const (
+	PermMenuRead       = "menu:read"
+	PermMenuWrite      = "menu:write"
+	PermRegionsRead    = "regions:read"
+	PermRegionsWrite   = "regions:write"
+	PermOrdersRead     = "orders:read"
+	PermOrdersStatus   = "orders:status"
+	PermOrdersDeliver  = "orders:deliver"
+	PermPaymentsRead   = "payments:read"
+	PermPaymentsRefund = "payments:refund"
+	PermReportsRead    = "reports:read"
+	PermKitchenRead    = "kitchen:read"
+	PermKitchenWrite   = "kitchen:write"
+	PermUsersManage    = "users:manage"
+	PermSecurityManage = "security:manage"
+	PermAuditRead      = "audit:read"
+)
+
+// rolePermissions lists what each role may do; owner gets everything.
+var rolePermissions = map[string][]string{
+	RoleOwner: {
+		PermMenuRead, PermMenuWrite, PermRegionsRead, PermRegionsWrite,
+		PermOrdersRead, PermOrdersStatus, PermOrdersDeliver,
+		PermPaymentsRead, PermPaymentsRefund, PermReportsRead,
+		PermKitchenRead, PermKitchenWrite, PermUsersManage, PermSecurityManage,
+		PermAuditRead,
+	},
+	RoleManager: {
+		PermMenuRead, PermMenuWrite, PermRegionsRead, PermRegionsWrite,
+		PermOrdersRead, PermOrdersStatus, PermOrdersDeliver,
+		PermPaymentsRead, PermPaymentsRefund, PermReportsRead,
+		PermKitchenRead, PermKitchenWrite, PermAuditRead,
+	},
+	RoleOperator: {
+		PermMenuRead, PermRegionsRead,
+		PermOrdersRead, PermOrdersStatus, PermOrdersDeliver,
+		PermPaymentsRead, PermKitchenRead,
+	},
+	RoleKitchen: {
+		PermMenuRead, PermKitchenRead, PermKitchenWrite,
+	},
+	RoleCourier: {
+		PermOrdersRead, PermOrdersDeliver,
+	},
+	RoleContentEditor: {
+		PermMenuRead, PermMenuWrite, PermRegionsRead,
+	},
+}
+
+// Roles returns all known staff roles.
+func Roles() []string {
+	return []string{RoleOwner, RoleManager, RoleOperator, RoleKitchen, RoleCourier, RoleContentEditor}
+}
+
+// ValidRole reports whether role is a known staff role.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[strings.ToLower(role)]
+	return ok
+}
+
+// PermissionsFor returns a copy of the permissions granted to role.
+func PermissionsFor(role string) []string {
+	perms := rolePermissions[strings.ToLower(role)]
+	out := make([]string, len(perms))
+	copy(out, perms)
+	return out
+}
@@ -3,27 +3,28 @@ package admin
 import (
 	"context"
 
-	"github.com/redis/go-redis/v9"
-
+	"github.com/rashidmailru/kabobfood/internal/audit"
+	"github.com/rashidmailru/kabobfood/internal/cache"
+	"github.com/rashidmailru/kabobfood/internal/menu"
 	"github.com/rashidmailru/kabobfood/internal/regions"
 )
 
-// RegionService manages region CRUD with cache invalidation.
+// RegionService manages region CRUD with cache invalidation and audit records.
 type RegionService struct {
 	repo  *regions.Repository
-	cache *redis.Client
+	cache *cache.Store
+	audit *audit.Service
 }
 
-func NewRegionService(repo *regions.Repository, cache *redis.Client) *RegionService {
-	return &RegionService{repo: repo, cache: cache}
+func NewRegionService(repo *regions.Repository, store *cache.Store, auditLog *audit.Service) *RegionService {
+	return &RegionService{repo: repo, cache: store, audit: auditLog}
 }
 
 func (s *RegionService) invalidate(ctx context.Context) {
 	if s.cache == nil {
 		return
 	}
-	_ = s.cache.Del(ctx, "regions:v1").Err()
-	_ = s.cache.Del(ctx, "menu:v1").Err()
+	s.cache.Delete(ctx, menu.RegionsCacheKey, menu.MenuCacheKey)
 }
 
 func (s *RegionService) CreateRegion(ctx context.Context, region regions.Region) (*regions.Region, error) {
@@ -31,23 +32,57 @@ func (s *RegionService) CreateRegion(ctx context.Context, region regions.Region)
 	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, audit.ActionCreate, audit.EntityRegion, created.ID, nil, created)
 	s.invalidate(ctx)
 	return created, nil
 }
 
 func (s *RegionService) UpdateRegion(ctx context.Context, region regions.Region) (*regions.Region, error) {
+	before, err := s.repo.GetByID(ctx, region.ID)
+	if err != nil {
+		return nil, err
+	}
 	updated, err := s.repo.Update(ctx, region)
 	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, audit.ActionUpdate, audit.EntityRegion, updated.ID, before, updated)
 	s.invalidate(ctx)
 	return updated, nil
 }
 
-func (s *RegionService) DeleteRegion(ctx context.Context, id int64) error {
-	if err := s.repo.Delete(ctx, id); err != nil {
-		return err
+// ArchiveRegion hides a region from GET /regions and new orders.
+func (s *RegionService) ArchiveRegion(ctx context.Context, id int64) (*regions.Region, error) {
+	before, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	archived, err := s.repo.Archive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, audit.ActionArchive, audit.EntityRegion, id, before, archived)
+	s.invalidate(ctx)
+	return archived, nil
+}
+
+// RestoreRegion brings an archived region back.
+func (s *RegionService) RestoreRegion(ctx context.Context, id int64) (*regions.Region, error) {
+	before, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
 	}
+	restored, err := s.repo.Restore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, audit.ActionRestore, audit.EntityRegion, id, before, restored)
 	s.invalidate(ctx)
-	return nil
+	return restored, nil
+}
+
+// ListRegions returns every region, inactive and archived ones included unless
+// archived narrows the result.
+func (s *RegionService) ListRegions(ctx context.Context, archived *bool) ([]regions.Region, error) {
+	return s.repo.ListAll(ctx, archived)
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,19 +19,28 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 	return &Repository{pool: pool}
 }
 
-var errNilPool = errors.New("admin repository: nil pool")
+var (
+	errNilPool = errors.New("admin repository: nil pool")
+	// ErrUserNotFound is returned when no admin user matches.
+	ErrUserNotFound = errors.New("admin user not found")
+	// ErrUsernameTaken is returned when the username is already used.
+	ErrUsernameTaken = errors.New("username already exists")
+)
+
+const userColumns = `id, username, password_hash, role, must_change_password, disabled_at, token_version,
+    COALESCE(totp_secret, ''), totp_enabled_at, totp_last_step, created_at, updated_at`
 
-// EnsureUser ensures username exists else creates with provided hash.
-func (r *Repository) EnsureUser(ctx context.Context, username, passwordHash string) error {
+// EnsureUser ensures username exists else creates with provided hash and role.
+func (r *Repository) EnsureUser(ctx context.Context, username, passwordHash, role string, mustChangePassword bool) error {
 	if r.pool == nil {
 		return errNilPool
 	}
 	const query = `
-INSERT INTO admin_users (username, password_hash)
-VALUES ($1, $2)
+INSERT INTO admin_users (username, password_hash, role, must_change_password)
+VALUES ($1, $2, $3, $4)
 ON CONFLICT (username) DO NOTHING;
 `
-	_, err := r.pool.Exec(ctx, query, username, passwordHash)
+	_, err := r.pool.Exec(ctx, query, username, passwordHash, role, mustChangePassword)
 	return err
 }
 
@@ -38,12 +49,278 @@ func (r *Repository) GetByUsername(ctx context.Context, username string) (*User,
 	if r.pool == nil {
 		return nil, errNilPool
 	}
+	query := `SELECT ` + userColumns + ` FROM admin_users WHERE username = $1;`
+	return scanUser(r.pool.QueryRow(ctx, query, username))
+}
+
+// GetByID returns admin user by id.
+func (r *Repository) GetByID(ctx context.Context, id int64) (*User, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `SELECT ` + userColumns + ` FROM admin_users WHERE id = $1;`
+	return scanUser(r.pool.QueryRow(ctx, query, id))
+}
+
+// List returns all admin users ordered by username.
+func (r *Repository) List(ctx context.Context) ([]User, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	rows, err := r.pool.Query(ctx, `SELECT `+userColumns+` FROM admin_users ORDER BY username;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *user)
+	}
+	return list, rows.Err()
+}
+
+// Create inserts a new admin user.
+func (r *Repository) Create(ctx context.Context, username, passwordHash, role string, mustChangePassword bool) (*User, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `
+INSERT INTO admin_users (username, password_hash, role, must_change_password)
+VALUES ($1, $2, $3, $4)
+RETURNING ` + userColumns + `;
+`
+	user, err := scanUser(r.pool.QueryRow(ctx, query, username, passwordHash, role, mustChangePassword))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrUsernameTaken
+		}
+		return nil, err
+	}
+	return user, nil
+}
 
-	const query = `SELECT id, username, password_hash, created_at FROM admin_users WHERE username = $1;`
+// UpdateParams holds optional changes; a non-nil PasswordHash also sets MustChangePassword.
+type UpdateParams struct {
+	Role               *string
+	PasswordHash       *string
+	MustChangePassword bool
+	Disabled           *bool
+}
+
+// Update applies changes and bumps token_version so existing tokens stop working.
+func (r *Repository) Update(ctx context.Context, id int64, params UpdateParams) (*User, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `
+UPDATE admin_users
+SET role = COALESCE($2, role),
+    password_hash = COALESCE($3, password_hash),
+    must_change_password = CASE WHEN $3::text IS NULL THEN must_change_password ELSE $4 END,
+    disabled_at = CASE
+        WHEN $5::boolean IS NULL THEN disabled_at
+        WHEN $5 THEN COALESCE(disabled_at, NOW())
+        ELSE NULL
+    END,
+    token_version = token_version + 1,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING ` + userColumns + `;
+`
+	return scanUser(r.pool.QueryRow(ctx, query, id, params.Role, params.PasswordHash, params.MustChangePassword, params.Disabled))
+}
+
+// RequirePasswordChange flags the account without revoking its tokens.
+func (r *Repository) RequirePasswordChange(ctx context.Context, id int64) error {
+	if r.pool == nil {
+		return errNilPool
+	}
+	_, err := r.pool.Exec(ctx, `UPDATE admin_users SET must_change_password = TRUE, updated_at = NOW() WHERE id = $1;`, id)
+	return err
+}
+
+// Delete removes admin user.
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	if r.pool == nil {
+		return errNilPool
+	}
+	tag, err := r.pool.Exec(ctx, `DELETE FROM admin_users WHERE id = $1;`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// CountActiveOwners returns the number of enabled owners.
+func (r *Repository) CountActiveOwners(ctx context.Context) (int, error) {
+	if r.pool == nil {
+		return 0, errNilPool
+	}
+	var n int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM admin_users WHERE role = $1 AND disabled_at IS NULL;`, RoleOwner).Scan(&n)
+	return n, err
+}
+
+// SetTOTPSecret stores a pending secret; it becomes active only after EnableTOTP.
+func (r *Repository) SetTOTPSecret(ctx context.Context, id int64, secret string) error {
+	if r.pool == nil {
+		return errNilPool
+	}
+	tag, err := r.pool.Exec(ctx, `UPDATE admin_users SET totp_secret = $2, totp_enabled_at = NULL, totp_last_step = 0, updated_at = NOW() WHERE id = $1;`, id, secret)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// EnableTOTP activates the pending secret and replaces recovery codes in one transaction.
+func (r *Repository) EnableTOTP(ctx context.Context, id, step int64, recoveryHashes []string) error {
+	if r.pool == nil {
+		return errNilPool
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+UPDATE admin_users
+SET totp_enabled_at = NOW(), totp_last_step = $2, updated_at = NOW()
+WHERE id = $1 AND totp_secret IS NOT NULL;
+`, id, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM admin_recovery_codes WHERE admin_id = $1;`, id); err != nil {
+		return err
+	}
+	for _, hash := range recoveryHashes {
+		if _, err := tx.Exec(ctx, `INSERT INTO admin_recovery_codes (admin_id, code_hash) VALUES ($1, $2);`, id, hash); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// DisableTOTP removes the secret and all recovery codes.
+func (r *Repository) DisableTOTP(ctx context.Context, id int64) error {
+	if r.pool == nil {
+		return errNilPool
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `UPDATE admin_users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = 0, updated_at = NOW() WHERE id = $1;`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM admin_recovery_codes WHERE admin_id = $1;`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// AdvanceTOTPStep records the last accepted step; it returns false if a newer or equal
+// step was already used, which rejects concurrent replays of the same code.
+func (r *Repository) AdvanceTOTPStep(ctx context.Context, id, step int64) (bool, error) {
+	if r.pool == nil {
+		return false, errNilPool
+	}
+	tag, err := r.pool.Exec(ctx, `UPDATE admin_users SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2;`, id, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UseRecoveryCode marks an unused recovery code as spent; it returns false if none matched.
+func (r *Repository) UseRecoveryCode(ctx context.Context, id int64, codeHash string) (bool, error) {
+	if r.pool == nil {
+		return false, errNilPool
+	}
+	tag, err := r.pool.Exec(ctx, `
+UPDATE admin_recovery_codes SET used_at = NOW()
+WHERE admin_id = $1 AND code_hash = $2 AND used_at IS NULL;
+`, id, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// RemainingRecoveryCodes counts unused recovery codes.
+func (r *Repository) RemainingRecoveryCodes(ctx context.Context, id int64) (int, error) {
+	if r.pool == nil {
+		return 0, errNilPool
+	}
+	var n int
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM admin_recovery_codes WHERE admin_id = $1 AND used_at IS NULL;`, id).Scan(&n)
+	return n, err
+}
+
+// RevokeSessionsWithoutTOTP bumps token_version of every account without active 2FA.
+func (r *Repository) RevokeSessionsWithoutTOTP(ctx context.Context) error {
+	if r.pool == nil {
+		return errNilPool
+	}
+	_, err := r.pool.Exec(ctx, `UPDATE admin_users SET token_version = token_version + 1 WHERE totp_enabled_at IS NULL;`)
+	return err
+}
+
+// GetSetting returns a staff setting value; ok is false when it was never set.
+func (r *Repository) GetSetting(ctx context.Context, key string) (string, bool, error) {
+	if r.pool == nil {
+		return "", false, errNilPool
+	}
+	var value string
+	err := r.pool.QueryRow(ctx, `SELECT value FROM admin_settings WHERE key = $1;`, key).Scan(&value)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// SetSetting upserts a staff setting.
+func (r *Repository) SetSetting(ctx context.Context, key, value string) error {
+	if r.pool == nil {
+		return errNilPool
+	}
+	const query = `
+INSERT INTO admin_settings (key, value) VALUES ($1, $2)
+ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW();
+`
+	_, err := r.pool.Exec(ctx, query, key, value)
+	return err
+}
 
-	row := r.pool.QueryRow(ctx, query, username)
+func scanUser(row pgx.Row) (*User, error) {
 	var user User
-	if err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt); err != nil {
+	if err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.MustChangePassword, &user.DisabledAt, &user.TokenVersion,
+		&user.TOTPSecret, &user.TOTPEnabledAt, &user.TOTPLastStep, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
 		return nil, err
 	}
 	return &user, nil
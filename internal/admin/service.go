@@ -3,20 +3,52 @@ package admin
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/rashidmailru/kabobfood/internal/tokens"
+)
+
+// minPasswordLength applies to passwords set through the API.
+const minPasswordLength = 8
+
+var (
+	// ErrInvalidCredentials is returned for unknown users or wrong passwords.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrAccountDisabled is returned when a disabled account logs in or uses an old token.
+	ErrAccountDisabled = errors.New("account disabled")
+	// ErrSessionRevoked is returned for tokens issued before a password, role or status change.
+	ErrSessionRevoked = errors.New("token revoked")
+	// ErrWeakPassword is returned for passwords that are too short or reuse the bootstrap password.
+	ErrWeakPassword = errors.New("password must be at least 8 characters and differ from the default one")
 )
 
 // AuthService handles admin authentication.
 type AuthService struct {
-	repo      *Repository
-	jwtSecret []byte
+	repo            *Repository
+	keys            *tokens.Keyring
+	jwtExpiry       time.Duration
+	defaultPassword string
+	totpIssuer      string
+	tokens          *tokens.Service
+	now             func() time.Time
 }
 
 // AuthConfig contains dependencies.
 type AuthConfig struct {
 	Repo      *Repository
-	JWTSecret string
+	Keys      *tokens.Keyring
+	JWTExpiry time.Duration
+	// DefaultPassword is the bootstrap password; accounts still using it must change it.
+	DefaultPassword string
+	// TOTPIssuer is the account issuer shown in authenticator apps.
+	TOTPIssuer string
+	// Tokens issues and revokes refresh tokens; optional.
+	Tokens *tokens.Service
+	// Now overrides the clock used for tokens and TOTP; defaults to time.Now.
+	Now func() time.Time
 }
 
 // NewAuthService builds service.
@@ -24,32 +56,229 @@ func NewAuthService(cfg AuthConfig) (*AuthService, error) {
 	if cfg.Repo == nil {
 		return nil, errors.New("admin repo is required")
 	}
-	if cfg.JWTSecret == "" {
-		return nil, errors.New("jwt secret required")
+	if cfg.Keys == nil {
+		return nil, errors.New("jwt keyring required")
+	}
+	expiry := cfg.JWTExpiry
+	if expiry <= 0 {
+		expiry = 15 * time.Minute
 	}
-	return &AuthService{repo: cfg.Repo, jwtSecret: []byte(cfg.JWTSecret)}, nil
+	issuer := cfg.TOTPIssuer
+	if issuer == "" {
+		issuer = "KabobFood"
+	}
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+	return &AuthService{
+		repo:            cfg.Repo,
+		keys:            cfg.Keys,
+		jwtExpiry:       expiry,
+		defaultPassword: cfg.DefaultPassword,
+		totpIssuer:      issuer,
+		tokens:          cfg.Tokens,
+		now:             now,
+	}, nil
 }
 
-// Login authenticates admin credentials.
-func (s *AuthService) Login(ctx context.Context, username, password string) (*User, error) {
+// Login authenticates admin credentials and, when enabled, the second factor.
+// Accounts logging in with the bootstrap password are flagged to change it even
+// if the flag was not stored; accounts without 2FA under the 2FA policy are flagged to enroll.
+func (s *AuthService) Login(ctx context.Context, input LoginInput) (*User, error) {
+	username, password := input.Username, input.Password
 	user, err := s.repo.GetByUsername(ctx, username)
 	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, ErrInvalidCredentials
+		}
 		return nil, err
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, ErrInvalidCredentials
+	}
+	if user.Disabled() {
+		return nil, ErrAccountDisabled
+	}
+	if user.TOTPEnabled() {
+		if err := s.verifySecondFactor(ctx, user, input.OTP, input.RecoveryCode); err != nil {
+			return nil, err
+		}
+	} else if err := s.flagEnrollment(ctx, user); err != nil {
+		return nil, err
+	}
+	if s.defaultPassword != "" && password == s.defaultPassword && !user.MustChangePassword {
+		// Stored so that refreshed tokens keep the restriction.
+		if err := s.repo.RequirePasswordChange(ctx, user.ID); err != nil {
+			return nil, err
+		}
+		user.MustChangePassword = true
 	}
 	return user, nil
 }
 
-// EnsureDefaultAdmin creates an admin with provided credentials if missing.
+// IssueSession signs an access token for user and, when configured, starts a refresh token family.
+func (s *AuthService) IssueSession(ctx context.Context, user *User) (*tokens.Session, error) {
+	access, err := s.issueToken(user)
+	if err != nil {
+		return nil, err
+	}
+	if s.tokens == nil {
+		return &tokens.Session{Token: access.Token, ExpiresAt: access.ExpiresAt}, nil
+	}
+	return s.tokens.Issue(ctx, tokens.Subject{Kind: tokens.SubjectAdmin, ID: user.ID}, access)
+}
+
+// IssueAccess signs a new access token for adminID when a refresh token rotates;
+// disabled accounts are refused and restrictions are re-evaluated.
+func (s *AuthService) IssueAccess(ctx context.Context, adminID int64) (tokens.Access, error) {
+	user, err := s.repo.GetByID(ctx, adminID)
+	if err != nil {
+		return tokens.Access{}, err
+	}
+	if user.Disabled() {
+		return tokens.Access{}, ErrAccountDisabled
+	}
+	if !user.TOTPEnabled() {
+		if err := s.flagEnrollment(ctx, user); err != nil {
+			return tokens.Access{}, err
+		}
+	}
+	return s.issueToken(user)
+}
+
+// issueToken signs a staff JWT with role, permissions and token version.
+// Accounts that must change the password or enroll 2FA get a token limited to that.
+func (s *AuthService) issueToken(user *User) (tokens.Access, error) {
+	now := s.now()
+	jti := tokens.NewJTI()
+	expiresAt := now.Add(s.jwtExpiry)
+	claims := jwt.MapClaims{
+		"sub":   user.ID,
+		"role":  user.Role,
+		"perms": PermissionsFor(user.Role),
+		"tv":    user.TokenVersion,
+		"jti":   jti,
+		"iat":   now.Unix(),
+		"exp":   expiresAt.Unix(),
+		"scope": "admin",
+	}
+	if user.MustChangePassword {
+		claims["pwd_change"] = true
+	}
+	if user.MustEnrollTOTP {
+		claims["totp_enroll"] = true
+	}
+	signed, err := s.keys.Sign(claims)
+	if err != nil {
+		return tokens.Access{}, err
+	}
+	return tokens.Access{Token: signed, JTI: jti, ExpiresAt: expiresAt}, nil
+}
+
+// revokeSessions ends every refresh token and live access token of adminID.
+func (s *AuthService) revokeSessions(ctx context.Context, adminID int64) error {
+	if s.tokens == nil {
+		return nil
+	}
+	return s.tokens.RevokeSubject(ctx, tokens.Subject{Kind: tokens.SubjectAdmin, ID: adminID})
+}
+
+// CheckSession confirms that a token issued with tokenVersion still belongs to an enabled account.
+func (s *AuthService) CheckSession(ctx context.Context, adminID, tokenVersion int64) error {
+	user, err := s.repo.GetByID(ctx, adminID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if user.Disabled() {
+		return ErrAccountDisabled
+	}
+	if user.TokenVersion != tokenVersion {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// Me returns the authenticated admin user.
+func (s *AuthService) Me(ctx context.Context, adminID int64) (*User, error) {
+	user, err := s.repo.GetByID(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled() {
+		if err := s.flagEnrollment(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// ChangePassword verifies the current password and stores a new one; other sessions are revoked.
+func (s *AuthService) ChangePassword(ctx context.Context, adminID int64, input ChangePasswordInput) (*User, error) {
+	user, err := s.repo.GetByID(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if input.NewPassword == input.CurrentPassword {
+		return nil, ErrWeakPassword
+	}
+	hash, err := s.hashPassword(input.NewPassword)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.Update(ctx, adminID, UpdateParams{PasswordHash: &hash, MustChangePassword: false})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.revokeSessions(ctx, adminID); err != nil {
+		return nil, err
+	}
+	if !updated.TOTPEnabled() {
+		if err := s.flagEnrollment(ctx, updated); err != nil {
+			return nil, err
+		}
+	}
+	return updated, nil
+}
+
+func (s *AuthService) hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength || (s.defaultPassword != "" && password == s.defaultPassword) {
+		return "", ErrWeakPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// EnsureDefaultAdmin creates the owner account with provided credentials if missing;
+// the password has to be changed on first login.
 func (s *AuthService) EnsureDefaultAdmin(ctx context.Context, username, password string) error {
 	if username == "" || password == "" {
 		return errors.New("default admin credentials not provided")
 	}
+	return s.ensureUser(ctx, username, password, RoleOwner, true)
+}
+
+// EnsureKitchenUser creates a kitchen account if credentials are configured.
+func (s *AuthService) EnsureKitchenUser(ctx context.Context, username, password string) error {
+	if username == "" || password == "" {
+		return nil
+	}
+	return s.ensureUser(ctx, username, password, RoleKitchen, false)
+}
+
+func (s *AuthService) ensureUser(ctx context.Context, username, password, role string, mustChange bool) error {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
-	return s.repo.EnsureUser(ctx, username, string(hash))
+	return s.repo.EnsureUser(ctx, username, string(hash), role, mustChange)
 }
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 key from the RFC 6238 test vectors.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeMatchesRFCVectors(t *testing.T) {
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := totpCode(rfcSecret, totpStep(time.Unix(unix, 0)))
+		if err != nil {
+			t.Fatalf("%d: %v", unix, err)
+		}
+		if got != want {
+			t.Fatalf("%d: expected %s, got %s", unix, want, got)
+		}
+	}
+}
+
+func TestVerifyTOTPWindowAndReplay(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := totpStep(now)
+	previous, _ := totpCode(rfcSecret, step-1)
+	stale, _ := totpCode(rfcSecret, step-2)
+
+	matched, ok := verifyTOTP(rfcSecret, previous, now, 0)
+	if !ok || matched != step-1 {
+		t.Fatalf("expected previous step to be accepted, got %d %v", matched, ok)
+	}
+	if _, ok := verifyTOTP(rfcSecret, stale, now, 0); ok {
+		t.Fatal("expected code outside the window to be rejected")
+	}
+	if _, ok := verifyTOTP(rfcSecret, previous, now, step-1); ok {
+		t.Fatal("expected replayed code to be rejected")
+	}
+	if _, ok := verifyTOTP(rfcSecret, "12345", now, 0); ok {
+		t.Fatal("expected short code to be rejected")
+	}
+}
+
+func TestOTPAuthURI(t *testing.T) {
+	uri := otpauthURI("KabobFood", "chef", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/KabobFood:chef?") {
+		t.Fatalf("unexpected uri %s", uri)
+	}
+	for _, part := range []string{"secret=ABC", "issuer=KabobFood", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Fatalf("uri %s misses %s", uri, part)
+		}
+	}
+}
+
+func TestRecoveryCodesAreHashedAndNormalized(t *testing.T) {
+	plain, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plain) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("expected %d codes, got %d", recoveryCodeCount, len(plain))
+	}
+	if hashes[0] == plain[0] {
+		t.Fatal("recovery code stored in plain text")
+	}
+	if hashRecoveryCode(strings.ToUpper(strings.ReplaceAll(plain[0], "-", ""))) != hashes[0] {
+		t.Fatal("expected hash to ignore case and dashes")
+	}
+}
@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// settingRequire2FA is the admin_settings key of the staff-wide 2FA policy.
+const settingRequire2FA = "require_2fa"
+
+var (
+	// ErrOTPRequired is returned when an account with 2FA logs in without a second factor.
+	ErrOTPRequired = errors.New("two-factor code required")
+	// ErrInvalidOTP is returned for wrong, expired or already used codes.
+	ErrInvalidOTP = errors.New("invalid two-factor code")
+	// ErrTOTPAlreadyEnabled is returned when enrolling an account that already has 2FA.
+	ErrTOTPAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	// ErrTOTPNotEnrolled is returned when confirming or disabling without a secret.
+	ErrTOTPNotEnrolled = errors.New("two-factor authentication not enrolled")
+	// ErrTOTPRequired blocks disabling 2FA while the policy requires it.
+	ErrTOTPRequired = errors.New("two-factor authentication is required by policy")
+)
+
+// DisableTOTPInput confirms the password and a current code before 2FA is removed.
+type DisableTOTPInput struct {
+	Password     string `json:"password"`
+	OTP          string `json:"otp"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// EnrollTOTP generates a new pending secret; 2FA is enabled once ConfirmTOTP accepts a code.
+func (s *AuthService) EnrollTOTP(ctx context.Context, adminID int64) (*TOTPEnrollment, error) {
+	user, err := s.repo.GetByID(ctx, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled() {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetTOTPSecret(ctx, adminID, secret); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{Secret: secret, OTPAuthURI: otpauthURI(s.totpIssuer, user.Username, secret)}, nil
+}
+
+// ConfirmTOTP enables 2FA after the first valid code and returns fresh recovery codes,
+// which are shown once and stored hashed.
+func (s *AuthService) ConfirmTOTP(ctx context.Context, adminID int64, code string) (*User, []string, error) {
+	user, err := s.repo.GetByID(ctx, adminID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.TOTPEnabled() {
+		return nil, nil, ErrTOTPAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, nil, ErrTOTPNotEnrolled
+	}
+	step, ok := verifyTOTP(user.TOTPSecret, code, s.now(), 0)
+	if !ok {
+		return nil, nil, ErrInvalidOTP
+	}
+	plain, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.repo.EnableTOTP(ctx, adminID, step, hashes); err != nil {
+		return nil, nil, err
+	}
+	user, err = s.repo.GetByID(ctx, adminID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, plain, nil
+}
+
+// DisableTOTP removes the second factor after checking the password and a code.
+func (s *AuthService) DisableTOTP(ctx context.Context, adminID int64, input DisableTOTPInput) error {
+	user, err := s.repo.GetByID(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled() {
+		return ErrTOTPNotEnrolled
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
+		return ErrInvalidCredentials
+	}
+	policy, err := s.SecurityPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	if policy.Require2FA {
+		return ErrTOTPRequired
+	}
+	if err := s.verifySecondFactor(ctx, user, input.OTP, input.RecoveryCode); err != nil {
+		return err
+	}
+	return s.repo.DisableTOTP(ctx, adminID)
+}
+
+// SecurityPolicy returns the staff-wide security settings.
+func (s *AuthService) SecurityPolicy(ctx context.Context) (SecurityPolicy, error) {
+	value, ok, err := s.repo.GetSetting(ctx, settingRequire2FA)
+	if err != nil || !ok {
+		return SecurityPolicy{}, err
+	}
+	require, _ := strconv.ParseBool(value)
+	return SecurityPolicy{Require2FA: require}, nil
+}
+
+// SetSecurityPolicy stores the staff-wide security settings. Turning the 2FA
+// requirement on revokes tokens of accounts without 2FA, so their next login is
+// limited to enrollment.
+func (s *AuthService) SetSecurityPolicy(ctx context.Context, policy SecurityPolicy) (SecurityPolicy, error) {
+	if err := s.repo.SetSetting(ctx, settingRequire2FA, strconv.FormatBool(policy.Require2FA)); err != nil {
+		return SecurityPolicy{}, err
+	}
+	if policy.Require2FA {
+		if err := s.repo.RevokeSessionsWithoutTOTP(ctx); err != nil {
+			return SecurityPolicy{}, err
+		}
+	}
+	return policy, nil
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+func (s *AuthService) verifySecondFactor(ctx context.Context, user *User, otp, recoveryCode string) error {
+	switch {
+	case otp != "":
+		step, ok := verifyTOTP(user.TOTPSecret, otp, s.now(), user.TOTPLastStep)
+		if !ok {
+			return ErrInvalidOTP
+		}
+		advanced, err := s.repo.AdvanceTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidOTP
+		}
+		return nil
+	case recoveryCode != "":
+		used, err := s.repo.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(recoveryCode))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidOTP
+		}
+		return nil
+	default:
+		return ErrOTPRequired
+	}
+}
+
+// flagEnrollment marks accounts without 2FA while the policy requires it.
+func (s *AuthService) flagEnrollment(ctx context.Context, user *User) error {
+	policy, err := s.SecurityPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	user.MustEnrollTOTP = policy.Require2FA && !user.TOTPEnabled()
+	return nil
+}
@@ -0,0 +1,147 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidRole is returned for unknown staff roles.
+	ErrInvalidRole = errors.New("unknown role")
+	// ErrInvalidUsername is returned for empty usernames.
+	ErrInvalidUsername = errors.New("username is required")
+	// ErrSelfModification blocks admins from disabling, deleting or demoting themselves.
+	ErrSelfModification = errors.New("cannot disable, delete or change role of your own account")
+	// ErrLastOwner keeps at least one enabled owner.
+	ErrLastOwner = errors.New("at least one active owner is required")
+)
+
+// userStore is the part of Repository that UserService uses.
+type userStore interface {
+	GetByID(ctx context.Context, id int64) (*User, error)
+	List(ctx context.Context) ([]User, error)
+	Create(ctx context.Context, username, passwordHash, role string, mustChangePassword bool) (*User, error)
+	Update(ctx context.Context, id int64, params UpdateParams) (*User, error)
+	Delete(ctx context.Context, id int64) error
+	CountActiveOwners(ctx context.Context) (int, error)
+	DisableTOTP(ctx context.Context, id int64) error
+}
+
+// UserService manages staff accounts.
+type UserService struct {
+	repo userStore
+	auth *AuthService
+}
+
+// NewUserService builds service; auth provides password hashing rules.
+func NewUserService(repo *Repository, auth *AuthService) *UserService {
+	return &UserService{repo: repo, auth: auth}
+}
+
+// List returns all staff accounts.
+func (s *UserService) List(ctx context.Context) ([]User, error) {
+	return s.repo.List(ctx)
+}
+
+// Get returns staff account by id.
+func (s *UserService) Get(ctx context.Context, id int64) (*User, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Create adds a staff account; the new user must change the password on first login.
+func (s *UserService) Create(ctx context.Context, input CreateUserInput) (*User, error) {
+	username := strings.TrimSpace(input.Username)
+	if username == "" {
+		return nil, ErrInvalidUsername
+	}
+	role := strings.ToLower(strings.TrimSpace(input.Role))
+	if !ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	hash, err := s.auth.hashPassword(input.Password)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, username, hash, role, true)
+}
+
+// Update changes role, resets password or 2FA or toggles the account of another user.
+// Every change revokes the user's existing tokens.
+func (s *UserService) Update(ctx context.Context, actorID, id int64, input UpdateUserInput) (*User, error) {
+	current, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	params := UpdateParams{Disabled: input.Disabled}
+	if input.Role != nil {
+		role := strings.ToLower(strings.TrimSpace(*input.Role))
+		if !ValidRole(role) {
+			return nil, ErrInvalidRole
+		}
+		params.Role = &role
+	}
+	if input.Password != nil {
+		hash, err := s.auth.hashPassword(*input.Password)
+		if err != nil {
+			return nil, err
+		}
+		params.PasswordHash = &hash
+		params.MustChangePassword = true
+	}
+
+	demoted := params.Role != nil && *params.Role != current.Role
+	disabling := params.Disabled != nil && *params.Disabled
+	if actorID == id && (demoted || disabling) {
+		return nil, ErrSelfModification
+	}
+	if current.Role == RoleOwner && !current.Disabled() && (demoted || disabling) {
+		if err := s.ensureAnotherOwner(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if input.ResetTOTP {
+		if err := s.repo.DisableTOTP(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	updated, err := s.repo.Update(ctx, id, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.auth.revokeSessions(ctx, id); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Delete removes another user's account.
+func (s *UserService) Delete(ctx context.Context, actorID, id int64) error {
+	if actorID == id {
+		return ErrSelfModification
+	}
+	current, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.Role == RoleOwner && !current.Disabled() {
+		if err := s.ensureAnotherOwner(ctx); err != nil {
+			return err
+		}
+	}
+	if err := s.auth.revokeSessions(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *UserService) ensureAnotherOwner(ctx context.Context) error {
+	owners, err := s.repo.CountActiveOwners(ctx)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
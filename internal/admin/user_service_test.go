@@ -0,0 +1,221 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rashidmailru/kabobfood/internal/tokens"
+)
+
+// memoryUsers mimics Repository: every update bumps the token version.
+type memoryUsers struct {
+	users   map[int64]*User
+	deleted []int64
+}
+
+func (m *memoryUsers) GetByID(_ context.Context, id int64) (*User, error) {
+	u, ok := m.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	cp := *u
+	return &cp, nil
+}
+
+func (m *memoryUsers) List(context.Context) ([]User, error) {
+	var list []User
+	for _, u := range m.users {
+		list = append(list, *u)
+	}
+	return list, nil
+}
+
+func (m *memoryUsers) Create(_ context.Context, username, passwordHash, role string, mustChangePassword bool) (*User, error) {
+	u := &User{ID: int64(len(m.users) + 1), Username: username, PasswordHash: passwordHash, Role: role, MustChangePassword: mustChangePassword}
+	m.users[u.ID] = u
+	cp := *u
+	return &cp, nil
+}
+
+func (m *memoryUsers) Update(_ context.Context, id int64, params UpdateParams) (*User, error) {
+	u, ok := m.users[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	if params.Role != nil {
+		u.Role = *params.Role
+	}
+	if params.PasswordHash != nil {
+		u.PasswordHash = *params.PasswordHash
+		u.MustChangePassword = params.MustChangePassword
+	}
+	if params.Disabled != nil {
+		u.DisabledAt = nil
+		if *params.Disabled {
+			now := time.Now()
+			u.DisabledAt = &now
+		}
+	}
+	u.TokenVersion++
+	cp := *u
+	return &cp, nil
+}
+
+func (m *memoryUsers) Delete(_ context.Context, id int64) error {
+	if _, ok := m.users[id]; !ok {
+		return ErrUserNotFound
+	}
+	delete(m.users, id)
+	m.deleted = append(m.deleted, id)
+	return nil
+}
+
+func (m *memoryUsers) CountActiveOwners(context.Context) (int, error) {
+	n := 0
+	for _, u := range m.users {
+		if u.Role == RoleOwner && !u.Disabled() {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *memoryUsers) DisableTOTP(context.Context, int64) error { return nil }
+
+// revokedSubjects records which subjects had their refresh tokens revoked.
+type revokedSubjects struct {
+	ids []int64
+}
+
+func (r *revokedSubjects) Create(_ context.Context, t tokens.RefreshToken) (*tokens.RefreshToken, error) {
+	return &t, nil
+}
+
+func (r *revokedSubjects) GetByHash(context.Context, string) (*tokens.RefreshToken, error) {
+	return nil, tokens.ErrTokenNotFound
+}
+
+func (r *revokedSubjects) Replace(_ context.Context, _ int64, next tokens.RefreshToken) (*tokens.RefreshToken, error) {
+	return &next, nil
+}
+
+func (r *revokedSubjects) RevokeFamily(context.Context, string, time.Time) ([]tokens.RefreshToken, error) {
+	return nil, nil
+}
+
+func (r *revokedSubjects) RevokeSubject(_ context.Context, subject tokens.Subject, _ time.Time) ([]tokens.RefreshToken, error) {
+	if subject.Kind == tokens.SubjectAdmin {
+		r.ids = append(r.ids, subject.ID)
+	}
+	return nil, nil
+}
+
+func newUserServiceFixture(t *testing.T) (*UserService, *memoryUsers, *revokedSubjects) {
+	t.Helper()
+	store := &memoryUsers{users: map[int64]*User{
+		1: {ID: 1, Username: "owner", Role: RoleOwner},
+		2: {ID: 2, Username: "manager", Role: RoleManager},
+		3: {ID: 3, Username: "cook", Role: RoleKitchen},
+	}}
+	revoked := &revokedSubjects{}
+	tokenService, err := tokens.NewService(tokens.Config{Store: revoked})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &UserService{repo: store, auth: &AuthService{tokens: tokenService}}, store, revoked
+}
+
+func TestUserServiceGuards(t *testing.T) {
+	ctx := context.Background()
+	manager, owner, disabled := RoleManager, RoleOwner, true
+
+	tests := []struct {
+		name    string
+		actorID int64
+		id      int64
+		input   UpdateUserInput
+		want    error
+	}{
+		{name: "last owner cannot be demoted", actorID: 2, id: 1, input: UpdateUserInput{Role: &manager}, want: ErrLastOwner},
+		{name: "last owner cannot be disabled", actorID: 2, id: 1, input: UpdateUserInput{Disabled: &disabled}, want: ErrLastOwner},
+		{name: "self demote", actorID: 1, id: 1, input: UpdateUserInput{Role: &manager}, want: ErrSelfModification},
+		{name: "self disable", actorID: 2, id: 2, input: UpdateUserInput{Disabled: &disabled}, want: ErrSelfModification},
+		{name: "keeping own role is allowed", actorID: 1, id: 1, input: UpdateUserInput{Role: &owner}},
+		{name: "another user can be disabled", actorID: 1, id: 3, input: UpdateUserInput{Disabled: &disabled}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, _, _ := newUserServiceFixture(t)
+			_, err := s.Update(ctx, tc.actorID, tc.id, tc.input)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("got %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	s, store, _ := newUserServiceFixture(t)
+	if err := s.Delete(ctx, 1, 1); !errors.Is(err, ErrSelfModification) {
+		t.Fatalf("self delete: got %v", err)
+	}
+	if err := s.Delete(ctx, 2, 1); !errors.Is(err, ErrLastOwner) {
+		t.Fatalf("last owner delete: got %v", err)
+	}
+	store.users[4] = &User{ID: 4, Username: "second", Role: RoleOwner}
+	if err := s.Delete(ctx, 4, 1); err != nil {
+		t.Fatalf("owner with another owner left must be deletable, got %v", err)
+	}
+}
+
+func TestUserServiceRevokesSessions(t *testing.T) {
+	ctx := context.Background()
+	s, store, revoked := newUserServiceFixture(t)
+	disabled := true
+
+	updated, err := s.Update(ctx, 1, 3, UpdateUserInput{Disabled: &disabled})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.Disabled() || updated.TokenVersion != 1 {
+		t.Fatalf("expected a disabled account with a bumped token version, got %+v", updated)
+	}
+	if err := s.Delete(ctx, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(revoked.ids) != 2 || revoked.ids[0] != 3 || revoked.ids[1] != 2 {
+		t.Fatalf("expected sessions of 3 and 2 to be revoked, got %v", revoked.ids)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != 2 {
+		t.Fatalf("expected account 2 to be deleted, got %v", store.deleted)
+	}
+}
+
+func TestUserServicePasswordReset(t *testing.T) {
+	ctx := context.Background()
+	s, _, revoked := newUserServiceFixture(t)
+
+	short := "short"
+	if _, err := s.Update(ctx, 1, 3, UpdateUserInput{Password: &short}); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("expected weak password error, got %v", err)
+	}
+	password := "new-secret-password"
+	updated, err := s.Update(ctx, 1, 3, UpdateUserInput{Password: &password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.MustChangePassword || updated.PasswordHash == "" || updated.PasswordHash == password {
+		t.Fatalf("expected a hashed password that must be changed, got %+v", updated)
+	}
+	if len(revoked.ids) != 1 || revoked.ids[0] != 3 {
+		t.Fatalf("expected sessions of 3 to be revoked, got %v", revoked.ids)
+	}
+
+	created, err := s.Create(ctx, CreateUserInput{Username: " courier ", Password: password, Role: "Courier"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Username != "courier" || created.Role != RoleCourier || !created.MustChangePassword {
+		t.Fatalf("unexpected new account %+v", created)
+	}
+}
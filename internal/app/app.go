@@ -2,6 +2,9 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/redis/go-redis/v9"
@@ -9,6 +12,7 @@ import (
 
 	"github.com/rashidmailru/kabobfood/internal/addresses"
 	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/audit"
 	"github.com/rashidmailru/kabobfood/internal/auth"
 	cachepkg "github.com/rashidmailru/kabobfood/internal/cache"
 	"github.com/rashidmailru/kabobfood/internal/config"
@@ -16,14 +20,21 @@ import (
 	kabobhttp "github.com/rashidmailru/kabobfood/internal/http"
 	"github.com/rashidmailru/kabobfood/internal/http/handlers"
 	"github.com/rashidmailru/kabobfood/internal/http/middleware"
+	"github.com/rashidmailru/kabobfood/internal/kitchen"
+	"github.com/rashidmailru/kabobfood/internal/lockout"
 	"github.com/rashidmailru/kabobfood/internal/menu"
 	"github.com/rashidmailru/kabobfood/internal/metrics"
 	"github.com/rashidmailru/kabobfood/internal/notifications"
 	"github.com/rashidmailru/kabobfood/internal/orders"
+	"github.com/rashidmailru/kabobfood/internal/payments"
+	"github.com/rashidmailru/kabobfood/internal/pricing"
 	"github.com/rashidmailru/kabobfood/internal/products"
 	"github.com/rashidmailru/kabobfood/internal/profile"
+	"github.com/rashidmailru/kabobfood/internal/receipt"
 	"github.com/rashidmailru/kabobfood/internal/regions"
 	"github.com/rashidmailru/kabobfood/internal/server"
+	"github.com/rashidmailru/kabobfood/internal/storage"
+	"github.com/rashidmailru/kabobfood/internal/tokens"
 	"github.com/rashidmailru/kabobfood/internal/users"
 )
 
@@ -37,6 +48,7 @@ type App struct {
 	server *server.Server
 	dbPool *pgxpool.Pool
 	cache  *redis.Client
+	store  *cachepkg.Store
 }
 
 // New constructs the application.
@@ -49,27 +61,65 @@ func New(cfg *config.Config, log *zap.Logger) (*App, error) {
 
 	redisClient, err := cachepkg.NewRedis(ctx, cfg.Redis)
 	if err != nil {
-		// Continue without Redis cache if it is misconfigured or unavailable.
-		log.Warn("redis unavailable, running without cache", zap.Error(err))
+		// Continue with the in-process cache alone if Redis is misconfigured or unavailable.
+		log.Warn("redis unavailable, running with local cache only", zap.Error(err))
 		redisClient = nil
 	}
 
 	metricsCollector := metrics.New()
+	cacheStore := cachepkg.NewStore(redisClient, cachepkg.StoreOptions{
+		LocalSize: cfg.Cache.LocalSize,
+		Metrics:   metricsCollector,
+	})
 
 	userRepo := users.NewRepository(pool)
 	addressRepo := addresses.NewRepository(pool)
 	addressService := addresses.NewService(addressRepo)
 	menuRepo := menu.NewRepository(pool)
-	productsRepo := products.NewRepository(pool)
+	priceRuleRepo := pricing.NewRepository(pool)
+	priceEngine := pricing.NewEngine(priceRuleRepo, pricingLocation(cfg.Pricing, log))
+	productsRepo := products.NewRepository(pool, priceEngine)
 	regionRepo := regions.NewRepository(pool)
 	adminRepo := admin.NewRepository(pool)
 
+	userKeys, err := newKeyring(cfg.JWT.Secret, cfg.JWT.SigningKeys, cfg.JWT.Issuer, cfg.JWT.Audience, cfg.JWT.LegacyUntil)
+	if err != nil {
+		pool.Close()
+		if redisClient != nil {
+			redisClient.Close()
+		}
+		return nil, err
+	}
+	adminKeys, err := newKeyring(cfg.Admin.JWTSecret, cfg.Admin.JWTSigningKeys, cfg.JWT.Issuer, cfg.Admin.JWTAudience, cfg.Admin.JWTLegacyUntil)
+	if err != nil {
+		pool.Close()
+		if redisClient != nil {
+			redisClient.Close()
+		}
+		return nil, err
+	}
+
+	tokenService, err := tokens.NewService(tokens.Config{
+		Store:      tokens.NewRepository(pool),
+		Denylist:   tokens.NewDenylist(redisClient, nil),
+		RefreshTTL: cfg.JWT.RefreshExpiration,
+		Logger:     log,
+	})
+	if err != nil {
+		pool.Close()
+		if redisClient != nil {
+			redisClient.Close()
+		}
+		return nil, err
+	}
+
 	authService, err := auth.NewService(auth.Config{
 		UserRepo:    userRepo,
 		BotToken:    cfg.Telegram.BotToken,
-		JWTSecret:   cfg.JWT.Secret,
+		Keys:        userKeys,
 		JWTExpiry:   cfg.JWT.Expiration,
 		InitDataTTL: cfg.Auth.TelegramInitTTL,
+		Tokens:      tokenService,
 	})
 	if err != nil {
 		pool.Close()
@@ -82,22 +132,73 @@ func New(cfg *config.Config, log *zap.Logger) (*App, error) {
 	menuService := menu.NewService(menu.ServiceConfig{
 		MenuRepo:   menuRepo,
 		RegionRepo: regionRepo,
-		Cache:      redisClient,
+		Cache:      cacheStore,
+		Prices:     priceEngine,
 		MenuTTL:    cfg.Cache.MenuTTL,
 		RegionsTTL: cfg.Cache.RegionsTTL,
+		SearchTTL:  cfg.Cache.SearchTTL,
+		StaleTTL:   cfg.Cache.StaleTTL,
 	})
-	adminMenuService := admin.NewMenuService(menuRepo, redisClient)
-	adminRegionService := admin.NewRegionService(regionRepo, redisClient)
+	auditService := audit.NewService(audit.NewRepository(pool), log)
+	imageStorage, err := newImageStorage(cfg.Storage)
+	if err != nil {
+		pool.Close()
+		if redisClient != nil {
+			redisClient.Close()
+		}
+		return nil, err
+	}
+	adminMenuService := admin.NewMenuService(menuRepo, cacheStore, auditService, imageStorage)
+	adminRegionService := admin.NewRegionService(regionRepo, cacheStore, auditService)
+	adminPriceRuleService := admin.NewPriceRuleService(priceRuleRepo, cacheStore, auditService)
 
 	profileService := profile.NewService(userRepo, addressService)
-	ordersRepo := orders.NewRepository(pool)
+	ordersRepo := orders.NewRepository(pool, cacheStore)
 	notifier := notifications.NewTelegramNotifier(notifications.TelegramConfig{
 		BotToken:    cfg.Telegram.BotToken,
 		AdminChatID: cfg.Telegram.AdminChatID,
 	})
-	ordersService := orders.NewService(ordersRepo, productsRepo, addressRepo, regionRepo, userRepo, notifier, metricsCollector)
-	adminOrdersService := orders.NewAdminService(ordersRepo, userRepo, notifier)
-	adminAuthService, err := admin.NewAuthService(admin.AuthConfig{Repo: adminRepo, JWTSecret: cfg.JWT.Secret})
+	paymentsService := payments.NewService(payments.ServiceConfig{
+		Repo:         payments.NewRepository(pool),
+		OrdersRepo:   ordersRepo,
+		ProductsRepo: productsRepo,
+		UserRepo:     userRepo,
+		Notifier:     notifier,
+		Providers:    paymentProviders(cfg.Payments, cfg.Telegram.BotToken),
+		Currency:     cfg.Payments.Currency,
+		Logger:       log,
+	})
+	ordersService := orders.NewService(ordersRepo, productsRepo, addressRepo, regionRepo, userRepo, notifier, metricsCollector, paymentsService)
+	receiptRenderer := receipt.NewRenderer(receiptOptions(cfg.Printer, log))
+	var orderPrinter orders.OrderPrinter
+	if cfg.Printer.AutoPrint {
+		layouts := make([]receipt.Layout, 0, len(cfg.Printer.Layouts))
+		for _, raw := range cfg.Printer.Layouts {
+			if layout, err := receipt.ParseLayout(raw); err == nil {
+				layouts = append(layouts, layout)
+			}
+		}
+		if printer := receipt.NewNetworkPrinter(receipt.PrinterConfig{
+			Addr:     cfg.Printer.Addr,
+			Timeout:  cfg.Printer.Timeout,
+			Layouts:  layouts,
+			Renderer: receiptRenderer,
+			Logger:   log,
+		}); printer != nil {
+			orderPrinter = printer
+		} else {
+			log.Warn("printer auto-print enabled without PRINTER_ADDR, skipping")
+		}
+	}
+	adminOrdersService := orders.NewAdminService(ordersRepo, userRepo, notifier, orderPrinter, paymentsService, auditService)
+	adminAuthService, err := admin.NewAuthService(admin.AuthConfig{
+		Repo:            adminRepo,
+		Keys:            adminKeys,
+		JWTExpiry:       cfg.Admin.JWTExpiration,
+		DefaultPassword: cfg.Admin.DefaultPassword,
+		TOTPIssuer:      cfg.Admin.TOTPIssuer,
+		Tokens:          tokenService,
+	})
 	if err != nil {
 		pool.Close()
 		if redisClient != nil {
@@ -105,6 +206,8 @@ func New(cfg *config.Config, log *zap.Logger) (*App, error) {
 		}
 		return nil, err
 	}
+	tokenService.RegisterMinter(tokens.SubjectUser, authService, 0)
+	tokenService.RegisterMinter(tokens.SubjectAdmin, adminAuthService, cfg.Admin.RefreshExpiration)
 	if err := adminAuthService.EnsureDefaultAdmin(ctx, cfg.Admin.DefaultUsername, cfg.Admin.DefaultPassword); err != nil {
 		pool.Close()
 		if redisClient != nil {
@@ -112,25 +215,48 @@ func New(cfg *config.Config, log *zap.Logger) (*App, error) {
 		}
 		return nil, err
 	}
+	if err := adminAuthService.EnsureKitchenUser(ctx, cfg.Admin.KitchenUsername, cfg.Admin.KitchenPassword); err != nil {
+		pool.Close()
+		if redisClient != nil {
+			redisClient.Close()
+		}
+		return nil, err
+	}
+	kitchenService := kitchen.NewService(ordersRepo)
 	rateLimiterUsers := middleware.NewRateLimiter(cfg.RateLimit.UserLimit, cfg.RateLimit.Window)
 	rateLimiterAdmins := middleware.NewRateLimiter(cfg.RateLimit.AdminLimit, cfg.RateLimit.Window)
+	rateLimiterLogin := middleware.NewRateLimiter(cfg.RateLimit.LoginLimit, cfg.RateLimit.Window)
+	adminLoginGuard := newLockoutGuard("admin_login", cfg.Lockout, redisClient, notifier, metricsCollector, log)
+	botRegisterGuard := newLockoutGuard("bot_register", cfg.Lockout, redisClient, notifier, metricsCollector, log)
 
 	profileHandler := handlers.NewProfileHandler(profileService)
 	addressesHandler := handlers.NewAddressesHandler(addressService)
 	ordersHandler := handlers.NewOrdersHandler(ordersService)
-	protectedHandlers := []kabobhttp.RouteRegister{profileHandler, addressesHandler, ordersHandler}
-	jwtMiddleware := middleware.JWTAuth(cfg.JWT.Secret)
-	adminAuthHandler := handlers.NewAdminAuthHandler(adminAuthService, cfg.JWT.Secret, cfg.Admin.JWTExpiration)
-	adminMenuHandler := handlers.NewAdminMenuHandler(adminMenuService)
+	paymentsHandler := handlers.NewPaymentsHandler(paymentsService)
+	protectedHandlers := []kabobhttp.RouteRegister{profileHandler, addressesHandler, ordersHandler, paymentsHandler}
+	jwtMiddleware := middleware.JWTAuth(userKeys, tokenService)
+	adminAuthHandler := handlers.NewAdminAuthHandler(adminAuthService, adminLoginGuard)
+	adminAccountHandler := handlers.NewAdminAccountHandler(adminAuthService)
+	adminUsersHandler := handlers.NewAdminUsersHandler(admin.NewUserService(adminRepo, adminAuthService))
+	adminMenuHandler := handlers.NewAdminMenuHandler(adminMenuService, cfg.Storage.MaxUploadSize)
 	adminRegionHandler := handlers.NewAdminRegionHandler(adminRegionService)
-	adminOrdersHandler := handlers.NewAdminOrdersHandler(adminOrdersService)
-	adminHandlers := []kabobhttp.RouteRegister{adminMenuHandler, adminRegionHandler, adminOrdersHandler}
-	adminMiddleware := middleware.AdminJWT(cfg.JWT.Secret)
+	adminPriceRulesHandler := handlers.NewAdminPriceRulesHandler(adminPriceRuleService)
+	adminOrdersHandler := handlers.NewAdminOrdersHandler(adminOrdersService, receiptRenderer)
+	adminPaymentsHandler := handlers.NewAdminPaymentsHandler(paymentsService)
+	adminAuditHandler := handlers.NewAdminAuditHandler(auditService)
+	kitchenHandler := handlers.NewKitchenHandler(kitchenService, cfg.Kitchen.StreamInterval)
+	adminHandlers := []kabobhttp.RouteRegister{adminAccountHandler, adminUsersHandler, adminMenuHandler, adminRegionHandler, adminPriceRulesHandler, adminOrdersHandler, adminPaymentsHandler, adminAuditHandler, kitchenHandler}
+	adminMiddleware := middleware.AdminJWT(adminKeys, adminAuthService, tokenService)
 
 	healthHandler := handlers.NewHealthHandler(Version)
-	authHandler := handlers.NewAuthHandler(authService)
-	botHandler := handlers.NewBotHandler(authService)
-	menuHandler := handlers.NewMenuHandler(menuService)
+	authHandler := handlers.NewAuthHandler(authService, tokenService)
+	botHandler := handlers.NewBotHandler(authService, botRegisterGuard)
+	menuHandler := handlers.NewMenuHandler(menuService, cfg.HTTP.CacheMaxAge)
+	paymentCallbacksHandler := handlers.NewPaymentCallbacksHandler(paymentsService)
+	publicHandlers := []kabobhttp.RouteRegister{paymentCallbacksHandler}
+	if local, ok := imageStorage.(*storage.Local); ok {
+		publicHandlers = append(publicHandlers, handlers.NewMediaHandler(local.Dir()))
+	}
 
 	router := kabobhttp.NewRouter(kabobhttp.RouterParams{
 		Logger:            log,
@@ -141,6 +267,8 @@ func New(cfg *config.Config, log *zap.Logger) (*App, error) {
 		BotHandler:        botHandler,
 		MenuHandler:       menuHandler,
 		AdminAuthHandler:  adminAuthHandler,
+		LoginMiddleware:   rateLimiterLogin.Middleware(),
+		PublicHandlers:    publicHandlers,
 		AuthMiddleware:    middleware.Chain(rateLimiterUsers.Middleware(), jwtMiddleware),
 		ProtectedHandlers: protectedHandlers,
 		AdminMiddleware:   middleware.Chain(rateLimiterAdmins.Middleware(), adminMiddleware),
@@ -150,7 +278,137 @@ func New(cfg *config.Config, log *zap.Logger) (*App, error) {
 
 	srv := server.New(cfg, router, log)
 
-	return &App{cfg: cfg, log: log, server: srv, dbPool: pool, cache: redisClient}, nil
+	return &App{cfg: cfg, log: log, server: srv, dbPool: pool, cache: redisClient, store: cacheStore}, nil
+}
+
+// newImageStorage builds the product image backend selected by STORAGE_DRIVER.
+func newImageStorage(cfg config.StorageConfig) (storage.Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		publicURL := cfg.PublicURL
+		if publicURL == "" {
+			publicURL = "/media"
+		}
+		return storage.NewLocal(cfg.LocalDir, publicURL)
+	case "s3":
+		return storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+			PublicURL: cfg.PublicURL,
+		}, nil)
+	case "memory":
+		return storage.NewMemory(cfg.PublicURL), nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", cfg.Driver)
+	}
+}
+
+func pricingLocation(cfg config.PricingConfig, log *zap.Logger) *time.Location {
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		log.Warn("invalid pricing timezone, using local", zap.String("timezone", cfg.Timezone), zap.Error(err))
+		return time.Local
+	}
+	return loc
+}
+
+func receiptOptions(cfg config.PrinterConfig, log *zap.Logger) receipt.Options {
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		log.Warn("invalid printer timezone, using local", zap.String("timezone", cfg.Timezone), zap.Error(err))
+		loc = time.Local
+	}
+	return receipt.Options{
+		Width:    cfg.Width,
+		CodePage: byte(cfg.CodePage),
+		Header:   cfg.Header,
+		Location: loc,
+	}
+}
+
+// newKeyring builds a JWT keyring from "kid=ALG:material[@until]" specs, or from a
+// single HMAC secret when no specs are configured. Until legacyUntil, kid-less
+// tokens signed with secret before rotation support still verify.
+func newKeyring(secret string, specs []string, issuer, audience string, legacyUntil time.Time) (*tokens.Keyring, error) {
+	keys := make([]*tokens.Key, 0, len(specs))
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		key, err := tokens.ParseKeySpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		keys = append(keys, tokens.NewHMACKey("", secret))
+	}
+	ring, err := tokens.NewKeyring(issuer, audience, keys, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !legacyUntil.IsZero() {
+		ring.AcceptLegacy(secret, legacyUntil)
+	}
+	return ring, nil
+}
+
+// newLockoutGuard builds brute-force protection for scope, sharing counters through Redis when available.
+func newLockoutGuard(scope string, cfg config.LockoutConfig, redisClient *redis.Client, notifier *notifications.TelegramNotifier, m *metrics.Metrics, log *zap.Logger) *lockout.Guard {
+	opts := lockout.Options{Alerter: notifier, Metrics: m, Logger: log}
+	if redisClient != nil {
+		opts.Store = lockout.NewRedisStore(redisClient)
+	}
+	return lockout.NewGuard(scope, lockout.Config{
+		FreeAttempts:      cfg.FreeAttempts,
+		BaseDelay:         cfg.BaseDelay,
+		MaxDelay:          cfg.MaxDelay,
+		UsernameThreshold: cfg.UsernameThreshold,
+		IPThreshold:       cfg.IPThreshold,
+		Window:            cfg.Window,
+		Duration:          cfg.Duration,
+	}, opts)
+}
+
+// paymentProviders builds providers for offline methods and configured online gateways.
+func paymentProviders(cfg config.PaymentsConfig, botToken string) []payments.Provider {
+	providers := make([]payments.Provider, 0, len(cfg.OfflineMethods)+4)
+	for _, method := range cfg.OfflineMethods {
+		if method = strings.ToLower(strings.TrimSpace(method)); method != "" {
+			providers = append(providers, payments.NewOfflineProvider(method))
+		}
+	}
+	if cfg.PaymeMerchantID != "" && cfg.PaymeKey != "" {
+		providers = append(providers, payments.NewPaymeProvider(payments.PaymeConfig{
+			MerchantID:  cfg.PaymeMerchantID,
+			Key:         cfg.PaymeKey,
+			CheckoutURL: cfg.PaymeCheckoutURL,
+			ReturnURL:   cfg.ReturnURL,
+		}))
+	}
+	if cfg.ClickServiceID != "" && cfg.ClickSecretKey != "" {
+		providers = append(providers, payments.NewClickProvider(payments.ClickConfig{
+			ServiceID:  cfg.ClickServiceID,
+			MerchantID: cfg.ClickMerchantID,
+			SecretKey:  cfg.ClickSecretKey,
+			ReturnURL:  cfg.ReturnURL,
+		}))
+	}
+	if cfg.TelegramProviderToken != "" && botToken != "" {
+		providers = append(providers, payments.NewTelegramProvider(payments.TelegramConfig{
+			BotToken:      botToken,
+			ProviderToken: cfg.TelegramProviderToken,
+		}))
+	}
+	if cfg.FakeEnabled {
+		providers = append(providers, payments.NewFakeProvider(cfg.FakeSecret, cfg.PublicURL))
+	}
+	return providers
 }
 
 // Run starts handling HTTP traffic.
@@ -166,6 +424,7 @@ func (a *App) Shutdown(ctx context.Context) error {
 	if a.dbPool != nil {
 		a.dbPool.Close()
 	}
+	_ = a.store.Close()
 	if a.cache != nil {
 		_ = a.cache.Close()
 	}
@@ -0,0 +1,23 @@
+package audit
+
+import "context"
+
+// Actor identifies the staff member behind a request.
+type Actor struct {
+	ID   int64
+	Role string
+	IP   string
+}
+
+type actorKey struct{}
+
+// WithActor attaches the acting staff member to ctx.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor stored in ctx, if any.
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// snapshot encodes v as a JSON object; nil values give a nil snapshot.
+func snapshot(v any) (json.RawMessage, map[string]any, error) {
+	if v == nil {
+		return nil, nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		// Not an object: compare it as a single value.
+		var value any
+		_ = json.Unmarshal(raw, &value)
+		fields = map[string]any{"value": value}
+	}
+	return raw, fields, nil
+}
+
+// diff returns the top-level fields whose JSON values differ between before and after.
+func diff(before, after map[string]any) map[string]Change {
+	changes := make(map[string]Change)
+	for k, b := range before {
+		if a := after[k]; !reflect.DeepEqual(b, a) {
+			changes[k] = Change{Before: b, After: a}
+		}
+	}
+	for k, a := range after {
+		if _, seen := before[k]; !seen {
+			changes[k] = Change{Before: nil, After: a}
+		}
+	}
+	return changes
+}
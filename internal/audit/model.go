@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions recorded for admin mutations.
+const (
+	ActionCreate       = "create"
+	ActionUpdate       = "update"
+	ActionArchive      = "archive"
+	ActionRestore      = "restore"
+	ActionDelete       = "delete"
+	ActionReorder      = "reorder"
+	ActionStatusChange = "status_change"
+	ActionPublish      = "publish"
+	ActionRollback     = "rollback"
+)
+
+// Entity types recorded in the log.
+const (
+	EntityCategory  = "category"
+	EntityProduct   = "product"
+	EntityRegion    = "region"
+	EntityOrder     = "order"
+	EntityPriceRule = "price_rule"
+	EntityMenu      = "menu"
+)
+
+// Entry is one recorded admin action.
+type Entry struct {
+	ID            int64             `json:"id"`
+	ActorID       *int64            `json:"actor_id"`
+	ActorUsername string            `json:"actor_username,omitempty"`
+	ActorRole     string            `json:"actor_role,omitempty"`
+	Action        string            `json:"action"`
+	EntityType    string            `json:"entity_type"`
+	EntityID      int64             `json:"entity_id"`
+	Before        json.RawMessage   `json:"before,omitempty"`
+	After         json.RawMessage   `json:"after,omitempty"`
+	Changes       map[string]Change `json:"changes"`
+	IP            string            `json:"ip,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// Change holds the old and new value of a single field.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// ListParams filters the audit log.
+type ListParams struct {
+	ActorID    int64
+	Action     string
+	EntityType string
+	EntityID   int64
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// Page is a slice of the log with the total number of matching entries.
+type Page struct {
+	Entries []Entry `json:"entries"`
+	Total   int     `json:"total"`
+	Limit   int     `json:"limit"`
+	Offset  int     `json:"offset"`
+}
@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists audit entries.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository builds repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+var errNilPool = errors.New("audit repository: nil pool")
+
+// Insert stores an entry.
+func (r *Repository) Insert(ctx context.Context, entry Entry) error {
+	if r.pool == nil {
+		return errNilPool
+	}
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+	const query = `
+INSERT INTO audit_log (actor_id, actor_role, action, entity_type, entity_id, before_data, after_data, changes, ip)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+`
+	_, err = r.pool.Exec(ctx, query, entry.ActorID, entry.ActorRole, entry.Action, entry.EntityType, entry.EntityID,
+		nullJSON(entry.Before), nullJSON(entry.After), changes, entry.IP)
+	return err
+}
+
+// List returns entries matching params, newest first, with the total match count.
+func (r *Repository) List(ctx context.Context, params ListParams) ([]Entry, int, error) {
+	if r.pool == nil {
+		return nil, 0, errNilPool
+	}
+	query := `
+SELECT a.id, a.actor_id, COALESCE(u.username,''), a.actor_role, a.action, a.entity_type, a.entity_id,
+       a.before_data, a.after_data, a.changes, a.ip, a.created_at, COUNT(*) OVER()
+FROM audit_log a
+LEFT JOIN admin_users u ON u.id = a.actor_id
+WHERE 1=1`
+	args := []interface{}{}
+	idx := 1
+	if params.ActorID != 0 {
+		query += fmt.Sprintf(" AND a.actor_id = $%d", idx)
+		args = append(args, params.ActorID)
+		idx++
+	}
+	if params.Action != "" {
+		query += fmt.Sprintf(" AND a.action = $%d", idx)
+		args = append(args, params.Action)
+		idx++
+	}
+	if params.EntityType != "" {
+		query += fmt.Sprintf(" AND a.entity_type = $%d", idx)
+		args = append(args, params.EntityType)
+		idx++
+	}
+	if params.EntityID != 0 {
+		query += fmt.Sprintf(" AND a.entity_id = $%d", idx)
+		args = append(args, params.EntityID)
+		idx++
+	}
+	if params.From != nil {
+		query += fmt.Sprintf(" AND a.created_at >= $%d", idx)
+		args = append(args, *params.From)
+		idx++
+	}
+	if params.To != nil {
+		query += fmt.Sprintf(" AND a.created_at <= $%d", idx)
+		args = append(args, *params.To)
+		idx++
+	}
+	query += fmt.Sprintf(" ORDER BY a.created_at DESC, a.id DESC LIMIT $%d OFFSET $%d", idx, idx+1)
+	args = append(args, params.Limit, params.Offset)
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	total := 0
+	for rows.Next() {
+		var (
+			entry                 Entry
+			before, after, change []byte
+		)
+		if err := rows.Scan(&entry.ID, &entry.ActorID, &entry.ActorUsername, &entry.ActorRole, &entry.Action, &entry.EntityType, &entry.EntityID,
+			&before, &after, &change, &entry.IP, &entry.CreatedAt, &total); err != nil {
+			return nil, 0, err
+		}
+		entry.Before, entry.After = before, after
+		if err := json.Unmarshal(change, &entry.Changes); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// nullJSON stores empty snapshots as SQL NULL.
+func nullJSON(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return []byte(raw)
+}
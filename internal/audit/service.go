@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+// Service records admin mutations and lists them for review.
+type Service struct {
+	repo   *Repository
+	logger *zap.Logger
+}
+
+// NewService builds service; logger is optional.
+func NewService(repo *Repository, logger *zap.Logger) *Service {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Service{repo: repo, logger: logger}
+}
+
+// Record stores who did action to the entity, with before/after snapshots and the
+// changed fields. The mutation has already been applied, so failures are logged
+// rather than returned. A nil service records nothing.
+func (s *Service) Record(ctx context.Context, action, entityType string, entityID int64, before, after any) {
+	if s == nil {
+		return
+	}
+	entry, err := buildEntry(ctx, action, entityType, entityID, before, after)
+	if err == nil {
+		err = s.repo.Insert(ctx, entry)
+	}
+	if err != nil {
+		s.logger.Warn("audit record failed",
+			zap.String("action", action),
+			zap.String("entity_type", entityType),
+			zap.Int64("entity_id", entityID),
+			zap.Error(err))
+	}
+}
+
+// List returns a page of entries matching params.
+func (s *Service) List(ctx context.Context, params ListParams) (*Page, error) {
+	if params.Limit <= 0 {
+		params.Limit = defaultLimit
+	}
+	if params.Limit > maxLimit {
+		params.Limit = maxLimit
+	}
+	if params.Offset < 0 {
+		params.Offset = 0
+	}
+	entries, total, err := s.repo.List(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return &Page{Entries: entries, Total: total, Limit: params.Limit, Offset: params.Offset}, nil
+}
+
+func buildEntry(ctx context.Context, action, entityType string, entityID int64, before, after any) (Entry, error) {
+	beforeRaw, beforeFields, err := snapshot(before)
+	if err != nil {
+		return Entry{}, err
+	}
+	afterRaw, afterFields, err := snapshot(after)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry := Entry{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     beforeRaw,
+		After:      afterRaw,
+		Changes:    diff(beforeFields, afterFields),
+	}
+	if actor, ok := ActorFrom(ctx); ok {
+		if actor.ID != 0 {
+			id := actor.ID
+			entry.ActorID = &id
+		}
+		entry.ActorRole = actor.Role
+		entry.IP = actor.IP
+	}
+	return entry, nil
+}
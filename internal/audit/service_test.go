@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"context"
+	"testing"
+)
+
+type item struct {
+	ID    int64   `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+	Tags  []string
+}
+
+func TestBuildEntryDiff(t *testing.T) {
+	ctx := WithActor(context.Background(), Actor{ID: 7, Role: "manager", IP: "10.0.0.1"})
+	before := &item{ID: 1, Name: "Plov", Price: 30000, Tags: []string{"rice"}}
+	after := &item{ID: 1, Name: "Plov", Price: 35000, Tags: []string{"rice", "hot"}}
+
+	entry, err := buildEntry(ctx, ActionUpdate, EntityProduct, 1, before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.ActorID == nil || *entry.ActorID != 7 || entry.ActorRole != "manager" || entry.IP != "10.0.0.1" {
+		t.Fatalf("unexpected actor %+v", entry)
+	}
+	if len(entry.Changes) != 2 {
+		t.Fatalf("expected price and tags to change, got %v", entry.Changes)
+	}
+	if c := entry.Changes["price"]; c.Before != 30000.0 || c.After != 35000.0 {
+		t.Fatalf("unexpected price change %+v", c)
+	}
+	if _, ok := entry.Changes["name"]; ok {
+		t.Fatal("unchanged fields must not be listed")
+	}
+}
+
+func TestBuildEntryMissingSnapshots(t *testing.T) {
+	var missing *item
+	created, err := buildEntry(context.Background(), ActionCreate, EntityProduct, 2, missing, &item{ID: 2, Name: "Somsa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Before != nil || created.ActorID != nil {
+		t.Fatalf("expected no before snapshot and no actor, got %+v", created)
+	}
+	if c := created.Changes["name"]; c.Before != nil || c.After != "Somsa" {
+		t.Fatalf("unexpected create change %+v", c)
+	}
+
+	removed, err := buildEntry(context.Background(), ActionArchive, EntityProduct, 2, &item{ID: 2, Name: "Somsa"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed.After != nil || removed.Changes["name"].Before != "Somsa" {
+		t.Fatalf("unexpected entry without after snapshot %+v", removed)
+	}
+}
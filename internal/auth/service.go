@@ -15,6 +15,7 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 
+	"github.com/rashidmailru/kabobfood/internal/tokens"
 	"github.com/rashidmailru/kabobfood/internal/users"
 )
 
@@ -22,23 +23,27 @@ import (
 type Service struct {
 	userRepo    UserRepository
 	botToken    string
-	jwtSecret   []byte
+	keys        *tokens.Keyring
 	jwtExpiry   time.Duration
 	initDataTTL time.Duration
+	tokens      *tokens.Service
 }
 
 // Config gathers dependencies for Service.
 type Config struct {
 	UserRepo    UserRepository
 	BotToken    string
-	JWTSecret   string
+	Keys        *tokens.Keyring
 	JWTExpiry   time.Duration
 	InitDataTTL time.Duration
+	// Tokens issues refresh tokens; without it only access tokens are returned.
+	Tokens *tokens.Service
 }
 
 // UserRepository abstracts user persistence for auth service.
 type UserRepository interface {
 	UpsertTelegramUser(ctx context.Context, input users.UpsertTelegramUserInput) (*users.User, error)
+	GetByID(ctx context.Context, id int64) (*users.User, error)
 }
 
 var (
@@ -60,11 +65,11 @@ func NewService(cfg Config) (*Service, error) {
 	if cfg.BotToken == "" {
 		return nil, errors.New("telegram bot token is required")
 	}
-	if cfg.JWTSecret == "" {
-		return nil, errors.New("jwt secret is required")
+	if cfg.Keys == nil {
+		return nil, errors.New("jwt keyring is required")
 	}
 	if cfg.JWTExpiry <= 0 {
-		cfg.JWTExpiry = 24 * time.Hour
+		cfg.JWTExpiry = 15 * time.Minute
 	}
 	if cfg.InitDataTTL <= 0 {
 		cfg.InitDataTTL = time.Hour
@@ -73,16 +78,20 @@ func NewService(cfg Config) (*Service, error) {
 	return &Service{
 		userRepo:    cfg.UserRepo,
 		botToken:    cfg.BotToken,
-		jwtSecret:   []byte(cfg.JWTSecret),
+		keys:        cfg.Keys,
 		jwtExpiry:   cfg.JWTExpiry,
 		initDataTTL: cfg.InitDataTTL,
+		tokens:      cfg.Tokens,
 	}, nil
 }
 
-// AuthResult contains a JWT token plus user profile.
+// AuthResult contains a short-lived JWT, the refresh token and the user profile.
 type AuthResult struct {
-	Token   string      `json:"token"`
-	Profile *users.User `json:"profile"`
+	Token            string      `json:"token"`
+	ExpiresAt        time.Time   `json:"expires_at"`
+	RefreshToken     string      `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time  `json:"refresh_expires_at,omitempty"`
+	Profile          *users.User `json:"profile"`
 }
 
 // BotRegisterInput contains data collected by the Telegram bot for onboarding.
@@ -128,12 +137,7 @@ func (s *Service) Authenticate(ctx context.Context, initData string) (*AuthResul
 		return nil, err
 	}
 
-	token, err := s.issueJWT(profile)
-	if err != nil {
-		return nil, err
-	}
-
-	return &AuthResult{Token: token, Profile: profile}, nil
+	return s.newResult(ctx, profile)
 }
 
 // RegisterBotUser upserts a user from bot-provided data and issues a JWT.
@@ -164,11 +168,7 @@ func (s *Service) RegisterBotUser(ctx context.Context, input BotRegisterInput) (
 	if err != nil {
 		return nil, err
 	}
-	token, err := s.issueJWT(profile)
-	if err != nil {
-		return nil, err
-	}
-	return &AuthResult{Token: token, Profile: profile}, nil
+	return s.newResult(ctx, profile)
 }
 
 func (s *Service) verifyPayload(values url.Values) error {
@@ -208,18 +208,51 @@ func (s *Service) verifyPayload(values url.Values) error {
 	return nil
 }
 
-func (s *Service) issueJWT(user *users.User) (string, error) {
+// IssueAccess signs a new access token for userID; used when refresh tokens rotate.
+func (s *Service) IssueAccess(ctx context.Context, userID int64) (tokens.Access, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return tokens.Access{}, err
+	}
+	return s.issueJWT(user)
+}
+
+// newResult issues an access token and, when configured, a refresh token for profile.
+func (s *Service) newResult(ctx context.Context, profile *users.User) (*AuthResult, error) {
+	access, err := s.issueJWT(profile)
+	if err != nil {
+		return nil, err
+	}
+	result := &AuthResult{Token: access.Token, ExpiresAt: access.ExpiresAt, Profile: profile}
+	if s.tokens != nil {
+		session, err := s.tokens.Issue(ctx, tokens.Subject{Kind: tokens.SubjectUser, ID: profile.ID}, access)
+		if err != nil {
+			return nil, err
+		}
+		result.RefreshToken = session.RefreshToken
+		result.RefreshExpiresAt = session.RefreshExpiresAt
+	}
+	return result, nil
+}
+
+func (s *Service) issueJWT(user *users.User) (tokens.Access, error) {
 	now := time.Now()
+	jti := tokens.NewJTI()
+	expiresAt := now.Add(s.jwtExpiry)
 	claims := jwt.MapClaims{
 		"sub":         user.ID,
 		"telegram_id": user.TelegramID,
 		"role":        "user",
+		"jti":         jti,
 		"iat":         now.Unix(),
-		"exp":         now.Add(s.jwtExpiry).Unix(),
+		"exp":         expiresAt.Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(s.jwtSecret)
+	token, err := s.keys.Sign(claims)
+	if err != nil {
+		return tokens.Access{}, err
+	}
+	return tokens.Access{Token: token, JTI: jti, ExpiresAt: expiresAt}, nil
 }
 
 func buildDataCheckString(values url.Values) string {
@@ -27,6 +27,7 @@ type Config struct {
 // Bot drives Telegram interactions and backend registration requests.
 type Bot struct {
 	api        *tgbotapi.BotAPI
+	token      string
 	backendURL string
 	miniAppURL string
 	httpClient *http.Client
@@ -58,6 +59,7 @@ func New(cfg Config) (*Bot, error) {
 	}
 	return &Bot{
 		api:        api,
+		token:      cfg.Token,
 		backendURL: backend,
 		miniAppURL: mini,
 		httpClient: client,
@@ -82,6 +84,10 @@ func (b *Bot) Run(ctx context.Context) error {
 }
 
 func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	if update.PreCheckoutQuery != nil {
+		b.handlePreCheckout(update.PreCheckoutQuery)
+		return
+	}
 	if update.Message == nil {
 		return
 	}
@@ -89,6 +95,10 @@ func (b *Bot) handleUpdate(update tgbotapi.Update) {
 	if msg.From == nil {
 		return
 	}
+	if msg.SuccessfulPayment != nil {
+		b.handleSuccessfulPayment(msg)
+		return
+	}
 	userID := msg.From.ID
 	if msg.IsCommand() {
 		b.handleCommand(msg)
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	paymentEventPreCheckout       = "pre_checkout"
+	paymentEventSuccessfulPayment = "successful_payment"
+	paymentSignatureHeader        = "X-Bot-Signature"
+)
+
+type paymentEvent struct {
+	Type                    string `json:"type"`
+	TelegramID              int64  `json:"telegram_id"`
+	InvoicePayload          string `json:"invoice_payload"`
+	Currency                string `json:"currency"`
+	TotalAmount             int    `json:"total_amount"`
+	TelegramPaymentChargeID string `json:"telegram_payment_charge_id,omitempty"`
+	ProviderPaymentChargeID string `json:"provider_payment_charge_id,omitempty"`
+}
+
+type paymentEventResult struct {
+	OK      bool   `json:"ok"`
+	OrderID int64  `json:"order_id"`
+	Error   string `json:"error"`
+}
+
+// handlePreCheckout asks the backend to re-check the order before Telegram charges the user.
+func (b *Bot) handlePreCheckout(query *tgbotapi.PreCheckoutQuery) {
+	answer := tgbotapi.PreCheckoutConfig{PreCheckoutQueryID: query.ID, OK: true}
+	res, err := b.postPaymentEvent(paymentEvent{
+		Type:           paymentEventPreCheckout,
+		TelegramID:     query.From.ID,
+		InvoicePayload: query.InvoicePayload,
+		Currency:       query.Currency,
+		TotalAmount:    query.TotalAmount,
+	})
+	switch {
+	case err != nil:
+		log.Printf("pre-checkout %s: %v", query.InvoicePayload, err)
+		answer.OK = false
+		answer.ErrorMessage = "Не удалось проверить заказ, попробуйте ещё раз."
+	case !res.OK:
+		answer.OK = false
+		answer.ErrorMessage = res.Error
+	}
+	if _, err := b.api.Request(answer); err != nil {
+		log.Printf("answer pre-checkout %s: %v", query.InvoicePayload, err)
+	}
+}
+
+// handleSuccessfulPayment reports the charge; the backend ignores repeated reports of the same charge.
+func (b *Bot) handleSuccessfulPayment(msg *tgbotapi.Message) {
+	payment := msg.SuccessfulPayment
+	res, err := b.postPaymentEvent(paymentEvent{
+		Type:                    paymentEventSuccessfulPayment,
+		TelegramID:              msg.From.ID,
+		InvoicePayload:          payment.InvoicePayload,
+		Currency:                payment.Currency,
+		TotalAmount:             payment.TotalAmount,
+		TelegramPaymentChargeID: payment.TelegramPaymentChargeID,
+		ProviderPaymentChargeID: payment.ProviderPaymentChargeID,
+	})
+	if err != nil || !res.OK {
+		log.Printf("successful payment %s (%s) not applied: %v %+v", payment.InvoicePayload, payment.TelegramPaymentChargeID, err, res)
+		b.reply(msg.Chat.ID, fmt.Sprintf("Оплата получена, но мы не смогли обновить заказ. Сообщите оператору код платежа: %s", payment.TelegramPaymentChargeID))
+		return
+	}
+	b.reply(msg.Chat.ID, fmt.Sprintf("Оплата заказа #%d получена. Передали заказ на кухню!", res.OrderID))
+}
+
+func (b *Bot) postPaymentEvent(event paymentEvent) (*paymentEventResult, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, []byte(b.token))
+	mac.Write(data)
+
+	endpoint := fmt.Sprintf("%s/payments/telegram/callback", b.backendURL)
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(paymentSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("backend ответил %d", resp.StatusCode)
+	}
+	var res paymentEventResult
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,311 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/rashidmailru/kabobfood/internal/metrics"
+)
+
+// invalidateChannel carries deleted keys to the stores of other instances.
+const invalidateChannel = "cache:invalidate"
+
+// DefaultLocalSize is the number of entries kept in process when
+// StoreOptions.LocalSize is not set.
+const DefaultLocalSize = 1000
+
+// Lookup tiers and results recorded in metrics.
+const (
+	tierLocal = "local"
+	tierRedis = "redis"
+	tierNone  = "none"
+
+	resultHit   = "hit"
+	resultStale = "stale"
+	resultMiss  = "miss"
+)
+
+// redisBackoff is how long the store skips Redis after it fails, so a Redis
+// outage does not add its timeout to every local miss.
+const redisBackoff = 5 * time.Second
+
+// envelopeSize is the header of stored values: fresh-until and expires-at
+// as Unix milliseconds.
+const envelopeSize = 16
+
+// Entry is a cached value. Stale entries are past their TTL but still inside
+// the stale window; callers serve them while refreshing in the background.
+type Entry struct {
+	Data  []byte
+	Stale bool
+}
+
+// StoreOptions configures a Store; all fields are optional.
+type StoreOptions struct {
+	LocalSize int
+	Metrics   *metrics.Metrics
+}
+
+// Store is a two-tier cache: an in-process LRU in front of Redis. Without a
+// Redis client, or for redisBackoff after a Redis error, the LRU is used alone. Values live in
+// Redis hashes when a field is given, so a key and all its fields are deleted
+// together. Deletes are broadcast, so every instance drops its local copy.
+type Store struct {
+	redis   *redis.Client
+	pubsub  *redis.PubSub
+	metrics *metrics.Metrics
+	// redisDownUntil holds the Unix nanoseconds until which Redis is skipped.
+	redisDownUntil atomic.Int64
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	size    int
+}
+
+type localEntry struct {
+	key        string
+	data       []byte
+	freshUntil time.Time
+	expiresAt  time.Time
+}
+
+// NewStore builds a store; client may be nil. With a client it subscribes to
+// invalidations of other instances until Close.
+func NewStore(client *redis.Client, opts StoreOptions) *Store {
+	size := opts.LocalSize
+	if size <= 0 {
+		size = DefaultLocalSize
+	}
+	s := &Store{
+		redis:   client,
+		metrics: opts.Metrics,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		size:    size,
+	}
+	if client != nil {
+		s.pubsub = client.Subscribe(context.Background(), invalidateChannel)
+		go s.listen(s.pubsub.Channel())
+	}
+	return s
+}
+
+// Get returns the entry under key and field; field may be empty. A stale
+// local entry is replaced by a fresh one from Redis when another instance
+// has already refreshed it.
+func (s *Store) Get(ctx context.Context, key, field string) (Entry, bool) {
+	now := time.Now()
+	local, ok := s.getLocal(localKey(key, field), now)
+	if ok && now.Before(local.freshUntil) {
+		s.observe(key, tierLocal, resultHit)
+		return Entry{Data: local.data}, true
+	}
+
+	if remote, err := s.getRedis(ctx, key, field, now); err == nil {
+		s.setLocal(localKey(key, field), remote)
+		stale := !now.Before(remote.freshUntil)
+		if stale {
+			s.observe(key, tierRedis, resultStale)
+		} else {
+			s.observe(key, tierRedis, resultHit)
+		}
+		return Entry{Data: remote.data, Stale: stale}, true
+	}
+
+	if ok {
+		s.observe(key, tierLocal, resultStale)
+		return Entry{Data: local.data, Stale: true}, true
+	}
+	s.observe(key, tierNone, resultMiss)
+	return Entry{}, false
+}
+
+// Set stores data for ttl plus a stale window in both tiers. Redis errors are
+// ignored; the local copy still serves this instance. For fields the hash TTL
+// is only ever shortened, so one short-lived field expires the whole hash.
+func (s *Store) Set(ctx context.Context, key, field string, data []byte, ttl, stale time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	if stale < 0 {
+		stale = 0
+	}
+	now := time.Now()
+	entry := localEntry{data: data, freshUntil: now.Add(ttl), expiresAt: now.Add(ttl + stale)}
+	s.setLocal(localKey(key, field), entry)
+
+	if !s.redisUp(now) {
+		return
+	}
+	value := encodeEnvelope(entry)
+	if field == "" {
+		s.redisFailed(ctx, s.redis.Set(ctx, key, value, ttl+stale).Err())
+		return
+	}
+	_, err := s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, field, value)
+		pipe.ExpireLT(ctx, key, ttl+stale)
+		return nil
+	})
+	s.redisFailed(ctx, err)
+}
+
+// Delete drops keys with all their fields on every instance. It tries Redis
+// even inside the backoff window, since a missed delete serves stale data.
+func (s *Store) Delete(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	s.deleteLocal(keys)
+	if s.redis == nil {
+		return
+	}
+	if err := s.redis.Del(ctx, keys...).Err(); err != nil {
+		s.redisFailed(ctx, err)
+		return
+	}
+	s.redisFailed(ctx, s.redis.Publish(ctx, invalidateChannel, strings.Join(keys, "\n")).Err())
+}
+
+// Close stops listening for invalidations.
+func (s *Store) Close() error {
+	if s.pubsub == nil {
+		return nil
+	}
+	return s.pubsub.Close()
+}
+
+func (s *Store) listen(messages <-chan *redis.Message) {
+	for msg := range messages {
+		s.deleteLocal(strings.Split(msg.Payload, "\n"))
+	}
+}
+
+func (s *Store) getRedis(ctx context.Context, key, field string, now time.Time) (localEntry, error) {
+	if !s.redisUp(now) {
+		return localEntry{}, redis.Nil
+	}
+	var (
+		value []byte
+		err   error
+	)
+	if field == "" {
+		value, err = s.redis.Get(ctx, key).Bytes()
+	} else {
+		value, err = s.redis.HGet(ctx, key, field).Bytes()
+	}
+	if err != nil {
+		s.redisFailed(ctx, err)
+		return localEntry{}, err
+	}
+	entry, err := decodeEnvelope(value)
+	if err != nil {
+		return localEntry{}, err
+	}
+	if !now.Before(entry.expiresAt) {
+		return localEntry{}, redis.Nil
+	}
+	return entry, nil
+}
+
+// redisUp reports whether Redis is configured and outside the backoff window.
+func (s *Store) redisUp(now time.Time) bool {
+	return s.redis != nil && now.UnixNano() >= s.redisDownUntil.Load()
+}
+
+// redisFailed opens the backoff window on a Redis error. Misses and errors
+// caused by the caller's own context do not count.
+func (s *Store) redisFailed(ctx context.Context, err error) {
+	if err == nil || errors.Is(err, redis.Nil) || ctx.Err() != nil {
+		return
+	}
+	s.redisDownUntil.Store(time.Now().Add(redisBackoff).UnixNano())
+}
+
+func (s *Store) getLocal(key string, now time.Time) (localEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.entries[key]
+	if !ok {
+		return localEntry{}, false
+	}
+	entry := el.Value.(*localEntry)
+	if !now.Before(entry.expiresAt) {
+		s.order.Remove(el)
+		delete(s.entries, key)
+		return localEntry{}, false
+	}
+	s.order.MoveToFront(el)
+	return *entry, true
+}
+
+func (s *Store) setLocal(key string, entry localEntry) {
+	entry.key = key
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[key]; ok {
+		el.Value = &entry
+		s.order.MoveToFront(el)
+		return
+	}
+	s.entries[key] = s.order.PushFront(&entry)
+	for s.order.Len() > s.size {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*localEntry).key)
+	}
+}
+
+func (s *Store) deleteLocal(keys []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		prefix := localKey(key, "")
+		for k, el := range s.entries {
+			if strings.HasPrefix(k, prefix) {
+				s.order.Remove(el)
+				delete(s.entries, k)
+			}
+		}
+	}
+}
+
+func (s *Store) observe(key, tier, result string) {
+	if s.metrics != nil {
+		s.metrics.CacheLookups.WithLabelValues(key, tier, result).Inc()
+	}
+}
+
+// localKey joins key and field; plain keys end with the separator too, so
+// deleting a key matches every field by prefix.
+func localKey(key, field string) string {
+	return key + "\x00" + field
+}
+
+func encodeEnvelope(e localEntry) []byte {
+	out := make([]byte, envelopeSize+len(e.data))
+	binary.BigEndian.PutUint64(out[0:8], uint64(e.freshUntil.UnixMilli()))
+	binary.BigEndian.PutUint64(out[8:16], uint64(e.expiresAt.UnixMilli()))
+	copy(out[envelopeSize:], e.data)
+	return out
+}
+
+func decodeEnvelope(value []byte) (localEntry, error) {
+	if len(value) < envelopeSize {
+		return localEntry{}, errors.New("cache: short value")
+	}
+	return localEntry{
+		data:       value[envelopeSize:],
+		freshUntil: time.UnixMilli(int64(binary.BigEndian.Uint64(value[0:8]))),
+		expiresAt:  time.UnixMilli(int64(binary.BigEndian.Uint64(value[8:16]))),
+	}, nil
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestStoreLocal(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(nil, StoreOptions{LocalSize: 2})
+
+	if _, ok := s.Get(ctx, "menu", ""); ok {
+		t.Fatal("expected miss on empty store")
+	}
+	s.Set(ctx, "menu", "", []byte("a"), time.Minute, 0)
+	if entry, ok := s.Get(ctx, "menu", ""); !ok || entry.Stale || string(entry.Data) != "a" {
+		t.Fatalf("unexpected entry %+v %v", entry, ok)
+	}
+
+	s.Set(ctx, "search", "20:plov", []byte("b"), time.Minute, 0)
+	s.Set(ctx, "search", "20:somsa", []byte("c"), time.Minute, 0)
+	if _, ok := s.Get(ctx, "menu", ""); ok {
+		t.Fatal("least recently used entry must be evicted")
+	}
+
+	s.Delete(ctx, "search")
+	if _, ok := s.Get(ctx, "search", "20:somsa"); ok {
+		t.Fatal("deleting a key must drop its fields")
+	}
+}
+
+func TestStoreStale(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore(nil, StoreOptions{})
+
+	s.Set(ctx, "menu", "", []byte("a"), time.Millisecond, time.Minute)
+	s.Set(ctx, "regions", "", []byte("b"), time.Millisecond, 0)
+	time.Sleep(5 * time.Millisecond)
+
+	if entry, ok := s.Get(ctx, "menu", ""); !ok || !entry.Stale || string(entry.Data) != "a" {
+		t.Fatalf("expected stale entry, got %+v %v", entry, ok)
+	}
+	if _, ok := s.Get(ctx, "regions", ""); ok {
+		t.Fatal("entry without stale window must expire")
+	}
+}
+
+func TestStoreRedisBackoff(t *testing.T) {
+	ctx := context.Background()
+	const dialDelay = 100 * time.Millisecond
+	client := redis.NewClient(&redis.Options{
+		Addr:          "redis.invalid:6379",
+		MaxRetries:    -1,
+		DialerRetries: 1,
+		Dialer: func(context.Context, string, string) (net.Conn, error) {
+			time.Sleep(dialDelay)
+			return nil, errors.New("connection refused")
+		},
+	})
+	s := NewStore(client, StoreOptions{})
+	t.Cleanup(func() { _ = s.Close() })
+
+	start := time.Now()
+	if _, ok := s.Get(ctx, "menu", ""); ok {
+		t.Fatal("expected miss while redis is down")
+	}
+	if time.Since(start) < dialDelay {
+		t.Fatal("expected the first lookup to try redis")
+	}
+
+	start = time.Now()
+	s.Set(ctx, "menu", "", []byte("a"), time.Minute, 0)
+	if _, ok := s.Get(ctx, "regions", ""); ok {
+		t.Fatal("expected miss")
+	}
+	if entry, ok := s.Get(ctx, "menu", ""); !ok || string(entry.Data) != "a" {
+		t.Fatalf("expected local entry, got %+v %v", entry, ok)
+	}
+	if elapsed := time.Since(start); elapsed >= dialDelay {
+		t.Fatalf("expected redis to be skipped after a failure, took %v", elapsed)
+	}
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	now := time.UnixMilli(time.Now().UnixMilli())
+	in := localEntry{data: []byte(`{"categories":[]}`), freshUntil: now.Add(time.Second), expiresAt: now.Add(time.Minute)}
+	out, err := decodeEnvelope(encodeEnvelope(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out.data) != string(in.data) || !out.freshUntil.Equal(in.freshUntil) || !out.expiresAt.Equal(in.expiresAt) {
+		t.Fatalf("got %+v, want %+v", out, in)
+	}
+	if _, err := decodeEnvelope([]byte("short")); err == nil {
+		t.Fatal("expected error for short value")
+	}
+}
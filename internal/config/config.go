@@ -1,9 +1,22 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/caarlos0/env/v10"
+
+	"github.com/rashidmailru/kabobfood/internal/tokens"
+)
+
+// Development defaults that must not reach production.
+const (
+	defaultJWTSecret      = "supersecret"
+	defaultAdminJWTSecret = "supersecret-admin"
+	minProductionSecret   = 32
 )
 
 // Config aggregates application configuration sourced from environment variables.
@@ -17,8 +30,14 @@ type Config struct {
 	Auth            AuthConfig      `envPrefix:"AUTH_"`
 	Cache           CacheConfig     `envPrefix:"CACHE_"`
 	Admin           AdminConfig     `envPrefix:"ADMIN_"`
+	Kitchen         KitchenConfig   `envPrefix:"KITCHEN_"`
+	Printer         PrinterConfig   `envPrefix:"PRINTER_"`
+	Payments        PaymentsConfig  `envPrefix:"PAYMENTS_"`
 	RateLimit       RateLimitConfig `envPrefix:"RATE_"`
+	Lockout         LockoutConfig   `envPrefix:"LOCKOUT_"`
 	Sentry          SentryConfig    `envPrefix:"SENTRY_"`
+	Storage         StorageConfig   `envPrefix:"STORAGE_"`
+	Pricing         PricingConfig   `envPrefix:"PRICING_"`
 	ShutdownTimeout time.Duration   `env:"SHUTDOWN_TIMEOUT" envDefault:"10s"`
 }
 
@@ -30,6 +49,9 @@ type HTTPConfig struct {
 	WriteTimeout time.Duration `env:"WRITE_TIMEOUT" envDefault:"10s"`
 	IdleTimeout  time.Duration `env:"IDLE_TIMEOUT" envDefault:"60s"`
 	CORSOrigins  []string      `env:"CORS_ORIGINS" envSeparator:","`
+	// CacheMaxAge is how long clients may reuse /menu and /regions before
+	// revalidating them with If-None-Match; zero means every time.
+	CacheMaxAge time.Duration `env:"CACHE_MAX_AGE" envDefault:"30s"`
 }
 
 // DatabaseConfig declares PostgreSQL connection options.
@@ -47,10 +69,18 @@ type RedisConfig struct {
 	DialTimeout time.Duration `env:"DIAL_TIMEOUT" envDefault:"5s"`
 }
 
-// JWTConfig contains signing settings.
+// JWTConfig contains signing settings for customer tokens. SigningKeys, when set,
+// replaces Secret with "kid=ALG:material[@until]" entries; the first one signs.
+// Tokens issued before key ids existed verify against Secret until LegacyUntil;
+// when it is unset they are rejected and customers have to log in again.
 type JWTConfig struct {
-	Secret     string        `env:"SECRET" envDefault:"supersecret"`
-	Expiration time.Duration `env:"EXPIRATION" envDefault:"24h"`
+	Secret            string        `env:"SECRET" envDefault:"supersecret"`
+	SigningKeys       []string      `env:"SIGNING_KEYS" envSeparator:","`
+	LegacyUntil       time.Time     `env:"LEGACY_UNTIL"`
+	Issuer            string        `env:"ISSUER" envDefault:"kabobfood"`
+	Audience          string        `env:"AUDIENCE" envDefault:"kabobfood-users"`
+	Expiration        time.Duration `env:"EXPIRATION" envDefault:"15m"`
+	RefreshExpiration time.Duration `env:"REFRESH_EXPIRATION" envDefault:"720h"`
 }
 
 // TelegramConfig defines credentials for Bot API integration.
@@ -64,17 +94,87 @@ type AuthConfig struct {
 	TelegramInitTTL time.Duration `env:"TELEGRAM_INIT_TTL" envDefault:"1h"`
 }
 
-// CacheConfig defines TTLs for cached payloads.
+// CacheConfig defines TTLs for cached payloads. Expired entries are served
+// for StaleTTL more while they are refreshed; LocalSize bounds the in-process
+// tier in front of Redis.
 type CacheConfig struct {
 	MenuTTL    time.Duration `env:"MENU_TTL" envDefault:"30s"`
 	RegionsTTL time.Duration `env:"REGIONS_TTL" envDefault:"30s"`
+	SearchTTL  time.Duration `env:"SEARCH_TTL" envDefault:"5m"`
+	StaleTTL   time.Duration `env:"STALE_TTL" envDefault:"30s"`
+	LocalSize  int           `env:"LOCAL_SIZE" envDefault:"1000"`
 }
 
-// AdminConfig defines bootstrap admin credentials and JWT ttl.
+// AdminConfig defines bootstrap admin credentials and staff token signing.
+// JWTLegacyUntil works like JWTConfig.LegacyUntil for staff tokens.
 type AdminConfig struct {
-	DefaultUsername string        `env:"DEFAULT_USERNAME" envDefault:"admin"`
-	DefaultPassword string        `env:"DEFAULT_PASSWORD" envDefault:"admin123"`
-	JWTExpiration   time.Duration `env:"JWT_EXPIRATION" envDefault:"24h"`
+	DefaultUsername   string        `env:"DEFAULT_USERNAME" envDefault:"admin"`
+	DefaultPassword   string        `env:"DEFAULT_PASSWORD" envDefault:"admin123"`
+	KitchenUsername   string        `env:"KITCHEN_USERNAME"`
+	KitchenPassword   string        `env:"KITCHEN_PASSWORD"`
+	JWTSecret         string        `env:"JWT_SECRET" envDefault:"supersecret-admin"`
+	JWTSigningKeys    []string      `env:"JWT_SIGNING_KEYS" envSeparator:","`
+	JWTLegacyUntil    time.Time     `env:"JWT_LEGACY_UNTIL"`
+	JWTAudience       string        `env:"JWT_AUDIENCE" envDefault:"kabobfood-admin"`
+	JWTExpiration     time.Duration `env:"JWT_EXPIRATION" envDefault:"15m"`
+	RefreshExpiration time.Duration `env:"REFRESH_EXPIRATION" envDefault:"24h"`
+	TOTPIssuer        string        `env:"TOTP_ISSUER" envDefault:"KabobFood"`
+}
+
+// KitchenConfig defines kitchen display settings.
+type KitchenConfig struct {
+	StreamInterval time.Duration `env:"STREAM_INTERVAL" envDefault:"5s"`
+}
+
+// PrinterConfig defines receipt layout and the optional network printer.
+type PrinterConfig struct {
+	Addr      string        `env:"ADDR"`
+	AutoPrint bool          `env:"AUTO_PRINT" envDefault:"false"`
+	Layouts   []string      `env:"LAYOUTS" envSeparator:"," envDefault:"kitchen"`
+	Width     int           `env:"WIDTH" envDefault:"48"`
+	CodePage  int           `env:"CODE_PAGE" envDefault:"17"`
+	Header    string        `env:"HEADER" envDefault:"KabobFood"`
+	Timezone  string        `env:"TIMEZONE" envDefault:"Asia/Tashkent"`
+	Timeout   time.Duration `env:"TIMEOUT" envDefault:"5s"`
+}
+
+// PaymentsConfig lists enabled payment methods and online provider credentials.
+type PaymentsConfig struct {
+	OfflineMethods        []string `env:"OFFLINE_METHODS" envSeparator:"," envDefault:"cash,card,terminal,transfer"`
+	Currency              string   `env:"CURRENCY" envDefault:"UZS"`
+	PublicURL             string   `env:"PUBLIC_URL" envDefault:"http://localhost:8080"`
+	ReturnURL             string   `env:"RETURN_URL"`
+	FakeEnabled           bool     `env:"FAKE_ENABLED" envDefault:"false"`
+	FakeSecret            string   `env:"FAKE_SECRET" envDefault:"fake-secret"`
+	PaymeMerchantID       string   `env:"PAYME_MERCHANT_ID"`
+	PaymeKey              string   `env:"PAYME_KEY"`
+	PaymeCheckoutURL      string   `env:"PAYME_CHECKOUT_URL"`
+	ClickServiceID        string   `env:"CLICK_SERVICE_ID"`
+	ClickMerchantID       string   `env:"CLICK_MERCHANT_ID"`
+	ClickSecretKey        string   `env:"CLICK_SECRET_KEY"`
+	TelegramProviderToken string   `env:"TELEGRAM_PROVIDER_TOKEN"`
+}
+
+// StorageConfig selects where uploaded images are kept: "local" writes to
+// LocalDir and serves it under /media, "s3" uses an S3-compatible bucket,
+// "memory" keeps files in process and is meant for tests. PublicURL overrides
+// the base of image URLs, e.g. a CDN.
+type StorageConfig struct {
+	Driver        string `env:"DRIVER" envDefault:"local"`
+	LocalDir      string `env:"LOCAL_DIR" envDefault:"./data/media"`
+	PublicURL     string `env:"PUBLIC_URL"`
+	MaxUploadSize int64  `env:"MAX_UPLOAD_SIZE" envDefault:"5242880"`
+	S3Endpoint    string `env:"S3_ENDPOINT"`
+	S3Region      string `env:"S3_REGION" envDefault:"us-east-1"`
+	S3Bucket      string `env:"S3_BUCKET"`
+	S3AccessKey   string `env:"S3_ACCESS_KEY"`
+	S3SecretKey   string `env:"S3_SECRET_KEY"`
+	S3PathStyle   bool   `env:"S3_PATH_STYLE" envDefault:"true"`
+}
+
+// PricingConfig sets the time zone of happy-hour windows.
+type PricingConfig struct {
+	Timezone string `env:"TIMEZONE" envDefault:"Asia/Tashkent"`
 }
 
 // SentryConfig stores sentry DSN.
@@ -82,22 +182,100 @@ type SentryConfig struct {
 	DSN string `env:"DSN"`
 }
 
-// RateLimitConfig defines token bucket settings.
+// RateLimitConfig defines token bucket settings. LoginLimit applies to auth,
+// bot registration and admin login, which get a much smaller budget.
 type RateLimitConfig struct {
 	UserLimit  int           `env:"USER_LIMIT" envDefault:"60"`
 	AdminLimit int           `env:"ADMIN_LIMIT" envDefault:"120"`
+	LoginLimit int           `env:"LOGIN_LIMIT" envDefault:"10"`
 	Window     time.Duration `env:"WINDOW" envDefault:"1m"`
 }
 
+// LockoutConfig defines brute-force protection for login and registration.
+type LockoutConfig struct {
+	FreeAttempts      int           `env:"FREE_ATTEMPTS" envDefault:"3"`
+	BaseDelay         time.Duration `env:"BASE_DELAY" envDefault:"1s"`
+	MaxDelay          time.Duration `env:"MAX_DELAY" envDefault:"30s"`
+	UsernameThreshold int           `env:"USERNAME_THRESHOLD" envDefault:"10"`
+	IPThreshold       int           `env:"IP_THRESHOLD" envDefault:"50"`
+	Window            time.Duration `env:"WINDOW" envDefault:"15m"`
+	Duration          time.Duration `env:"DURATION" envDefault:"15m"`
+}
+
 // Load parses environment variables into Config.
 func Load() (*Config, error) {
 	cfg := &Config{}
 	if err := env.Parse(cfg); err != nil {
 		return nil, err
 	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
+// Production reports whether the app runs in a production environment.
+func (c *Config) Production() bool {
+	return c.AppEnv == "prod" || c.AppEnv == "production"
+}
+
+// validate refuses development signing secrets in production.
+func (c *Config) validate() error {
+	if !c.Production() {
+		return nil
+	}
+	userSecrets, err := hmacSecrets(c.JWT.Secret, c.JWT.SigningKeys, !c.JWT.LegacyUntil.IsZero())
+	if err != nil {
+		return fmt.Errorf("JWT_SIGNING_KEYS: %w", err)
+	}
+	for _, secret := range userSecrets {
+		if weakSecret(secret, defaultJWTSecret) {
+			return errors.New("JWT_SECRET and HS256 JWT_SIGNING_KEYS must be random values of at least 32 characters in production")
+		}
+	}
+	adminSecrets, err := hmacSecrets(c.Admin.JWTSecret, c.Admin.JWTSigningKeys, !c.Admin.JWTLegacyUntil.IsZero())
+	if err != nil {
+		return fmt.Errorf("ADMIN_JWT_SIGNING_KEYS: %w", err)
+	}
+	for _, secret := range adminSecrets {
+		if weakSecret(secret, defaultAdminJWTSecret) {
+			return errors.New("ADMIN_JWT_SECRET and HS256 ADMIN_JWT_SIGNING_KEYS must be random values of at least 32 characters in production")
+		}
+		if slices.Contains(userSecrets, secret) {
+			return errors.New("staff and customer tokens must not share an HMAC secret")
+		}
+	}
+	return nil
+}
+
+// hmacSecrets returns the HMAC material a keyring verifies with: every HS256
+// spec, plus secret when no specs are set or legacy tokens are accepted.
+func hmacSecrets(secret string, specs []string, legacy bool) ([]string, error) {
+	var secrets []string
+	configured := false
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		configured = true
+		key, err := tokens.ParseKeySpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := key.HMACSecret(); ok {
+			secrets = append(secrets, s)
+		}
+	}
+	if !configured || legacy {
+		secrets = append(secrets, secret)
+	}
+	return secrets, nil
+}
+
+func weakSecret(secret, def string) bool {
+	return secret == def || len(secret) < minProductionSecret
+}
+
 // MustLoad is a helper that panics if configuration cannot be loaded.
 func MustLoad() *Config {
 	cfg, err := Load()
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caarlos0/env/v10"
+)
+
+func TestValidateSigningSecrets(t *testing.T) {
+	strong := strings.Repeat("u", minProductionSecret)
+	strongAdmin := strings.Repeat("a", minProductionSecret)
+
+	tests := []struct {
+		name  string
+		jwt   JWTConfig
+		admin AdminConfig
+		ok    bool
+	}{
+		{name: "strong secrets", jwt: JWTConfig{Secret: strong}, admin: AdminConfig{JWTSecret: strongAdmin}, ok: true},
+		{name: "default secret", jwt: JWTConfig{Secret: defaultJWTSecret}, admin: AdminConfig{JWTSecret: strongAdmin}},
+		{name: "shared secret", jwt: JWTConfig{Secret: strong}, admin: AdminConfig{JWTSecret: strong}},
+		{name: "weak HS256 spec", jwt: JWTConfig{SigningKeys: []string{"k1=HS256:short"}}, admin: AdminConfig{JWTSecret: strongAdmin}},
+		{name: "weak admin HS256 spec", jwt: JWTConfig{Secret: strong}, admin: AdminConfig{JWTSigningKeys: []string{"k1=HS256:" + defaultAdminJWTSecret}}},
+		{name: "HS256 spec shared across keyrings", jwt: JWTConfig{SigningKeys: []string{"k1=HS256:" + strong}}, admin: AdminConfig{JWTSigningKeys: []string{"k2=HS256:" + strong}}},
+		{name: "strong HS256 specs", jwt: JWTConfig{Secret: defaultJWTSecret, SigningKeys: []string{"k1=HS256:" + strong}}, admin: AdminConfig{JWTSigningKeys: []string{"k2=HS256:" + strongAdmin}}, ok: true},
+		{name: "legacy tokens keep the secret in use", jwt: JWTConfig{Secret: defaultJWTSecret, SigningKeys: []string{"k1=HS256:" + strong}, LegacyUntil: time.Now()}, admin: AdminConfig{JWTSecret: strongAdmin}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{AppEnv: "prod", JWT: tc.jwt, Admin: tc.admin}
+			if err := cfg.validate(); (err == nil) != tc.ok {
+				t.Fatalf("validate() = %v, want ok=%v", err, tc.ok)
+			}
+		})
+	}
+}
+
+func TestLegacyUntilParses(t *testing.T) {
+	t.Setenv("LEGACY_UNTIL", "2030-01-01T00:00:00Z")
+	var cfg JWTConfig
+	if err := env.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LegacyUntil.Year() != 2030 {
+		t.Fatalf("unexpected cutoff %v", cfg.LegacyUntil)
+	}
+}
@@ -1,29 +1,25 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/http/middleware"
+	"github.com/rashidmailru/kabobfood/internal/lockout"
 )
 
 // AdminAuthHandler issues admin JWT tokens.
 type AdminAuthHandler struct {
 	authService *admin.AuthService
-	jwtSecret   []byte
-	jwtExpiry   time.Duration
+	guard       *lockout.Guard
 }
 
-// NewAdminAuthHandler builds handler.
-func NewAdminAuthHandler(service *admin.AuthService, secret string, expiry time.Duration) *AdminAuthHandler {
-	return &AdminAuthHandler{
-		authService: service,
-		jwtSecret:   []byte(secret),
-		jwtExpiry:   expiry,
-	}
+// NewAdminAuthHandler builds handler; guard limits failed logins per username and IP.
+func NewAdminAuthHandler(service *admin.AuthService, guard *lockout.Guard) *AdminAuthHandler {
+	return &AdminAuthHandler{authService: service, guard: guard}
 }
 
 // Register wires routes.
@@ -31,38 +27,222 @@ func (h *AdminAuthHandler) Register(rg *gin.RouterGroup) {
 	rg.POST("/admin/login", h.login)
 }
 
-type adminLoginRequest struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
-}
-
 func (h *AdminAuthHandler) login(c *gin.Context) {
-	var req adminLoginRequest
+	var req admin.LoginInput
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "username and password required"})
 		return
 	}
 
-	user, err := h.authService.Login(c.Request.Context(), req.Username, req.Password)
+	ctx := c.Request.Context()
+	keys := []lockout.Key{lockout.Username(req.Username), lockout.IP(c.ClientIP())}
+	if h.guard != nil {
+		if wait := h.guard.Check(ctx, keys...); wait > 0 {
+			respondLocked(c, wait)
+			return
+		}
+	}
+
+	user, err := h.authService.Login(ctx, req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		if h.guard != nil && (errors.Is(err, admin.ErrInvalidCredentials) || errors.Is(err, admin.ErrInvalidOTP)) {
+			h.guard.Fail(ctx, keys...)
+		}
+		switch {
+		case errors.Is(err, admin.ErrAccountDisabled):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, admin.ErrOTPRequired):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "otp_required"})
+		case errors.Is(err, admin.ErrInvalidOTP):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "code": "invalid_otp"})
+		default:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		}
 		return
 	}
-
-	now := time.Now()
-	claims := jwt.MapClaims{
-		"sub":   user.ID,
-		"role":  "admin",
-		"iat":   now.Unix(),
-		"exp":   now.Add(h.jwtExpiry).Unix(),
-		"scope": "admin",
+	if h.guard != nil {
+		h.guard.Succeed(ctx, keys[0])
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString(h.jwtSecret)
+	respondAdminToken(c, h.authService, user, nil)
+}
+
+// respondAdminToken signs a token for user and writes it along with extra fields.
+func respondAdminToken(c *gin.Context, service *admin.AuthService, user *admin.User, extra gin.H) {
+	session, err := service.IssueSession(c.Request.Context(), user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "cannot sign token"})
 		return
 	}
+	body := gin.H{
+		"token":                session.Token,
+		"expires_at":           session.ExpiresAt,
+		"role":                 user.Role,
+		"permissions":          admin.PermissionsFor(user.Role),
+		"must_change_password": user.MustChangePassword,
+		"must_enroll_totp":     user.MustEnrollTOTP,
+	}
+	if session.RefreshToken != "" {
+		body["refresh_token"] = session.RefreshToken
+		body["refresh_expires_at"] = session.RefreshExpiresAt
+	}
+	for k, v := range extra {
+		body[k] = v
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+// AdminAccountHandler lets staff manage their own account.
+type AdminAccountHandler struct {
+	authService *admin.AuthService
+}
+
+// NewAdminAccountHandler builds handler.
+func NewAdminAccountHandler(service *admin.AuthService) *AdminAccountHandler {
+	return &AdminAccountHandler{authService: service}
+}
+
+// Register wires self-service routes (staff auth required, no permission needed).
+func (h *AdminAccountHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/me", h.me)
+	rg.PUT("/admin/me/password", h.changePassword)
+	rg.POST("/admin/me/2fa/enroll", h.enrollTOTP)
+	rg.POST("/admin/me/2fa/confirm", h.confirmTOTP)
+	rg.DELETE("/admin/me/2fa", h.disableTOTP)
+	rg.GET("/admin/security/policy", middleware.RequirePermission(admin.PermSecurityManage), h.getPolicy)
+	rg.PUT("/admin/security/policy", middleware.RequirePermission(admin.PermSecurityManage), h.updatePolicy)
+}
+
+func (h *AdminAccountHandler) me(c *gin.Context) {
+	adminID, ok := middleware.AdminIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	user, err := h.authService.Me(c.Request.Context(), adminID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user, "permissions": admin.PermissionsFor(user.Role)})
+}
+
+// changePassword replaces the password and returns a fresh token; older tokens are revoked.
+func (h *AdminAccountHandler) changePassword(c *gin.Context) {
+	adminID, ok := middleware.AdminIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req admin.ChangePasswordInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	user, err := h.authService.ChangePassword(c.Request.Context(), adminID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, admin.ErrInvalidCredentials):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "current password is incorrect"})
+		case errors.Is(err, admin.ErrWeakPassword):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+		}
+		return
+	}
+	respondAdminToken(c, h.authService, user, nil)
+}
+
+func (h *AdminAccountHandler) enrollTOTP(c *gin.Context) {
+	adminID, ok := middleware.AdminIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	enrollment, err := h.authService.EnrollTOTP(c.Request.Context(), adminID)
+	if err != nil {
+		h.respondTOTPError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, enrollment)
+}
+
+type totpConfirmRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// confirmTOTP enables 2FA and returns recovery codes (shown once) with a fresh token.
+func (h *AdminAccountHandler) confirmTOTP(c *gin.Context) {
+	adminID, ok := middleware.AdminIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req totpConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code required"})
+		return
+	}
+	user, codes, err := h.authService.ConfirmTOTP(c.Request.Context(), adminID, req.Code)
+	if err != nil {
+		h.respondTOTPError(c, err)
+		return
+	}
+	respondAdminToken(c, h.authService, user, gin.H{"recovery_codes": codes})
+}
 
-	c.JSON(http.StatusOK, gin.H{"token": signed})
+func (h *AdminAccountHandler) disableTOTP(c *gin.Context) {
+	adminID, ok := middleware.AdminIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req admin.DisableTOTPInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	if err := h.authService.DisableTOTP(c.Request.Context(), adminID, req); err != nil {
+		h.respondTOTPError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AdminAccountHandler) respondTOTPError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, admin.ErrInvalidCredentials):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "current password is incorrect"})
+	case errors.Is(err, admin.ErrOTPRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "otp_required"})
+	case errors.Is(err, admin.ErrInvalidOTP):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "invalid_otp"})
+	case errors.Is(err, admin.ErrTOTPAlreadyEnabled), errors.Is(err, admin.ErrTOTPNotEnrolled), errors.Is(err, admin.ErrTOTPRequired):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update two-factor authentication"})
+	}
+}
+
+func (h *AdminAccountHandler) getPolicy(c *gin.Context) {
+	policy, err := h.authService.SecurityPolicy(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load policy"})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *AdminAccountHandler) updatePolicy(c *gin.Context) {
+	var req admin.SecurityPolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	policy, err := h.authService.SetSecurityPolicy(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update policy"})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
 }
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/audit"
+	"github.com/rashidmailru/kabobfood/internal/http/middleware"
+)
+
+// AdminAuditHandler exposes the admin audit log.
+type AdminAuditHandler struct {
+	service *audit.Service
+}
+
+func NewAdminAuditHandler(service *audit.Service) *AdminAuditHandler {
+	return &AdminAuditHandler{service: service}
+}
+
+func (h *AdminAuditHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/audit", middleware.RequirePermission(admin.PermAuditRead), h.list)
+}
+
+func (h *AdminAuditHandler) list(c *gin.Context) {
+	params := audit.ListParams{
+		Action:     c.Query("action"),
+		EntityType: c.Query("entity_type"),
+	}
+	if v, err := strconv.ParseInt(c.Query("actor_id"), 10, 64); err == nil {
+		params.ActorID = v
+	}
+	if v, err := strconv.ParseInt(c.Query("entity_id"), 10, 64); err == nil {
+		params.EntityID = v
+	}
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil {
+		params.Limit = v
+	}
+	if v, err := strconv.Atoi(c.Query("offset")); err == nil {
+		params.Offset = v
+	}
+	if fromStr := c.Query("from"); fromStr != "" {
+		if ts, err := time.Parse(time.RFC3339, fromStr); err == nil {
+			params.From = &ts
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		if ts, err := time.Parse(time.RFC3339, toStr); err == nil {
+			params.To = &ts
+		}
+	}
+	page, err := h.service.List(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load audit log"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
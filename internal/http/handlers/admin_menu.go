@@ -1,32 +1,130 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 
 	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/http/middleware"
 	"github.com/rashidmailru/kabobfood/internal/menu"
 )
 
 // AdminMenuHandler exposes admin CRUD endpoints for menu entities.
 type AdminMenuHandler struct {
-	service *admin.MenuService
+	service      *admin.MenuService
+	maxImageSize int64
 }
 
-func NewAdminMenuHandler(service *admin.MenuService) *AdminMenuHandler {
-	return &AdminMenuHandler{service: service}
+// NewAdminMenuHandler builds the handler; maxImageSize limits product image uploads.
+func NewAdminMenuHandler(service *admin.MenuService, maxImageSize int64) *AdminMenuHandler {
+	return &AdminMenuHandler{service: service, maxImageSize: maxImageSize}
 }
 
 func (h *AdminMenuHandler) Register(rg *gin.RouterGroup) {
-	rg.POST("/admin/categories", h.createCategory)
-	rg.PUT("/admin/categories/:id", h.updateCategory)
-	rg.DELETE("/admin/categories/:id", h.deleteCategory)
+	rg.GET("/admin/menu/export", middleware.RequirePermission(admin.PermMenuRead), h.exportMenu)
+	rg.POST("/admin/menu/import", middleware.RequirePermission(admin.PermMenuWrite), h.importMenu)
+	rg.GET("/admin/menu/draft", middleware.RequirePermission(admin.PermMenuRead), h.getDraft)
+	rg.PUT("/admin/menu/draft", middleware.RequirePermission(admin.PermMenuWrite), h.saveDraft)
+	rg.DELETE("/admin/menu/draft", middleware.RequirePermission(admin.PermMenuWrite), h.discardDraft)
+	rg.GET("/admin/menu/preview", middleware.RequirePermission(admin.PermMenuRead), h.previewDraft)
+	rg.POST("/admin/menu/publish", middleware.RequirePermission(admin.PermMenuWrite), h.publishDraft)
+	rg.GET("/admin/menu/versions", middleware.RequirePermission(admin.PermMenuRead), h.listVersions)
+	rg.POST("/admin/menu/rollback", middleware.RequirePermission(admin.PermMenuWrite), h.rollbackMenu)
 
-	rg.POST("/admin/products", h.createProduct)
-	rg.PUT("/admin/products/:id", h.updateProduct)
-	rg.DELETE("/admin/products/:id", h.deleteProduct)
+	rg.GET("/admin/categories", middleware.RequirePermission(admin.PermMenuRead), h.listCategories)
+	rg.PUT("/admin/categories/order", middleware.RequirePermission(admin.PermMenuWrite), h.reorderCategories)
+	rg.POST("/admin/categories", middleware.RequirePermission(admin.PermMenuWrite), h.createCategory)
+	rg.PUT("/admin/categories/:id", middleware.RequirePermission(admin.PermMenuWrite), h.updateCategory)
+	rg.DELETE("/admin/categories/:id", middleware.RequirePermission(admin.PermMenuWrite), h.deleteCategory)
+	rg.POST("/admin/categories/:id/restore", middleware.RequirePermission(admin.PermMenuWrite), h.restoreCategory)
+
+	rg.GET("/admin/products", middleware.RequirePermission(admin.PermMenuRead), h.listProducts)
+	rg.PUT("/admin/products/order", middleware.RequirePermission(admin.PermMenuWrite), h.reorderProducts)
+	rg.POST("/admin/products", middleware.RequirePermission(admin.PermMenuWrite), h.createProduct)
+	rg.PUT("/admin/products/:id", middleware.RequirePermission(admin.PermMenuWrite), h.updateProduct)
+	rg.DELETE("/admin/products/:id", middleware.RequirePermission(admin.PermMenuWrite), h.deleteProduct)
+	rg.POST("/admin/products/:id/restore", middleware.RequirePermission(admin.PermMenuWrite), h.restoreProduct)
+	rg.PUT("/admin/products/:id/availability", middleware.RequirePermission(admin.PermMenuWrite), h.setAvailability)
+	rg.GET("/admin/products/:id/bundle", middleware.RequirePermission(admin.PermMenuRead), h.getBundle)
+	rg.PUT("/admin/products/:id/bundle", middleware.RequirePermission(admin.PermMenuWrite), h.setBundle)
+	rg.POST("/admin/products/:id/image", middleware.RequirePermission(admin.PermMenuWrite), h.uploadProductImage)
+	rg.DELETE("/admin/products/:id/image", middleware.RequirePermission(admin.PermMenuWrite), h.deleteProductImage)
+}
+
+func (h *AdminMenuHandler) listCategories(c *gin.Context) {
+	archived, ok := optionalBool(c, "archived")
+	if !ok {
+		return
+	}
+	categories, err := h.service.ListCategories(c.Request.Context(), archived)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load categories"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+func (h *AdminMenuHandler) listProducts(c *gin.Context) {
+	params := menu.AdminProductParams{Query: c.Query("q")}
+	if v, err := strconv.ParseInt(c.Query("category_id"), 10, 64); err == nil {
+		params.CategoryID = v
+	}
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil {
+		params.Limit = v
+	}
+	if v, err := strconv.Atoi(c.Query("offset")); err == nil {
+		params.Offset = v
+	}
+	var ok bool
+	if params.Active, ok = optionalBool(c, "active"); !ok {
+		return
+	}
+	if params.Archived, ok = optionalBool(c, "archived"); !ok {
+		return
+	}
+	if params.Available, ok = optionalBool(c, "available"); !ok {
+		return
+	}
+	page, err := h.service.ListProducts(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load products"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+type reorderRequest struct {
+	Items []menu.SortOrderUpdate `json:"items"`
+}
+
+func (h *AdminMenuHandler) reorderCategories(c *gin.Context) {
+	var req reorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	if err := h.service.ReorderCategories(c.Request.Context(), req.Items); err != nil {
+		writeReorderError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AdminMenuHandler) reorderProducts(c *gin.Context) {
+	var req reorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	if err := h.service.ReorderProducts(c.Request.Context(), req.Items); err != nil {
+		writeReorderError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
 }
 
 func (h *AdminMenuHandler) createCategory(c *gin.Context) {
@@ -69,13 +167,27 @@ func (h *AdminMenuHandler) deleteCategory(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
-	if err := h.service.DeleteCategory(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if _, err := h.service.ArchiveCategory(c.Request.Context(), id); err != nil {
+		writeArchiveError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
+func (h *AdminMenuHandler) restoreCategory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	restored, err := h.service.RestoreCategory(c.Request.Context(), id)
+	if err != nil {
+		writeArchiveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, restored)
+}
+
 func (h *AdminMenuHandler) createProduct(c *gin.Context) {
 	var req menu.Product
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -83,6 +195,10 @@ func (h *AdminMenuHandler) createProduct(c *gin.Context) {
 		return
 	}
 	created, err := h.service.CreateProduct(c.Request.Context(), req)
+	if errors.Is(err, menu.ErrInvalidAttributes) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -103,6 +219,10 @@ func (h *AdminMenuHandler) updateProduct(c *gin.Context) {
 	}
 	req.ID = id
 	updated, err := h.service.UpdateProduct(c.Request.Context(), req)
+	if errors.Is(err, menu.ErrInvalidAttributes) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -110,15 +230,124 @@ func (h *AdminMenuHandler) updateProduct(c *gin.Context) {
 	c.JSON(http.StatusOK, updated)
 }
 
+func (h *AdminMenuHandler) setAvailability(c *gin.Context) {
+	var req menu.Availability
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	updated, err := h.service.SetAvailability(c.Request.Context(), id, req)
+	if errors.Is(err, admin.ErrInvalidStock) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeArchiveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+type bundleRequest struct {
+	Slots []menu.BundleSlot `json:"slots"`
+}
+
+func (h *AdminMenuHandler) getBundle(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	slots, err := h.service.GetBundle(c.Request.Context(), id)
+	if err != nil {
+		writeArchiveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"slots": slots})
+}
+
+func (h *AdminMenuHandler) setBundle(c *gin.Context) {
+	var req bundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	slots, err := h.service.SetBundle(c.Request.Context(), id, req.Slots)
+	if errors.Is(err, menu.ErrInvalidBundle) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeArchiveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"slots": slots})
+}
+
 func (h *AdminMenuHandler) deleteProduct(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
-	if err := h.service.DeleteProduct(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if _, err := h.service.ArchiveProduct(c.Request.Context(), id); err != nil {
+		writeArchiveError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *AdminMenuHandler) restoreProduct(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	restored, err := h.service.RestoreProduct(c.Request.Context(), id)
+	if err != nil {
+		writeArchiveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, restored)
+}
+
+// writeArchiveError maps archive, restore and reorder failures; unknown ids give 404.
+func writeArchiveError(c *gin.Context, err error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func writeReorderError(c *gin.Context, err error) {
+	if errors.Is(err, admin.ErrInvalidReorder) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	writeArchiveError(c, err)
+}
+
+// optionalBool parses a tri-state query flag; it writes 400 and returns false on bad input.
+func optionalBool(c *gin.Context, name string) (*bool, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be true or false"})
+		return nil, false
+	}
+	return &v, true
+}
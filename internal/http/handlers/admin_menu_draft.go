@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/menu"
+)
+
+func (h *AdminMenuHandler) getDraft(c *gin.Context) {
+	draft, err := h.service.GetDraft(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load draft"})
+		return
+	}
+	c.JSON(http.StatusOK, draft)
+}
+
+func (h *AdminMenuHandler) saveDraft(c *gin.Context) {
+	var req menu.Snapshot
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	draft, err := h.service.SaveDraft(c.Request.Context(), req)
+	if errors.Is(err, menu.ErrInvalidDraft) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save draft"})
+		return
+	}
+	c.JSON(http.StatusOK, draft)
+}
+
+func (h *AdminMenuHandler) discardDraft(c *gin.Context) {
+	if err := h.service.DiscardDraft(c.Request.Context()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to discard draft"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AdminMenuHandler) previewDraft(c *gin.Context) {
+	preview, err := h.service.PreviewDraft(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build preview"})
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+func (h *AdminMenuHandler) publishDraft(c *gin.Context) {
+	version, err := h.service.PublishDraft(c.Request.Context())
+	switch {
+	case errors.Is(err, admin.ErrNoDraft), errors.Is(err, menu.ErrDraftOutdated):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, menu.ErrInvalidDraft):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to publish menu"})
+	default:
+		c.JSON(http.StatusOK, version)
+	}
+}
+
+func (h *AdminMenuHandler) listVersions(c *gin.Context) {
+	versions, err := h.service.ListVersions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load versions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
+
+type rollbackRequest struct {
+	Version int64 `json:"version"`
+}
+
+// rollbackMenu restores {"version": n}; an empty body restores the version
+// before the current one.
+func (h *AdminMenuHandler) rollbackMenu(c *gin.Context) {
+	var req rollbackRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+	}
+	version, err := h.service.RollbackMenu(c.Request.Context(), req.Version)
+	if err != nil {
+		writeArchiveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, version)
+}
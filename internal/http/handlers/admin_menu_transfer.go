@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/menu"
+)
+
+const maxMenuImportSize = 5 << 20
+
+func (h *AdminMenuHandler) exportMenu(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", menu.FormatJSON))
+	if format != menu.FormatCSV && format != menu.FormatJSON {
+		c.JSON(http.StatusBadRequest, gin.H{"error": menu.ErrUnknownFormat.Error()})
+		return
+	}
+	var buf bytes.Buffer
+	if err := h.service.ExportMenu(c.Request.Context(), &buf, format); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export menu"})
+		return
+	}
+	contentType := "application/json"
+	if format == menu.FormatCSV {
+		contentType = "text/csv; charset=utf-8"
+	}
+	filename := "menu-" + time.Now().Format("20060102") + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// importMenu accepts the file as the raw body or as the "file" field of a
+// multipart form. The format comes from ?format=, the file name or Content-Type.
+func (h *AdminMenuHandler) importMenu(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxMenuImportSize)
+	format := strings.ToLower(c.Query("format"))
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+			return
+		}
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+	}
+	if format == "" {
+		format = menu.FormatJSON
+		if strings.Contains(c.ContentType(), "csv") {
+			format = menu.FormatCSV
+		}
+	}
+	if format != menu.FormatCSV && format != menu.FormatJSON {
+		c.JSON(http.StatusBadRequest, gin.H{"error": menu.ErrUnknownFormat.Error()})
+		return
+	}
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+	deactivate, _ := strconv.ParseBool(c.Query("deactivate_missing"))
+
+	result, err := h.service.ImportMenu(c.Request.Context(), body, admin.ImportOptions{
+		Format:            format,
+		DryRun:            dryRun,
+		DeactivateMissing: deactivate,
+	})
+	switch {
+	case errors.Is(err, admin.ErrImportInvalid):
+		c.JSON(http.StatusUnprocessableEntity, result)
+	case errors.Is(err, menu.ErrInvalidFile):
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to import menu"})
+	default:
+		c.JSON(http.StatusOK, result)
+	}
+}
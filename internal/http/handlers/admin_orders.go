@@ -1,27 +1,35 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/http/middleware"
 	"github.com/rashidmailru/kabobfood/internal/orders"
+	"github.com/rashidmailru/kabobfood/internal/receipt"
 )
 
 // AdminOrdersHandler exposes operator endpoints for orders.
 type AdminOrdersHandler struct {
-	service *orders.AdminService
+	service  *orders.AdminService
+	receipts *receipt.Renderer
 }
 
-func NewAdminOrdersHandler(service *orders.AdminService) *AdminOrdersHandler {
-	return &AdminOrdersHandler{service: service}
+func NewAdminOrdersHandler(service *orders.AdminService, receipts *receipt.Renderer) *AdminOrdersHandler {
+	return &AdminOrdersHandler{service: service, receipts: receipts}
 }
 
 func (h *AdminOrdersHandler) Register(rg *gin.RouterGroup) {
-	rg.GET("/admin/orders", h.list)
-	rg.PUT("/admin/orders/:id/status", h.updateStatus)
+	rg.GET("/admin/orders", middleware.RequirePermission(admin.PermOrdersRead), h.list)
+	rg.GET("/admin/orders/:id/receipt", middleware.RequirePermission(admin.PermOrdersRead), h.receipt)
+	rg.PUT("/admin/orders/:id/status", middleware.RequireAnyPermission(admin.PermOrdersStatus, admin.PermOrdersDeliver), h.updateStatus)
 }
 
 func (h *AdminOrdersHandler) list(c *gin.Context) {
@@ -60,6 +68,8 @@ func (h *AdminOrdersHandler) list(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"orders": ordersList})
 }
 
+var deliveryStatuses = map[string]bool{orders.StatusDelivery: true, orders.StatusDelivered: true}
+
 func (h *AdminOrdersHandler) updateStatus(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -71,10 +81,52 @@ func (h *AdminOrdersHandler) updateStatus(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
 		return
 	}
+	// Couriers may only move orders along the delivery leg.
+	if !middleware.HasPermission(c, admin.PermOrdersStatus) && !deliveryStatuses[strings.ToLower(req.Status)] {
+		middleware.Forbidden(c, admin.PermOrdersStatus)
+		return
+	}
 	order, err := h.service.UpdateStatus(c.Request.Context(), id, req.Status)
 	if err != nil {
+		if errors.Is(err, orders.ErrOrderCanceled) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, order)
 }
+
+func (h *AdminOrdersHandler) receipt(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	format, err := receipt.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be escpos, text or pdf"})
+		return
+	}
+	layout, err := receipt.ParseLayout(c.Query("layout"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "layout must be kitchen or customer"})
+		return
+	}
+	order, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		writeArchiveError(c, err)
+		return
+	}
+	data, err := h.receipts.Render(order, layout, format)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render receipt"})
+		return
+	}
+	if format != receipt.FormatText {
+		ext := map[receipt.Format]string{receipt.FormatPDF: "pdf", receipt.FormatESCPOS: "bin"}[format]
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=order-%d-%s.%s", order.ID, layout, ext))
+	}
+	c.Data(http.StatusOK, receipt.ContentType(format), data)
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/http/middleware"
+	"github.com/rashidmailru/kabobfood/internal/pricing"
+)
+
+// AdminPriceRulesHandler manages scheduled prices and happy hours.
+type AdminPriceRulesHandler struct {
+	service *admin.PriceRuleService
+}
+
+func NewAdminPriceRulesHandler(service *admin.PriceRuleService) *AdminPriceRulesHandler {
+	return &AdminPriceRulesHandler{service: service}
+}
+
+func (h *AdminPriceRulesHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/price-rules", middleware.RequirePermission(admin.PermMenuRead), h.list)
+	rg.POST("/admin/price-rules", middleware.RequirePermission(admin.PermMenuWrite), h.create)
+	rg.DELETE("/admin/price-rules/:id", middleware.RequirePermission(admin.PermMenuWrite), h.delete)
+}
+
+func (h *AdminPriceRulesHandler) list(c *gin.Context) {
+	var params pricing.ListParams
+	if v, err := strconv.ParseInt(c.Query("product_id"), 10, 64); err == nil {
+		params.ProductID = v
+	}
+	if v, err := strconv.ParseInt(c.Query("category_id"), 10, 64); err == nil {
+		params.CategoryID = v
+	}
+	params.Expired, _ = strconv.ParseBool(c.Query("expired"))
+	rules, err := h.service.List(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load price rules"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+func (h *AdminPriceRulesHandler) create(c *gin.Context) {
+	var req pricing.Rule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	created, err := h.service.Create(c.Request.Context(), req)
+	if errors.Is(err, pricing.ErrInvalidRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *AdminPriceRulesHandler) delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		writeArchiveError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
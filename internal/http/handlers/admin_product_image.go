@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/rashidmailru/kabobfood/internal/admin"
+)
+
+// multipartOverhead leaves room for boundaries and part headers around the file.
+const multipartOverhead = 64 << 10
+
+// uploadProductImage accepts the image as the "image" field of a multipart form.
+func (h *AdminMenuHandler) uploadProductImage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxImageSize+multipartOverhead)
+	header, err := c.FormFile("image")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "image is too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image is required"})
+		return
+	}
+	if header.Size > h.maxImageSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "image is too large"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read image"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, h.maxImageSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read image"})
+		return
+	}
+	if int64(len(data)) > h.maxImageSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "image is too large"})
+		return
+	}
+
+	updated, err := h.service.SetProductImage(c.Request.Context(), id, data)
+	switch {
+	case errors.Is(err, admin.ErrUnsupportedImage):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, admin.ErrImagesDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case err != nil:
+		writeArchiveError(c, err)
+	default:
+		c.JSON(http.StatusOK, updated)
+	}
+}
+
+func (h *AdminMenuHandler) deleteProductImage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	updated, err := h.service.DeleteProductImage(c.Request.Context(), id)
+	if err != nil {
+		writeArchiveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
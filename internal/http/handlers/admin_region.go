@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/http/middleware"
 	"github.com/rashidmailru/kabobfood/internal/regions"
 )
 
@@ -20,9 +21,24 @@ func NewAdminRegionHandler(service *admin.RegionService) *AdminRegionHandler {
 }
 
 func (h *AdminRegionHandler) Register(rg *gin.RouterGroup) {
-	rg.POST("/admin/regions", h.createRegion)
-	rg.PUT("/admin/regions/:id", h.updateRegion)
-	rg.DELETE("/admin/regions/:id", h.deleteRegion)
+	rg.GET("/admin/regions", middleware.RequirePermission(admin.PermRegionsRead), h.listRegions)
+	rg.POST("/admin/regions", middleware.RequirePermission(admin.PermRegionsWrite), h.createRegion)
+	rg.PUT("/admin/regions/:id", middleware.RequirePermission(admin.PermRegionsWrite), h.updateRegion)
+	rg.DELETE("/admin/regions/:id", middleware.RequirePermission(admin.PermRegionsWrite), h.deleteRegion)
+	rg.POST("/admin/regions/:id/restore", middleware.RequirePermission(admin.PermRegionsWrite), h.restoreRegion)
+}
+
+func (h *AdminRegionHandler) listRegions(c *gin.Context) {
+	archived, ok := optionalBool(c, "archived")
+	if !ok {
+		return
+	}
+	list, err := h.service.ListRegions(c.Request.Context(), archived)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load regions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"regions": list})
 }
 
 func (h *AdminRegionHandler) createRegion(c *gin.Context) {
@@ -65,9 +81,23 @@ func (h *AdminRegionHandler) deleteRegion(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
 		return
 	}
-	if err := h.service.DeleteRegion(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if _, err := h.service.ArchiveRegion(c.Request.Context(), id); err != nil {
+		writeArchiveError(c, err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *AdminRegionHandler) restoreRegion(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	restored, err := h.service.RestoreRegion(c.Request.Context(), id)
+	if err != nil {
+		writeArchiveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, restored)
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/http/middleware"
+)
+
+// AdminUsersHandler manages staff accounts.
+type AdminUsersHandler struct {
+	service *admin.UserService
+}
+
+// NewAdminUsersHandler builds handler.
+func NewAdminUsersHandler(service *admin.UserService) *AdminUsersHandler {
+	return &AdminUsersHandler{service: service}
+}
+
+// Register wires staff management routes.
+func (h *AdminUsersHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/users", middleware.RequirePermission(admin.PermUsersManage), h.list)
+	rg.POST("/admin/users", middleware.RequirePermission(admin.PermUsersManage), h.create)
+	rg.GET("/admin/users/:id", middleware.RequirePermission(admin.PermUsersManage), h.get)
+	rg.PUT("/admin/users/:id", middleware.RequirePermission(admin.PermUsersManage), h.update)
+	rg.DELETE("/admin/users/:id", middleware.RequirePermission(admin.PermUsersManage), h.delete)
+}
+
+func (h *AdminUsersHandler) list(c *gin.Context) {
+	users, err := h.service.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load users"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users, "roles": admin.Roles()})
+}
+
+func (h *AdminUsersHandler) create(c *gin.Context) {
+	var req admin.CreateUserInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	user, err := h.service.Create(c.Request.Context(), req)
+	if err != nil {
+		writeAdminUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, user)
+}
+
+func (h *AdminUsersHandler) get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	user, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		writeAdminUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *AdminUsersHandler) update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	var req admin.UpdateUserInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+		return
+	}
+	actorID, _ := middleware.AdminIDFromContext(c)
+	user, err := h.service.Update(c.Request.Context(), actorID, id, req)
+	if err != nil {
+		writeAdminUserError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *AdminUsersHandler) delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	actorID, _ := middleware.AdminIDFromContext(c)
+	if err := h.service.Delete(c.Request.Context(), actorID, id); err != nil {
+		writeAdminUserError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func writeAdminUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, admin.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, admin.ErrUsernameTaken), errors.Is(err, admin.ErrLastOwner), errors.Is(err, admin.ErrSelfModification):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, admin.ErrInvalidRole), errors.Is(err, admin.ErrInvalidUsername), errors.Is(err, admin.ErrWeakPassword):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save user"})
+	}
+}
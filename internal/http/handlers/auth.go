@@ -5,23 +5,29 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
 
+	"github.com/rashidmailru/kabobfood/internal/admin"
 	"github.com/rashidmailru/kabobfood/internal/auth"
+	"github.com/rashidmailru/kabobfood/internal/tokens"
 )
 
-// AuthHandler handles Telegram WebApp authentication requests.
+// AuthHandler handles Telegram WebApp authentication requests and token refresh for users and staff.
 type AuthHandler struct {
 	service *auth.Service
+	tokens  *tokens.Service
 }
 
 // NewAuthHandler constructs AuthHandler.
-func NewAuthHandler(service *auth.Service) *AuthHandler {
-	return &AuthHandler{service: service}
+func NewAuthHandler(service *auth.Service, tokenService *tokens.Service) *AuthHandler {
+	return &AuthHandler{service: service, tokens: tokenService}
 }
 
 // Register wires auth routes.
 func (h *AuthHandler) Register(rg *gin.RouterGroup) {
 	rg.POST("/auth/telegram", h.authenticateTelegram)
+	rg.POST("/auth/refresh", h.refresh)
+	rg.POST("/auth/logout", h.logout)
 }
 
 type telegramAuthRequest struct {
@@ -47,3 +53,61 @@ func (h *AuthHandler) authenticateTelegram(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// refresh rotates a refresh token and returns a new token pair.
+func (h *AuthHandler) refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+	session, err := h.tokens.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, tokens.ErrInvalidRefresh), errors.Is(err, tokens.ErrRefreshReused),
+			errors.Is(err, admin.ErrUserNotFound), errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, admin.ErrAccountDisabled):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+	// All ends every session of the account, not only this one.
+	All bool `json:"all"`
+}
+
+// logout revokes the refresh token family (or all sessions) and the access tokens issued with it.
+func (h *AuthHandler) logout(c *gin.Context) {
+	var req logoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+	ctx := c.Request.Context()
+	var err error
+	if req.All {
+		err = h.tokens.LogoutAll(ctx, req.RefreshToken)
+	} else {
+		err = h.tokens.Logout(ctx, req.RefreshToken)
+	}
+	if err != nil {
+		if errors.Is(err, tokens.ErrInvalidRefresh) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
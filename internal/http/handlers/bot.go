@@ -1,23 +1,27 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/rashidmailru/kabobfood/internal/auth"
+	"github.com/rashidmailru/kabobfood/internal/lockout"
 )
 
 // BotHandler handles registration requests originating from the Telegram bot.
 type BotHandler struct {
 	service *auth.Service
+	guard   *lockout.Guard
 }
 
-// NewBotHandler builds BotHandler.
-func NewBotHandler(service *auth.Service) *BotHandler {
-	return &BotHandler{service: service}
+// NewBotHandler builds BotHandler; guard limits rejected registrations per IP and Telegram id.
+func NewBotHandler(service *auth.Service, guard *lockout.Guard) *BotHandler {
+	return &BotHandler{service: service, guard: guard}
 }
 
 // Register wires bot-specific routes.
@@ -40,11 +44,28 @@ type botLocationPayload struct {
 }
 
 func (h *BotHandler) register(c *gin.Context) {
+	ctx := c.Request.Context()
+	ipKey := lockout.IP(c.ClientIP())
+	if h.guard != nil {
+		if wait := h.guard.Check(ctx, ipKey); wait > 0 {
+			respondLocked(c, wait)
+			return
+		}
+	}
+
 	var req botRegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
+		h.fail(ctx, ipKey)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
+	keys := []lockout.Key{ipKey, {Kind: "telegram_id", Value: strconv.FormatInt(req.TelegramID, 10)}}
+	if h.guard != nil {
+		if wait := h.guard.Check(ctx, keys[1]); wait > 0 {
+			respondLocked(c, wait)
+			return
+		}
+	}
 
 	firstName := strings.TrimSpace(req.FirstName)
 	if firstName == "" {
@@ -53,14 +74,17 @@ func (h *BotHandler) register(c *gin.Context) {
 	lastName := strings.TrimSpace(req.LastName)
 	phone := strings.TrimSpace(req.Phone)
 	if firstName == "" {
+		h.fail(ctx, keys...)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "first name is required"})
 		return
 	}
 	if phone == "" {
+		h.fail(ctx, keys...)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "phone is required"})
 		return
 	}
 	if req.Location == nil {
+		h.fail(ctx, keys...)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "location is required"})
 		return
 	}
@@ -68,7 +92,7 @@ func (h *BotHandler) register(c *gin.Context) {
 	lat := req.Location.Latitude
 	lon := req.Location.Longitude
 
-	result, err := h.service.RegisterBotUser(c.Request.Context(), auth.BotRegisterInput{
+	result, err := h.service.RegisterBotUser(ctx, auth.BotRegisterInput{
 		TelegramID: req.TelegramID,
 		FirstName:  firstName,
 		LastName:   lastName,
@@ -78,6 +102,7 @@ func (h *BotHandler) register(c *gin.Context) {
 	})
 	if err != nil {
 		if errors.Is(err, auth.ErrInvalidRegisterInput) {
+			h.fail(ctx, keys...)
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
@@ -87,3 +112,9 @@ func (h *BotHandler) register(c *gin.Context) {
 
 	c.JSON(http.StatusOK, result)
 }
+
+func (h *BotHandler) fail(ctx context.Context, keys ...lockout.Key) {
+	if h.guard != nil {
+		h.guard.Fail(ctx, keys...)
+	}
+}
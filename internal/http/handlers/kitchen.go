@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/http/middleware"
+	"github.com/rashidmailru/kabobfood/internal/kitchen"
+)
+
+// KitchenHandler exposes the kitchen display endpoints for cooks.
+type KitchenHandler struct {
+	service  *kitchen.Service
+	interval time.Duration
+}
+
+// NewKitchenHandler builds handler; interval controls how often the stream polls for changes.
+func NewKitchenHandler(service *kitchen.Service, interval time.Duration) *KitchenHandler {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	return &KitchenHandler{service: service, interval: interval}
+}
+
+// Register wires kitchen routes.
+func (h *KitchenHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/kitchen/tickets", middleware.RequirePermission(admin.PermKitchenRead), h.list)
+	rg.GET("/kitchen/tickets/stream", middleware.RequirePermission(admin.PermKitchenRead), h.stream)
+	rg.PUT("/kitchen/tickets/:id/items/:itemId/done", middleware.RequirePermission(admin.PermKitchenWrite), h.markDone)
+}
+
+func (h *KitchenHandler) list(c *gin.Context) {
+	board, err := h.service.Board(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load tickets"})
+		return
+	}
+	c.JSON(http.StatusOK, board)
+}
+
+// stream pushes the board as server-sent events whenever it changes.
+func (h *KitchenHandler) stream(c *gin.Context) {
+	ctx := c.Request.Context()
+	// The stream outlives the server write timeout, so lift it for this response.
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	var lastVersion string
+	c.Stream(func(w io.Writer) bool {
+		board, err := h.service.Board(ctx)
+		if err != nil {
+			c.SSEvent("error", gin.H{"error": "failed to load tickets"})
+			return false
+		}
+		if board.Version != lastVersion {
+			c.SSEvent("tickets", board)
+			lastVersion = board.Version
+		} else {
+			c.SSEvent("ping", gin.H{"generated_at": board.GeneratedAt})
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+			return true
+		}
+	})
+}
+
+type kitchenDoneRequest struct {
+	Done *bool `json:"done"`
+}
+
+func (h *KitchenHandler) markDone(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	itemID, err := strconv.ParseInt(c.Param("itemId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		return
+	}
+	done := true
+	var req kitchenDoneRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+		if req.Done != nil {
+			done = *req.Done
+		}
+	}
+	item, err := h.service.MarkItemDone(c.Request.Context(), orderID, itemID, done)
+	if err != nil {
+		if errors.Is(err, kitchen.ErrItemNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update item"})
+		return
+	}
+	c.JSON(http.StatusOK, item)
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondLocked rejects an attempt made before the brute-force delay or lockout expired.
+func respondLocked(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed attempts", "code": "locked_out", "retry_after": seconds})
+}
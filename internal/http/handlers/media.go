@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/rashidmailru/kabobfood/internal/storage"
+)
+
+// MediaHandler serves files kept by the local storage backend.
+type MediaHandler struct {
+	fs http.FileSystem
+}
+
+// NewMediaHandler serves files from dir under /media.
+func NewMediaHandler(dir string) *MediaHandler {
+	return &MediaHandler{fs: http.Dir(dir)}
+}
+
+func (h *MediaHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/media/*filepath", h.serve)
+	rg.HEAD("/media/*filepath", h.serve)
+}
+
+// serve sends a stored file; names are content hashes, so responses are cached
+// for a year. Directories are not listed.
+func (h *MediaHandler) serve(c *gin.Context) {
+	name := path.Clean("/" + c.Param("filepath"))
+	if strings.HasSuffix(c.Param("filepath"), "/") {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	file, err := h.fs.Open(name)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.Header("Cache-Control", storage.CacheControl)
+	c.Header("X-Content-Type-Options", "nosniff")
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), file)
+}
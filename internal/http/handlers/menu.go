@@ -1,35 +1,64 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/rashidmailru/kabobfood/internal/http/middleware"
 	"github.com/rashidmailru/kabobfood/internal/menu"
 )
 
 // MenuHandler exposes menu + regions endpoints for Mini App.
 type MenuHandler struct {
-	service *menu.Service
+	service     *menu.Service
+	conditional gin.HandlerFunc
+	maxAge      time.Duration
 }
 
-// NewMenuHandler creates handler.
-func NewMenuHandler(service *menu.Service) *MenuHandler {
-	return &MenuHandler{service: service}
+// NewMenuHandler creates handler. Menu and regions responses carry an ETag
+// and may be reused by clients for cacheMaxAge, the menu no longer than until
+// its next price change.
+func NewMenuHandler(service *menu.Service, cacheMaxAge time.Duration) *MenuHandler {
+	return &MenuHandler{service: service, conditional: middleware.Conditional(cacheMaxAge), maxAge: cacheMaxAge}
 }
 
 // Register wires routes.
 func (h *MenuHandler) Register(rg *gin.RouterGroup) {
-	rg.GET("/menu", h.getMenu)
-	rg.GET("/regions", h.getRegions)
+	rg.GET("/menu", h.conditional, h.getMenu)
+	rg.GET("/menu/search", h.search)
+	rg.GET("/regions", h.conditional, h.getRegions)
 }
 
 func (h *MenuHandler) getMenu(c *gin.Context) {
-	resp, err := h.service.GetMenu(c.Request.Context())
+	filter := menu.MenuFilter{
+		Tags:             queryList(c, "tags"),
+		ExcludeAllergens: queryList(c, "exclude_allergens"),
+	}
+	resp, err := h.service.GetMenu(c.Request.Context(), filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load menu"})
 		return
 	}
+	middleware.CapMaxAge(c, resp.MaxAge(h.maxAge, time.Now()))
+	c.JSON(http.StatusOK, resp)
+}
+
+func (h *MenuHandler) search(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	resp, err := h.service.Search(c.Request.Context(), c.Query("q"), limit)
+	if errors.Is(err, menu.ErrEmptyQuery) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search menu"})
+		return
+	}
 	c.JSON(http.StatusOK, resp)
 }
 
@@ -41,3 +70,16 @@ func (h *MenuHandler) getRegions(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"regions": resp})
 }
+
+// queryList accepts both repeated (?tags=a&tags=b) and comma-separated (?tags=a,b) values.
+func queryList(c *gin.Context, name string) []string {
+	var out []string
+	for _, v := range c.QueryArray(name) {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -41,6 +42,11 @@ func (h *OrdersHandler) create(c *gin.Context) {
 	}
 
 	order, err := h.service.Create(c.Request.Context(), userID, req)
+	var stockErr *orders.StockError
+	if errors.As(err, &stockErr) {
+		c.JSON(http.StatusConflict, gin.H{"error": stockErr.Error(), "item": stockErr})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
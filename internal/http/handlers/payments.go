@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/rashidmailru/kabobfood/internal/admin"
+	"github.com/rashidmailru/kabobfood/internal/http/middleware"
+	"github.com/rashidmailru/kabobfood/internal/payments"
+)
+
+// PaymentsHandler exposes payment endpoints for customers.
+type PaymentsHandler struct {
+	service *payments.Service
+}
+
+// NewPaymentsHandler constructs handler.
+func NewPaymentsHandler(service *payments.Service) *PaymentsHandler {
+	return &PaymentsHandler{service: service}
+}
+
+// Register wires payment routes (auth required).
+func (h *PaymentsHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/orders/:id/payment", h.start)
+	rg.GET("/orders/:id/payment", h.get)
+	rg.POST("/orders/:id/payment/invoice", h.sendInvoice)
+}
+
+func (h *PaymentsHandler) start(c *gin.Context) {
+	userID, orderID, ok := userOrderParams(c)
+	if !ok {
+		return
+	}
+	payment, err := h.service.Start(c.Request.Context(), userID, orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		case errors.Is(err, payments.ErrOfflineProvider), errors.Is(err, payments.ErrNotPayable), errors.Is(err, payments.ErrUnknownMethod):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to start payment"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, payment)
+}
+
+// sendInvoice delivers a Telegram invoice to the user's chat with the bot.
+func (h *PaymentsHandler) sendInvoice(c *gin.Context) {
+	userID, orderID, ok := userOrderParams(c)
+	if !ok {
+		return
+	}
+	payment, err := h.service.SendInvoice(c.Request.Context(), userID, orderID)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		case errors.Is(err, payments.ErrNotPayable), errors.Is(err, payments.ErrUnknownMethod), errors.Is(err, payments.ErrOfflineProvider), errors.Is(err, payments.ErrNoTelegramChat):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to send invoice"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, payment)
+}
+
+func (h *PaymentsHandler) get(c *gin.Context) {
+	userID, orderID, ok := userOrderParams(c)
+	if !ok {
+		return
+	}
+	payment, err := h.service.Get(c.Request.Context(), userID, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) || errors.Is(err, payments.ErrPaymentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load payment"})
+		return
+	}
+	c.JSON(http.StatusOK, payment)
+}
+
+func userOrderParams(c *gin.Context) (int64, int64, bool) {
+	userID, ok := middleware.UserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return 0, 0, false
+	}
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return 0, 0, false
+	}
+	return userID, orderID, true
+}
+
+// PaymentCallbacksHandler receives provider notifications; authenticity is checked by each provider.
+type PaymentCallbacksHandler struct {
+	service *payments.Service
+}
+
+// NewPaymentCallbacksHandler constructs handler.
+func NewPaymentCallbacksHandler(service *payments.Service) *PaymentCallbacksHandler {
+	return &PaymentCallbacksHandler{service: service}
+}
+
+// Register wires public callback routes.
+func (h *PaymentCallbacksHandler) Register(rg *gin.RouterGroup) {
+	rg.POST("/payments/:provider/callback", h.callback)
+	rg.GET("/payments/fake/checkout/:externalId", h.fakeCheckout)
+}
+
+func (h *PaymentCallbacksHandler) callback(c *gin.Context) {
+	resp, err := h.service.HandleCallback(c.Request.Context(), c.Param("provider"), c.Request)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(resp.StatusCode, resp.Body)
+}
+
+// fakeCheckout completes a fake payment; ?status=failed simulates a declined card.
+func (h *PaymentCallbacksHandler) fakeCheckout(c *gin.Context) {
+	status := c.DefaultQuery("status", payments.StatusPaid)
+	resp, err := h.service.SimulateFake(c.Request.Context(), c.Param("externalId"), status)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(resp.StatusCode, resp.Body)
+}
+
+// AdminPaymentsHandler lists payment attempts for operators.
+type AdminPaymentsHandler struct {
+	service *payments.Service
+}
+
+// NewAdminPaymentsHandler constructs handler.
+func NewAdminPaymentsHandler(service *payments.Service) *AdminPaymentsHandler {
+	return &AdminPaymentsHandler{service: service}
+}
+
+// Register wires admin payment routes.
+func (h *AdminPaymentsHandler) Register(rg *gin.RouterGroup) {
+	rg.GET("/admin/orders/:id/payments", middleware.RequirePermission(admin.PermPaymentsRead), h.list)
+	rg.GET("/admin/orders/:id/refunds", middleware.RequirePermission(admin.PermPaymentsRead), h.listRefunds)
+	rg.POST("/admin/orders/:id/refunds", middleware.RequirePermission(admin.PermPaymentsRefund), h.refund)
+	rg.PUT("/admin/orders/:id/refunds/:refund_id/status", middleware.RequirePermission(admin.PermPaymentsRefund), h.confirmRefund)
+	rg.GET("/admin/reports/payments", middleware.RequirePermission(admin.PermReportsRead), h.report)
+}
+
+func (h *AdminPaymentsHandler) list(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	list, err := h.service.ListForOrder(c.Request.Context(), orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load payments"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"payments": list})
+}
+
+func (h *AdminPaymentsHandler) listRefunds(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	list, err := h.service.ListRefunds(c.Request.Context(), orderID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load refunds"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"refunds": list})
+}
+
+func (h *AdminPaymentsHandler) refund(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	var req payments.RefundInput
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+	}
+	adminID, _ := middleware.AdminIDFromContext(c)
+	refund, err := h.service.Refund(c.Request.Context(), orderID, adminID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		case errors.Is(err, payments.ErrInvalidRefund):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, payments.ErrNothingToRefund), errors.Is(err, payments.ErrRefundExceedsPaid):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, payments.ErrRefundFailed):
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "refund": refund})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refund"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, refund)
+}
+
+func (h *AdminPaymentsHandler) confirmRefund(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	refundID, err := strconv.ParseInt(c.Param("refund_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid refund id"})
+		return
+	}
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Status != payments.RefundSucceeded && req.Status != payments.RefundFailed) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be succeeded or failed"})
+		return
+	}
+	refund, err := h.service.ConfirmRefund(c.Request.Context(), orderID, refundID, req.Status == payments.RefundSucceeded)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			c.JSON(http.StatusNotFound, gin.H{"error": "refund not found"})
+		case errors.Is(err, payments.ErrRefundNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update refund"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, refund)
+}
+
+func (h *AdminPaymentsHandler) report(c *gin.Context) {
+	var params payments.ReportParams
+	if fromStr := c.Query("from"); fromStr != "" {
+		if ts, err := time.Parse(time.RFC3339, fromStr); err == nil {
+			params.From = &ts
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		if ts, err := time.Parse(time.RFC3339, toStr); err == nil {
+			params.To = &ts
+		}
+	}
+	report, err := h.service.Report(c.Request.Context(), params)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
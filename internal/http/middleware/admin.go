@@ -1,44 +1,171 @@
 package middleware
 
 import (
-	"errors"
+	"context"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/rashidmailru/kabobfood/internal/audit"
+)
+
+const (
+	adminIDContextKey        = "admin_id"
+	staffRoleContextKey      = "staff_role"
+	permissionsContextKey    = "staff_permissions"
+	passwordChangeContextKey = "staff_password_change"
+	totpEnrollContextKey     = "staff_totp_enroll"
 )
 
-const adminRoleKey = "admin"
+// StaffSessionChecker confirms that a staff token is still honoured, e.g. the account
+// was not disabled and its token version did not change since the token was issued.
+type StaffSessionChecker interface {
+	CheckSession(ctx context.Context, adminID, tokenVersion int64) error
+}
 
-// AdminJWT guards admin routes with role verification.
-func AdminJWT(secret string) gin.HandlerFunc {
+// AdminJWT authenticates staff tokens and exposes their role and permissions;
+// routes declare what they need with RequirePermission. checker and denylist are optional.
+func AdminJWT(verifier TokenVerifier, checker StaffSessionChecker, denylist TokenDenylist) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := extractBearer(c.GetHeader("Authorization"))
 		if tokenString == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
 			return
 		}
-		parsed, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return []byte(secret), nil
-		})
-		if err != nil || !parsed.Valid {
+		claims, err := verifier.Parse(tokenString)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
-		claims, ok := parsed.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+		role, _ := claims["role"].(string)
+		if role == "" {
+			Forbidden(c, "")
+			return
+		}
+		adminID, err := extractUserID(claims)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
 			return
 		}
-		role, _ := claims["role"].(string)
-		if !strings.EqualFold(role, adminRoleKey) {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		if tokenRevoked(c, denylist, claims) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			return
+		}
+		if checker != nil {
+			version, _ := claims["tv"].(float64)
+			if err := checker.CheckSession(c.Request.Context(), adminID, int64(version)); err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+				return
+			}
+		}
+		c.Set(adminIDContextKey, adminID)
+		c.Set(staffRoleContextKey, strings.ToLower(role))
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), audit.Actor{
+			ID:   adminID,
+			Role: strings.ToLower(role),
+			IP:   c.ClientIP(),
+		}))
+		c.Set(permissionsContextKey, extractPermissions(claims))
+		if pending, _ := claims["pwd_change"].(bool); pending {
+			c.Set(passwordChangeContextKey, true)
+		}
+		if pending, _ := claims["totp_enroll"].(bool); pending {
+			c.Set(totpEnrollContextKey, true)
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission aborts with 403 unless the staff token grants perm.
+func RequirePermission(perm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if accountActionPending(c) {
+			return
+		}
+		if !HasPermission(c, perm) {
+			Forbidden(c, perm)
 			return
 		}
 		c.Next()
 	}
 }
+
+// RequireAnyPermission aborts with 403 unless the staff token grants at least one of perms.
+func RequireAnyPermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if accountActionPending(c) {
+			return
+		}
+		for _, perm := range perms {
+			if HasPermission(c, perm) {
+				c.Next()
+				return
+			}
+		}
+		Forbidden(c, strings.Join(perms, "|"))
+	}
+}
+
+// HasPermission reports whether the authenticated staff member holds perm.
+func HasPermission(c *gin.Context, perm string) bool {
+	val, ok := c.Get(permissionsContextKey)
+	if !ok {
+		return false
+	}
+	perms, _ := val.(map[string]struct{})
+	_, ok = perms[perm]
+	return ok
+}
+
+// Forbidden writes the uniform 403 body for denied staff actions.
+func Forbidden(c *gin.Context, perm string) {
+	body := gin.H{"error": "forbidden", "code": "permission_denied"}
+	if perm != "" {
+		body["required_permission"] = perm
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, body)
+}
+
+// accountActionPending aborts with 403 while the account still has to replace its
+// password or enroll a second factor.
+func accountActionPending(c *gin.Context) bool {
+	switch {
+	case c.GetBool(passwordChangeContextKey):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "password change required", "code": "password_change_required"})
+	case c.GetBool(totpEnrollContextKey):
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "two-factor enrollment required", "code": "totp_enrollment_required"})
+	default:
+		return false
+	}
+	return true
+}
+
+// AdminIDFromContext fetches authenticated staff user id from Gin context.
+func AdminIDFromContext(c *gin.Context) (int64, bool) {
+	val, ok := c.Get(adminIDContextKey)
+	if !ok {
+		return 0, false
+	}
+	id, ok := val.(int64)
+	return id, ok
+}
+
+// StaffRoleFromContext fetches authenticated staff role from Gin context.
+func StaffRoleFromContext(c *gin.Context) string {
+	role, _ := c.Get(staffRoleContextKey)
+	s, _ := role.(string)
+	return s
+}
+
+func extractPermissions(claims jwt.MapClaims) map[string]struct{} {
+	raw, _ := claims["perms"].([]interface{})
+	perms := make(map[string]struct{}, len(raw))
+	for _, p := range raw {
+		if s, ok := p.(string); ok {
+			perms[s] = struct{}{}
+		}
+	}
+	return perms
+}
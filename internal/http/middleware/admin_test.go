@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/rashidmailru/kabobfood/internal/tokens"
+)
+
+func testKeyring(t *testing.T) *tokens.Keyring {
+	t.Helper()
+	keys, err := tokens.NewKeyring("kabobfood", "test", []*tokens.Key{tokens.NewHMACKey("k1", "test-secret")}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := testKeyring(t)
+	router := gin.New()
+	group := router.Group("", AdminJWT(keys, nil, nil))
+	group.GET("/menu", RequirePermission("menu:write"), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	sign := func(claims jwt.MapClaims) string {
+		claims["sub"] = 1
+		claims["exp"] = time.Now().Add(time.Minute).Unix()
+		token, err := keys.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	do := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/menu", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do(sign(jwt.MapClaims{"role": "content_editor", "perms": []string{"menu:read", "menu:write"}})); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+
+	for name, claims := range map[string]jwt.MapClaims{
+		"missing permission": {"role": "courier", "perms": []string{"orders:read"}},
+		"user token":         {},
+	} {
+		rec := do(sign(claims))
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("%s: expected 403, got %d", name, rec.Code)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body["code"] != "permission_denied" {
+			t.Fatalf("%s: unexpected body %s", name, rec.Body.String())
+		}
+	}
+
+	rec := do(sign(jwt.MapClaims{"role": "owner", "perms": []string{"menu:write"}, "pwd_change": true}))
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "password_change_required") {
+		t.Fatalf("expected password change block, got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(sign(jwt.MapClaims{"role": "owner", "perms": []string{"menu:write"}, "totp_enroll": true}))
+	if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "totp_enrollment_required") {
+		t.Fatalf("expected 2FA enrollment block, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+type denylist map[string]bool
+
+func (d denylist) IsRevoked(_ context.Context, jti string) (bool, error) {
+	return d[jti], nil
+}
+
+func TestJWTAuthRejectsRevokedTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys := testKeyring(t)
+	router := gin.New()
+	router.GET("/profile", JWTAuth(keys, denylist{"revoked": true}), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for jti, want := range map[string]int{"live": http.StatusNoContent, "revoked": http.StatusUnauthorized} {
+		token, err := keys.Sign(jwt.MapClaims{"sub": 1, "jti": jti, "exp": time.Now().Add(time.Minute).Unix()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/profile", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Fatalf("%s: expected %d, got %d", jti, want, rec.Code)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -24,8 +25,18 @@ func Chain(mws ...gin.HandlerFunc) gin.HandlerFunc {
 
 const userIDContextKey = "user_id"
 
-// JWTAuth returns Gin middleware verifying Authorization Bearer tokens.
-func JWTAuth(secret string) gin.HandlerFunc {
+// TokenDenylist reports access tokens revoked before they expire (logout, blocked account).
+type TokenDenylist interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// TokenVerifier checks signature, expiry, issuer and audience of a JWT and returns its claims.
+type TokenVerifier interface {
+	Parse(token string) (jwt.MapClaims, error)
+}
+
+// JWTAuth returns Gin middleware verifying Authorization Bearer tokens; denylist is optional.
+func JWTAuth(verifier TokenVerifier, denylist TokenDenylist) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := extractBearer(c.GetHeader("Authorization"))
 		if tokenString == "" {
@@ -33,28 +44,21 @@ func JWTAuth(secret string) gin.HandlerFunc {
 			return
 		}
 
-		parsed, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.New("unexpected signing method")
-			}
-			return []byte(secret), nil
-		})
-		if err != nil || !parsed.Valid {
+		claims, err := verifier.Parse(tokenString)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
 
-		claims, ok := parsed.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			return
-		}
-
 		userID, err := extractUserID(claims)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token subject"})
 			return
 		}
+		if tokenRevoked(c, denylist, claims) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			return
+		}
 
 		c.Set(userIDContextKey, userID)
 		c.Next()
@@ -71,6 +75,17 @@ func UserIDFromContext(c *gin.Context) (int64, bool) {
 	return id, ok
 }
 
+// tokenRevoked checks the jti claim against denylist; lookup errors let the token through
+// because the denylist already falls back to process memory.
+func tokenRevoked(c *gin.Context, denylist TokenDenylist, claims jwt.MapClaims) bool {
+	if denylist == nil {
+		return false
+	}
+	jti, _ := claims["jti"].(string)
+	revoked, err := denylist.IsRevoked(c.Request.Context(), jti)
+	return err == nil && revoked
+}
+
 func extractBearer(header string) string {
 	if header == "" {
 		return ""
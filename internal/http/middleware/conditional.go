@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// Bodies smaller than this are sent uncompressed.
+const minCompressSize = 1024
+
+// maxAgeContextKey holds a per-response cap set by CapMaxAge.
+const maxAgeContextKey = "conditional_max_age"
+
+// encodedCacheSize bounds the compressed bodies kept between requests.
+const encodedCacheSize = 64
+
+// Conditional buffers successful responses to tag them with a weak ETag of
+// the body and Cache-Control, answers a matching If-None-Match with 304 and
+// compresses the body with brotli or gzip when the client accepts it. The
+// ETag stays the same for every encoding. A zero maxAge makes clients
+// revalidate on every request; handlers lower it per response with CapMaxAge.
+func Conditional(maxAge time.Duration) gin.HandlerFunc {
+	encoded := &encodedCache{entries: make(map[string][]byte)}
+
+	return func(c *gin.Context) {
+		w := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		body := w.body.Bytes()
+		header := c.Writer.Header()
+		if w.Status() != http.StatusOK {
+			_, _ = c.Writer.Write(body)
+			return
+		}
+
+		sum := sha256.Sum256(body)
+		etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+		header.Set("ETag", etag)
+		age := maxAge
+		if capped, ok := c.Get(maxAgeContextKey); ok {
+			age = min(age, capped.(time.Duration))
+		}
+		header.Set("Cache-Control", cacheControl(age))
+		header.Add("Vary", "Accept-Encoding")
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			header.Del("Content-Length")
+			c.Writer.WriteHeader(http.StatusNotModified)
+			c.Writer.WriteHeaderNow()
+			return
+		}
+
+		if encoding := negotiateEncoding(c.GetHeader("Accept-Encoding")); encoding != "" && len(body) >= minCompressSize {
+			compressed, err := encoded.get(etag, encoding, body)
+			if err == nil {
+				body = compressed
+				header.Set("Content-Encoding", encoding)
+			}
+		}
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+		_, _ = c.Writer.Write(body)
+	}
+}
+
+// CapMaxAge lowers the max-age Conditional sends for the current response,
+// e.g. to the moment its content is known to change.
+func CapMaxAge(c *gin.Context, maxAge time.Duration) {
+	if capped, ok := c.Get(maxAgeContextKey); ok && capped.(time.Duration) <= maxAge {
+		return
+	}
+	c.Set(maxAgeContextKey, maxAge)
+}
+
+func cacheControl(maxAge time.Duration) string {
+	if seconds := int(maxAge.Seconds()); seconds > 0 {
+		return fmt.Sprintf("public, max-age=%d", seconds)
+	}
+	return "no-cache"
+}
+
+// bufferedWriter keeps the body until the handler chain returns. The status
+// is recorded by the wrapped writer, which writes nothing until its Write.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// etagMatches applies the weak comparison of If-None-Match.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding picks br or gzip from Accept-Encoding, preferring the
+// higher q-value and brotli on ties. It returns "" for identity.
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "br" && name != "gzip" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && name == "br") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// encodedCache keeps compressed bodies by ETag, so a menu is compressed once
+// per change rather than once per request.
+type encodedCache struct {
+	mu      sync.Mutex
+	entries map[string][]byte
+}
+
+func (e *encodedCache) get(etag, encoding string, body []byte) ([]byte, error) {
+	key := encoding + " " + etag
+	e.mu.Lock()
+	cached, ok := e.entries[key]
+	e.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	compressed, err := compress(encoding, body)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	if len(e.entries) >= encodedCacheSize {
+		clear(e.entries)
+	}
+	e.entries[key] = compressed
+	e.mu.Unlock()
+	return compressed, nil
+}
+
+func compress(encoding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	if encoding == "br" {
+		w = brotli.NewWriterLevel(&buf, brotli.DefaultCompression)
+	} else {
+		w = gzip.NewWriter(&buf)
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+func TestConditional(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	payload := strings.Repeat("шашлык ", 500)
+	router := gin.New()
+	router.GET("/menu", Conditional(30*time.Second), func(c *gin.Context) { c.String(http.StatusOK, payload) })
+	router.GET("/fail", Conditional(0), func(c *gin.Context) { c.String(http.StatusInternalServerError, "boom") })
+	router.GET("/capped", Conditional(30*time.Second), func(c *gin.Context) {
+		CapMaxAge(c, 10*time.Second)
+		CapMaxAge(c, 20*time.Second)
+		c.String(http.StatusOK, payload)
+	})
+	router.GET("/expired", Conditional(30*time.Second), func(c *gin.Context) {
+		CapMaxAge(c, 0)
+		c.String(http.StatusOK, payload)
+	})
+
+	do := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("/menu", nil)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || rec.Body.String() != payload || !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("unexpected response %d %q", rec.Code, etag)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=30" {
+		t.Fatalf("unexpected Cache-Control %q", cc)
+	}
+
+	if cc := do("/capped", nil).Header().Get("Cache-Control"); cc != "public, max-age=10" {
+		t.Fatalf("expected the lowest cap, got %q", cc)
+	}
+	if cc := do("/expired", nil).Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Fatalf("expected revalidation, got %q", cc)
+	}
+
+	rec = do("/menu", map[string]string{"If-None-Match": `"other", ` + strings.TrimPrefix(etag, "W/")})
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("expected 304 without body, got %d %d bytes", rec.Code, rec.Body.Len())
+	}
+
+	rec = do("/menu", map[string]string{"Accept-Encoding": "gzip, br"})
+	if rec.Header().Get("Content-Encoding") != "br" || rec.Header().Get("ETag") != etag {
+		t.Fatalf("expected brotli with the same ETag, got %v", rec.Header())
+	}
+	if body, _ := io.ReadAll(brotli.NewReader(rec.Body)); string(body) != payload {
+		t.Fatal("brotli body does not match")
+	}
+
+	rec = do("/menu", map[string]string{"Accept-Encoding": "gzip;q=1, br;q=0.5"})
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip, got %v %v", rec.Header(), err)
+	}
+	if body, _ := io.ReadAll(zr); string(body) != payload {
+		t.Fatal("gzip body does not match")
+	}
+
+	rec = do("/fail", map[string]string{"Accept-Encoding": "gzip"})
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() != "boom" || rec.Header().Get("ETag") != "" {
+		t.Fatalf("errors must pass through, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                     "",
+		"identity":             "",
+		"gzip, deflate":        "gzip",
+		"gzip, deflate, br":    "br",
+		"br;q=0, gzip":         "gzip",
+		"BR;q=0.8, gzip;q=0.9": "gzip",
+	}
+	for header, want := range tests {
+		if got := negotiateEncoding(header); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
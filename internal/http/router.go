@@ -29,6 +29,8 @@ type RouterParams struct {
 	BotHandler        *handlers.BotHandler
 	MenuHandler       *handlers.MenuHandler
 	AdminAuthHandler  *handlers.AdminAuthHandler
+	LoginMiddleware   gin.HandlerFunc
+	PublicHandlers    []RouteRegister
 	AuthMiddleware    gin.HandlerFunc
 	ProtectedHandlers []RouteRegister
 	AdminMiddleware   gin.HandlerFunc
@@ -56,18 +58,29 @@ func NewRouter(params RouterParams) *gin.Engine {
 
 	root := router.Group("")
 	params.HealthHandler.Register(root)
+
+	// Login and registration routes get their own per-IP limit.
+	login := root.Group("")
+	if params.LoginMiddleware != nil {
+		login.Use(params.LoginMiddleware)
+	}
 	if params.AuthHandler != nil {
-		params.AuthHandler.Register(root)
+		params.AuthHandler.Register(login)
 	}
 	if params.BotHandler != nil {
-		params.BotHandler.Register(root)
+		params.BotHandler.Register(login)
 	}
 	if params.AdminAuthHandler != nil {
-		params.AdminAuthHandler.Register(root)
+		params.AdminAuthHandler.Register(login)
 	}
 	if params.MenuHandler != nil {
 		params.MenuHandler.Register(root)
 	}
+	for _, h := range params.PublicHandlers {
+		if h != nil {
+			h.Register(root)
+		}
+	}
 
 	if params.AuthMiddleware != nil && len(params.ProtectedHandlers) > 0 {
 		authGroup := root.Group("")
@@ -95,9 +108,10 @@ func NewRouter(params RouterParams) *gin.Engine {
 func buildCORSConfig(origins []string) cors.Config {
 	cleanOrigins := normalizeOrigins(origins)
 	cfg := cors.Config{
-		AllowMethods: []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
-		AllowHeaders: []string{"Origin", "Content-Type", "Authorization"},
-		MaxAge:       12 * time.Hour,
+		AllowMethods:  []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Authorization", "If-None-Match"},
+		ExposeHeaders: []string{"ETag"},
+		MaxAge:        12 * time.Hour,
 	}
 	if len(cleanOrigins) == 0 {
 		cfg.AllowAllOrigins = true
@@ -0,0 +1,101 @@
+// Package imaging renders resized copies of uploaded images in pure Go.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	// Register decoders for every upload type the admin API accepts.
+	_ "image/gif"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels caps the decoded size to keep crafted files from exhausting memory.
+const MaxPixels = 40_000_000
+
+const jpegQuality = 82
+
+var (
+	// ErrDecode is returned when the bytes are not a decodable image.
+	ErrDecode = errors.New("image cannot be decoded")
+	// ErrTooLarge is returned for images above MaxPixels.
+	ErrTooLarge = errors.New("image dimensions are too large")
+)
+
+// Variant is a named bounding box; the longer side of the copy is at most MaxSize.
+type Variant struct {
+	Name    string
+	MaxSize int
+}
+
+// Variant names used for product images.
+const (
+	Thumbnail = "thumbnail"
+	Medium    = "medium"
+	Large     = "large"
+)
+
+// DefaultVariants are the product image sizes served to the mini-app.
+var DefaultVariants = []Variant{
+	{Name: Thumbnail, MaxSize: 160},
+	{Name: Medium, MaxSize: 480},
+	{Name: Large, MaxSize: 1080},
+}
+
+// Rendered is an encoded variant.
+type Rendered struct {
+	Name        string
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// Render decodes data once and encodes a JPEG per variant. Images are never
+// upscaled, and transparency is flattened onto white.
+func Render(data []byte, variants []Variant) ([]Rendered, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecode, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecode, err)
+	}
+
+	out := make([]Rendered, 0, len(variants))
+	for _, v := range variants {
+		width, height := fit(src.Bounds().Dx(), src.Bounds().Dy(), v.MaxSize)
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, err
+		}
+		out = append(out, Rendered{Name: v.Name, Width: width, Height: height, ContentType: "image/jpeg", Data: buf.Bytes()})
+	}
+	return out, nil
+}
+
+// fit scales width x height down so the longer side is at most maxSize.
+func fit(width, height, maxSize int) (int, int) {
+	if maxSize <= 0 || (width <= maxSize && height <= maxSize) {
+		return width, height
+	}
+	if width >= height {
+		return maxSize, max(1, height*maxSize/width)
+	}
+	return max(1, width*maxSize/height), maxSize
+}
@@ -0,0 +1,65 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestRenderVariants(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2000, 1000))
+	for x := 0; x < 2000; x++ {
+		src.Set(x, 500, color.NRGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := Render(buf.Bytes(), DefaultVariants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]int{Thumbnail: {160, 80}, Medium: {480, 240}, Large: {1080, 540}}
+	for _, r := range out {
+		size := want[r.Name]
+		if r.Width != size[0] || r.Height != size[1] || r.ContentType != "image/jpeg" {
+			t.Fatalf("unexpected variant %s %dx%d %s", r.Name, r.Width, r.Height, r.ContentType)
+		}
+		decoded, err := jpeg.Decode(bytes.NewReader(r.Data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := decoded.Bounds(); b.Dx() != size[0] || b.Dy() != size[1] {
+			t.Fatalf("encoded %s is %v", r.Name, b)
+		}
+		// Transparent pixels are flattened onto white.
+		if c := color.GrayModel.Convert(decoded.At(0, 0)).(color.Gray); c.Y < 240 {
+			t.Fatalf("expected white background in %s, got %v", r.Name, c)
+		}
+	}
+}
+
+func TestRenderDoesNotUpscale(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 100, 300))); err != nil {
+		t.Fatal(err)
+	}
+	out, err := Render(buf.Bytes(), []Variant{{Name: Large, MaxSize: 1080}, {Name: Thumbnail, MaxSize: 150}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out[0].Width != 100 || out[0].Height != 300 || out[1].Width != 50 || out[1].Height != 150 {
+		t.Fatalf("unexpected sizes %dx%d %dx%d", out[0].Width, out[0].Height, out[1].Width, out[1].Height)
+	}
+}
+
+func TestRenderRejectsGarbage(t *testing.T) {
+	if _, err := Render([]byte("\x89PNG\r\n\x1a\nnot really"), DefaultVariants); !errors.Is(err, ErrDecode) {
+		t.Fatalf("expected ErrDecode, got %v", err)
+	}
+}
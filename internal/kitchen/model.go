@@ -0,0 +1,51 @@
+package kitchen
+
+import "time"
+
+// Ticket is an order as seen by cooks: only what to cook and how long it waits.
+type Ticket struct {
+	OrderID        int64        `json:"order_id"`
+	Status         string       `json:"status"`
+	Type           string       `json:"type"`
+	Comment        string       `json:"comment"`
+	CreatedAt      time.Time    `json:"created_at"`
+	ElapsedSeconds int64        `json:"elapsed_seconds"`
+	Done           bool         `json:"done"`
+	Items          []TicketItem `json:"items"`
+}
+
+// TicketItem is a single line on a ticket with its done marker. Bundles list
+// what goes into each of them in Components.
+type TicketItem struct {
+	ID          int64             `json:"id"`
+	ProductID   int64             `json:"product_id"`
+	ProductName string            `json:"product_name"`
+	Qty         int32             `json:"qty"`
+	Components  []TicketComponent `json:"components,omitempty"`
+	Done        bool              `json:"done"`
+	DoneAt      *time.Time        `json:"done_at,omitempty"`
+}
+
+// TicketComponent is a product inside one bundle of a ticket item.
+type TicketComponent struct {
+	ProductID   int64  `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Qty         int32  `json:"qty"`
+}
+
+// SummaryLine aggregates outstanding quantity of a product across all tickets.
+// Bundles are counted by their components.
+type SummaryLine struct {
+	ProductID   int64  `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Qty         int32  `json:"qty"`
+	Tickets     int    `json:"tickets"`
+}
+
+// Board is the payload of the kitchen display.
+type Board struct {
+	Version     string        `json:"version"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	Tickets     []Ticket      `json:"tickets"`
+	Summary     []SummaryLine `json:"summary"`
+}
@@ -0,0 +1,150 @@
+package kitchen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/rashidmailru/kabobfood/internal/orders"
+)
+
+// maxTickets caps how many open orders the display loads at once; the oldest,
+// most urgent ones are loaded first.
+const maxTickets = 200
+
+// activeStatuses lists order statuses that belong on the kitchen display.
+var activeStatuses = []string{orders.StatusAccepted, orders.StatusCooking}
+
+// ErrItemNotFound is returned when the item is missing or its order left the kitchen.
+var ErrItemNotFound = errors.New("ticket item not found")
+
+// OrdersRepository defines order storage access used by the kitchen.
+type OrdersRepository interface {
+	ListAdmin(ctx context.Context, params orders.AdminListParams) ([]orders.Order, error)
+	MarkItemDone(ctx context.Context, orderID, itemID int64, done bool, statuses []string) (*orders.OrderItem, error)
+}
+
+// Service builds kitchen tickets from open orders.
+type Service struct {
+	repo OrdersRepository
+	now  func() time.Time
+}
+
+// NewService constructs kitchen service.
+func NewService(repo OrdersRepository) *Service {
+	if repo == nil {
+		panic("kitchen service: orders repository is required")
+	}
+	return &Service{repo: repo, now: time.Now}
+}
+
+// Board returns accepted and cooking orders as tickets, oldest first.
+func (s *Service) Board(ctx context.Context) (*Board, error) {
+	list, err := s.repo.ListAdmin(ctx, orders.AdminListParams{Statuses: activeStatuses, Limit: maxTickets, OldestFirst: true})
+	if err != nil {
+		return nil, err
+	}
+	return buildBoard(list, s.now()), nil
+}
+
+// MarkItemDone sets or clears the done marker of a ticket item.
+func (s *Service) MarkItemDone(ctx context.Context, orderID, itemID int64, done bool) (*orders.OrderItem, error) {
+	item, err := s.repo.MarkItemDone(ctx, orderID, itemID, done, activeStatuses)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrItemNotFound
+		}
+		return nil, err
+	}
+	return item, nil
+}
+
+func buildBoard(list []orders.Order, now time.Time) *Board {
+	tickets := make([]Ticket, 0, len(list))
+	summaryIdx := make(map[int64]int)
+	summary := []SummaryLine{}
+	hasher := fnv.New64a()
+
+	for _, order := range list {
+		ticket := Ticket{
+			OrderID:        order.ID,
+			Status:         order.Status,
+			Type:           order.Type,
+			Comment:        order.Comment,
+			CreatedAt:      order.CreatedAt,
+			ElapsedSeconds: int64(now.Sub(order.CreatedAt) / time.Second),
+			Done:           len(order.Items) > 0,
+			Items:          make([]TicketItem, 0, len(order.Items)),
+		}
+		if ticket.ElapsedSeconds < 0 {
+			ticket.ElapsedSeconds = 0
+		}
+		fmt.Fprintf(hasher, "%d:%s;", order.ID, order.Status)
+
+		seen := make(map[int64]struct{}, len(order.Items))
+		for _, item := range order.Items {
+			done := item.DoneAt != nil
+			ticketItem := TicketItem{
+				ID:          item.ID,
+				ProductID:   item.ProductID,
+				ProductName: item.ProductName,
+				Qty:         item.Qty,
+				Done:        done,
+				DoneAt:      item.DoneAt,
+			}
+			// What to cook: the item itself, or the components of a bundle.
+			cook := []TicketComponent{{ProductID: item.ProductID, ProductName: item.ProductName, Qty: item.Qty}}
+			if len(item.Components) > 0 {
+				cook = cook[:0]
+				for _, c := range item.Components {
+					ticketItem.Components = append(ticketItem.Components, TicketComponent{ProductID: c.ProductID, ProductName: c.ProductName, Qty: c.Qty})
+					cook = append(cook, TicketComponent{ProductID: c.ProductID, ProductName: c.ProductName, Qty: c.Qty * item.Qty})
+				}
+			}
+			ticket.Items = append(ticket.Items, ticketItem)
+			hasher.Write([]byte(strconv.FormatInt(item.ID, 10) + ":" + strconv.FormatBool(done) + ";"))
+			if done {
+				continue
+			}
+			ticket.Done = false
+
+			for _, line := range cook {
+				idx, ok := summaryIdx[line.ProductID]
+				if !ok {
+					idx = len(summary)
+					summaryIdx[line.ProductID] = idx
+					summary = append(summary, SummaryLine{ProductID: line.ProductID, ProductName: line.ProductName})
+				}
+				summary[idx].Qty += line.Qty
+				if _, counted := seen[line.ProductID]; !counted {
+					seen[line.ProductID] = struct{}{}
+					summary[idx].Tickets++
+				}
+			}
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	sort.SliceStable(tickets, func(i, j int) bool {
+		return tickets[i].CreatedAt.Before(tickets[j].CreatedAt)
+	})
+	sort.SliceStable(summary, func(i, j int) bool {
+		if summary[i].Qty != summary[j].Qty {
+			return summary[i].Qty > summary[j].Qty
+		}
+		return summary[i].ProductName < summary[j].ProductName
+	})
+
+	return &Board{
+		Version:     strconv.FormatUint(hasher.Sum64(), 16),
+		GeneratedAt: now,
+		Tickets:     tickets,
+		Summary:     summary,
+	}
+}
@@ -0,0 +1,105 @@
+package kitchen
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rashidmailru/kabobfood/internal/orders"
+)
+
+func TestBuildBoard(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	doneAt := now.Add(-time.Minute)
+	list := []orders.Order{
+		{
+			ID:        2,
+			Status:    "cooking",
+			CreatedAt: now.Add(-2 * time.Minute),
+			Items: []orders.OrderItem{
+				{ID: 21, ProductID: 1, ProductName: "Shashlik", Qty: 2},
+				{ID: 22, ProductID: 2, ProductName: "Fries", Qty: 1, DoneAt: &doneAt},
+			},
+		},
+		{
+			ID:        1,
+			Status:    "accepted",
+			CreatedAt: now.Add(-10 * time.Minute),
+			Items: []orders.OrderItem{
+				{ID: 11, ProductID: 1, ProductName: "Shashlik", Qty: 3},
+			},
+		},
+	}
+
+	board := buildBoard(list, now)
+	if len(board.Tickets) != 2 || board.Tickets[0].OrderID != 1 {
+		t.Fatalf("expected oldest ticket first, got %+v", board.Tickets)
+	}
+	if board.Tickets[0].ElapsedSeconds != 600 {
+		t.Fatalf("unexpected elapsed seconds: %d", board.Tickets[0].ElapsedSeconds)
+	}
+	if !board.Tickets[1].Items[1].Done || board.Tickets[1].Done {
+		t.Fatalf("unexpected done flags: %+v", board.Tickets[1])
+	}
+	if len(board.Summary) != 1 || board.Summary[0].Qty != 5 || board.Summary[0].Tickets != 2 {
+		t.Fatalf("unexpected summary: %+v", board.Summary)
+	}
+
+	list[0].Items[0].DoneAt = &doneAt
+	if next := buildBoard(list, now); next.Version == board.Version {
+		t.Fatal("expected version to change after marking item done")
+	}
+}
+
+func TestBuildBoardCountsBundleComponents(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	list := []orders.Order{{
+		ID:        3,
+		Status:    "accepted",
+		CreatedAt: now,
+		Items: []orders.OrderItem{
+			{ID: 31, ProductID: 9, ProductName: "Combo", Qty: 2, Components: []orders.OrderComponent{
+				{ProductID: 1, ProductName: "Shashlik", Qty: 1},
+				{ProductID: 2, ProductName: "Fries", Qty: 1},
+			}},
+			{ID: 32, ProductID: 1, ProductName: "Shashlik", Qty: 1},
+		},
+	}}
+
+	board := buildBoard(list, now)
+	if len(board.Tickets[0].Items[0].Components) != 2 {
+		t.Fatalf("expected bundle components on the ticket, got %+v", board.Tickets[0].Items[0])
+	}
+	if len(board.Summary) != 2 || board.Summary[0].ProductName != "Shashlik" || board.Summary[0].Qty != 3 || board.Summary[0].Tickets != 1 || board.Summary[1].Qty != 2 {
+		t.Fatalf("unexpected summary: %+v", board.Summary)
+	}
+}
+
+type paramsRecorder struct {
+	params orders.AdminListParams
+}
+
+func (r *paramsRecorder) ListAdmin(_ context.Context, params orders.AdminListParams) ([]orders.Order, error) {
+	r.params = params
+	return nil, nil
+}
+
+func (r *paramsRecorder) MarkItemDone(context.Context, int64, int64, bool, []string) (*orders.OrderItem, error) {
+	return nil, nil
+}
+
+func TestBoardLoadsOldestOrdersFirst(t *testing.T) {
+	t.Parallel()
+
+	repo := &paramsRecorder{}
+	if _, err := NewService(repo).Board(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !repo.params.OldestFirst || repo.params.Limit != maxTickets {
+		t.Fatalf("board must load the oldest %d orders, got %+v", maxTickets, repo.params)
+	}
+}
@@ -0,0 +1,256 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/rashidmailru/kabobfood/internal/metrics"
+)
+
+// Key kinds; each has its own lockout threshold.
+const (
+	KindUsername = "username"
+	KindIP       = "ip"
+)
+
+// Key identifies what failed attempts are counted against.
+type Key struct {
+	Kind  string
+	Value string
+}
+
+// Username builds a case-insensitive username key.
+func Username(name string) Key {
+	return Key{Kind: KindUsername, Value: strings.ToLower(strings.TrimSpace(name))}
+}
+
+// IP builds a client address key.
+func IP(addr string) Key {
+	return Key{Kind: KindIP, Value: addr}
+}
+
+// Event describes a lockout sent to the admin chat.
+type Event struct {
+	Scope string
+	Key   Key
+	Until time.Time
+}
+
+// Alerter delivers lockout events to staff.
+type Alerter interface {
+	NotifyAdmin(ctx context.Context, message string)
+}
+
+// Config tunes delays and lockouts.
+type Config struct {
+	// FreeAttempts failures are allowed without delay.
+	FreeAttempts int
+	// BaseDelay doubles with every further failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// UsernameThreshold and IPThreshold failures within Window lock the key for Duration.
+	UsernameThreshold int
+	IPThreshold       int
+	Window            time.Duration
+	Duration          time.Duration
+}
+
+// Guard counts failed attempts per key and tells callers how long to wait.
+type Guard struct {
+	scope    string
+	cfg      Config
+	store    Store
+	fallback *MemoryStore
+	alerter  Alerter
+	metrics  *metrics.Metrics
+	log      *zap.Logger
+	now      func() time.Time
+}
+
+// Options holds optional Guard dependencies.
+type Options struct {
+	// Store is normally a RedisStore; nil uses the in-memory store only.
+	Store   Store
+	Alerter Alerter
+	Metrics *metrics.Metrics
+	Logger  *zap.Logger
+	Now     func() time.Time
+}
+
+// NewGuard builds a guard for scope (e.g. "admin_login"); zero config values get defaults.
+func NewGuard(scope string, cfg Config, opts Options) *Guard {
+	if cfg.FreeAttempts <= 0 {
+		cfg.FreeAttempts = 3
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = time.Second
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = 30 * time.Second
+	}
+	if cfg.UsernameThreshold <= 0 {
+		cfg.UsernameThreshold = 10
+	}
+	if cfg.IPThreshold <= 0 {
+		cfg.IPThreshold = 50
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = 15 * time.Minute
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = 15 * time.Minute
+	}
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+	log := opts.Logger
+	if log == nil {
+		log = zap.NewNop()
+	}
+	g := &Guard{
+		scope:    scope,
+		cfg:      cfg,
+		store:    opts.Store,
+		fallback: NewMemoryStore(now),
+		alerter:  opts.Alerter,
+		metrics:  opts.Metrics,
+		log:      log,
+		now:      now,
+	}
+	if g.store == nil {
+		g.store = g.fallback
+	}
+	return g
+}
+
+// Check returns how long the caller must wait before the next attempt; zero means go ahead.
+func (g *Guard) Check(ctx context.Context, keys ...Key) time.Duration {
+	now := g.now()
+	var wait time.Duration
+	for _, key := range keys {
+		if key.Value == "" {
+			continue
+		}
+		st := g.get(ctx, key)
+		if d := st.LockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+		if d := st.LastFailure.Add(g.delay(st.Failures)).Sub(now); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 && g.metrics != nil {
+		g.metrics.AuthBlocked.WithLabelValues(g.scope).Inc()
+	}
+	return wait
+}
+
+// Fail records a failed attempt for every key and locks keys that crossed their threshold.
+func (g *Guard) Fail(ctx context.Context, keys ...Key) {
+	now := g.now()
+	for _, key := range keys {
+		if key.Value == "" {
+			continue
+		}
+		if g.metrics != nil {
+			g.metrics.AuthFailures.WithLabelValues(g.scope, key.Kind).Inc()
+		}
+		st := g.recordFailure(ctx, key, now)
+		if st.Failures < g.threshold(key.Kind) {
+			continue
+		}
+		until := now.Add(g.cfg.Duration)
+		g.lock(ctx, key, until)
+		if g.metrics != nil {
+			g.metrics.AuthLockouts.WithLabelValues(g.scope, key.Kind).Inc()
+		}
+		g.alert(ctx, Event{Scope: g.scope, Key: key, Until: until})
+	}
+}
+
+// Succeed clears counters of keys, typically the username after a successful login.
+func (g *Guard) Succeed(ctx context.Context, keys ...Key) {
+	for _, key := range keys {
+		if key.Value == "" {
+			continue
+		}
+		id := g.storeKey(key)
+		if err := g.store.Reset(ctx, id); err != nil {
+			g.log.Warn("lockout store unavailable", zap.Error(err))
+		}
+		_ = g.fallback.Reset(ctx, id)
+	}
+}
+
+// delay is the wait enforced after failures attempts.
+func (g *Guard) delay(failures int) time.Duration {
+	extra := failures - g.cfg.FreeAttempts
+	if extra <= 0 {
+		return 0
+	}
+	d := g.cfg.BaseDelay
+	for i := 1; i < extra && d < g.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > g.cfg.MaxDelay {
+		d = g.cfg.MaxDelay
+	}
+	return d
+}
+
+func (g *Guard) threshold(kind string) int {
+	if kind == KindIP {
+		return g.cfg.IPThreshold
+	}
+	return g.cfg.UsernameThreshold
+}
+
+func (g *Guard) storeKey(key Key) string {
+	return g.scope + ":" + key.Kind + ":" + key.Value
+}
+
+// The helpers below fall back to the in-memory store when Redis fails, so attempts
+// stay limited per instance during an outage.
+
+func (g *Guard) get(ctx context.Context, key Key) State {
+	id := g.storeKey(key)
+	st, err := g.store.Get(ctx, id)
+	if err != nil {
+		g.log.Warn("lockout store unavailable", zap.Error(err))
+		st, _ = g.fallback.Get(ctx, id)
+	}
+	return st
+}
+
+func (g *Guard) recordFailure(ctx context.Context, key Key, now time.Time) State {
+	id := g.storeKey(key)
+	st, err := g.store.RecordFailure(ctx, id, now, g.cfg.Window)
+	if err != nil {
+		g.log.Warn("lockout store unavailable", zap.Error(err))
+		st, _ = g.fallback.RecordFailure(ctx, id, now, g.cfg.Window)
+	}
+	return st
+}
+
+func (g *Guard) lock(ctx context.Context, key Key, until time.Time) {
+	id := g.storeKey(key)
+	if err := g.store.Lock(ctx, id, until, g.cfg.Duration); err != nil {
+		g.log.Warn("lockout store unavailable", zap.Error(err))
+		_ = g.fallback.Lock(ctx, id, until, g.cfg.Duration)
+	}
+}
+
+func (g *Guard) alert(ctx context.Context, ev Event) {
+	g.log.Warn("login lockout", zap.String("scope", ev.Scope), zap.String("kind", ev.Key.Kind), zap.String("key", ev.Key.Value), zap.Time("until", ev.Until))
+	if g.alerter == nil {
+		return
+	}
+	msg := fmt.Sprintf("Блокировка входа (%s): %s %s заблокирован до %s после серии неудачных попыток",
+		ev.Scope, ev.Key.Kind, ev.Key.Value, ev.Until.Format("15:04:05"))
+	go g.alerter.NotifyAdmin(context.WithoutCancel(ctx), msg)
+}
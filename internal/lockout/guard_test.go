@@ -0,0 +1,109 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time { return c.t }
+
+type alerts struct {
+	mu   sync.Mutex
+	msgs []string
+	done chan struct{}
+}
+
+func (a *alerts) NotifyAdmin(_ context.Context, message string) {
+	a.mu.Lock()
+	a.msgs = append(a.msgs, message)
+	a.mu.Unlock()
+	a.done <- struct{}{}
+}
+
+type brokenStore struct{}
+
+var errDown = errors.New("redis down")
+
+func (brokenStore) Get(context.Context, string) (State, error) { return State{}, errDown }
+func (brokenStore) RecordFailure(context.Context, string, time.Time, time.Duration) (State, error) {
+	return State{}, errDown
+}
+func (brokenStore) Lock(context.Context, string, time.Time, time.Duration) error { return errDown }
+func (brokenStore) Reset(context.Context, string) error                          { return errDown }
+
+func testConfig() Config {
+	return Config{FreeAttempts: 2, BaseDelay: time.Second, MaxDelay: 4 * time.Second, UsernameThreshold: 6, IPThreshold: 100, Window: time.Hour, Duration: 10 * time.Minute}
+}
+
+func TestGuardProgressiveDelayAndLockout(t *testing.T) {
+	ctx := context.Background()
+	clk := &clock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	alerter := &alerts{done: make(chan struct{}, 1)}
+	g := NewGuard("admin_login", testConfig(), Options{Alerter: alerter, Now: clk.now})
+	key := Username(" Admin ")
+
+	wantDelays := []time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second}
+	for i, want := range wantDelays {
+		if wait := g.Check(ctx, key); wait != 0 {
+			t.Fatalf("attempt %d: expected no wait, got %s", i+1, wait)
+		}
+		g.Fail(ctx, key)
+		if got := g.Check(ctx, key); got != want {
+			t.Fatalf("after failure %d: expected wait %s, got %s", i+1, want, got)
+		}
+		clk.t = clk.t.Add(want)
+	}
+
+	g.Fail(ctx, key)
+	<-alerter.done
+	if wait := g.Check(ctx, Username("admin")); wait != 10*time.Minute {
+		t.Fatalf("expected lockout for 10m, got %s", wait)
+	}
+	if len(alerter.msgs) != 1 {
+		t.Fatalf("expected one alert, got %d", len(alerter.msgs))
+	}
+
+	clk.t = clk.t.Add(10 * time.Minute)
+	if wait := g.Check(ctx, key); wait != 0 {
+		t.Fatalf("expected lockout to expire, got %s", wait)
+	}
+}
+
+func TestGuardSucceedResetsAndKeysAreIndependent(t *testing.T) {
+	ctx := context.Background()
+	clk := &clock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	g := NewGuard("admin_login", testConfig(), Options{Now: clk.now})
+	user, ip := Username("chef"), IP("10.0.0.1")
+
+	for i := 0; i < 3; i++ {
+		g.Fail(ctx, user, ip)
+	}
+	if wait := g.Check(ctx, Username("other")); wait != 0 {
+		t.Fatalf("unrelated user must not wait, got %s", wait)
+	}
+	g.Succeed(ctx, user)
+	if wait := g.Check(ctx, user); wait != 0 {
+		t.Fatalf("expected reset after success, got %s", wait)
+	}
+	if wait := g.Check(ctx, ip); wait == 0 {
+		t.Fatal("expected IP counter to survive a successful login")
+	}
+}
+
+func TestGuardFallsBackToMemoryWhenStoreFails(t *testing.T) {
+	ctx := context.Background()
+	clk := &clock{t: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	g := NewGuard("bot_register", testConfig(), Options{Store: brokenStore{}, Now: clk.now})
+	key := IP("10.0.0.2")
+	for i := 0; i < 3; i++ {
+		g.Fail(ctx, key)
+	}
+	if wait := g.Check(ctx, key); wait != time.Second {
+		t.Fatalf("expected in-memory delay of 1s, got %s", wait)
+	}
+}
@@ -0,0 +1,186 @@
+package lockout
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// State is the failure history of one key.
+type State struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// Store keeps failure counters; entries expire after the window passed on write.
+type Store interface {
+	Get(ctx context.Context, key string) (State, error)
+	RecordFailure(ctx context.Context, key string, now time.Time, ttl time.Duration) (State, error)
+	Lock(ctx context.Context, key string, until time.Time, ttl time.Duration) error
+	Reset(ctx context.Context, key string) error
+}
+
+// RedisStore shares counters between API instances.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore builds store; keys are namespaced with "lockout:".
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client, prefix: "lockout:"}
+}
+
+// Get returns state for key.
+func (s *RedisStore) Get(ctx context.Context, key string) (State, error) {
+	values, err := s.client.HGetAll(ctx, s.prefix+key).Result()
+	if err != nil {
+		return State{}, err
+	}
+	return parseState(values), nil
+}
+
+// RecordFailure increments the counter and refreshes its expiry.
+func (s *RedisStore) RecordFailure(ctx context.Context, key string, now time.Time, ttl time.Duration) (State, error) {
+	redisKey := s.prefix + key
+	var all *redis.MapStringStringCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, redisKey, "failures", 1)
+		pipe.HSet(ctx, redisKey, "last", now.UnixNano())
+		pipe.Expire(ctx, redisKey, ttl)
+		all = pipe.HGetAll(ctx, redisKey)
+		return nil
+	})
+	if err != nil {
+		return State{}, err
+	}
+	return parseState(all.Val()), nil
+}
+
+// Lock blocks key until the given time and clears its counter.
+func (s *RedisStore) Lock(ctx context.Context, key string, until time.Time, ttl time.Duration) error {
+	redisKey := s.prefix + key
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, redisKey, "failures", 0, "locked", until.UnixNano())
+		pipe.Expire(ctx, redisKey, ttl)
+		return nil
+	})
+	return err
+}
+
+// Reset forgets key.
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key).Err()
+}
+
+func parseState(values map[string]string) State {
+	var st State
+	st.Failures, _ = strconv.Atoi(values["failures"])
+	if last, err := strconv.ParseInt(values["last"], 10, 64); err == nil && last > 0 {
+		st.LastFailure = time.Unix(0, last)
+	}
+	if locked, err := strconv.ParseInt(values["locked"], 10, 64); err == nil && locked > 0 {
+		st.LockedUntil = time.Unix(0, locked)
+	}
+	return st
+}
+
+// memorySweepSize triggers removal of expired entries from MemoryStore.
+const memorySweepSize = 10000
+
+// MemoryStore keeps counters in process; used when Redis is absent or failing.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	state   State
+	expires time.Time
+}
+
+// NewMemoryStore builds store; now defaults to time.Now.
+func NewMemoryStore(now func() time.Time) *MemoryStore {
+	if now == nil {
+		now = time.Now
+	}
+	return &MemoryStore{entries: make(map[string]*memoryEntry), now: now}
+}
+
+// Get returns state for key.
+func (s *MemoryStore) Get(_ context.Context, key string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.entry(key); e != nil {
+		return e.state, nil
+	}
+	return State{}, nil
+}
+
+// RecordFailure increments the counter and refreshes its expiry.
+func (s *MemoryStore) RecordFailure(_ context.Context, key string, now time.Time, ttl time.Duration) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entry(key)
+	if e == nil {
+		s.sweep()
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+	e.state.Failures++
+	e.state.LastFailure = now
+	e.expires = now.Add(ttl)
+	return e.state, nil
+}
+
+// Lock blocks key until the given time and clears its counter.
+func (s *MemoryStore) Lock(_ context.Context, key string, until time.Time, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.entry(key)
+	if e == nil {
+		e = &memoryEntry{}
+		s.entries[key] = e
+	}
+	e.state.Failures = 0
+	e.state.LockedUntil = until
+	e.expires = s.now().Add(ttl)
+	return nil
+}
+
+// Reset forgets key.
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryStore) entry(key string) *memoryEntry {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !s.now().Before(e.expires) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e
+}
+
+func (s *MemoryStore) sweep() {
+	if len(s.entries) < memorySweepSize {
+		return
+	}
+	now := s.now()
+	for key, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Product tags shown as badges in the menu.
+const (
+	TagSpicy      = "spicy"
+	TagVegetarian = "vegetarian"
+	TagHalal      = "halal"
+	TagNew        = "new"
+	TagHit        = "hit"
+)
+
+// Tags lists the tags admins can put on a product.
+var Tags = []string{TagSpicy, TagVegetarian, TagHalal, TagNew, TagHit}
+
+// ErrInvalidAttributes is returned for unknown tags, malformed allergens or
+// negative nutrition values.
+var ErrInvalidAttributes = errors.New("invalid product attributes")
+
+const maxAllergenLen = 32
+
+// NormalizeAttributes lowercases and deduplicates tags and allergens in place
+// and validates them together with kcal and weight.
+func NormalizeAttributes(p *Product) error {
+	p.Tags = normalizeList(p.Tags)
+	for _, tag := range p.Tags {
+		if !slices.Contains(Tags, tag) {
+			return fmt.Errorf("%w: unknown tag %q, expected one of %s", ErrInvalidAttributes, tag, strings.Join(Tags, ", "))
+		}
+	}
+	p.Allergens = normalizeList(p.Allergens)
+	for _, allergen := range p.Allergens {
+		if len(allergen) > maxAllergenLen || strings.Contains(allergen, ",") {
+			return fmt.Errorf("%w: allergen %q must be at most %d characters without commas", ErrInvalidAttributes, allergen, maxAllergenLen)
+		}
+	}
+	if p.Kcal != nil && *p.Kcal < 0 {
+		return fmt.Errorf("%w: kcal must be >= 0", ErrInvalidAttributes)
+	}
+	if p.Weight != nil && *p.Weight <= 0 {
+		return fmt.Errorf("%w: weight must be > 0", ErrInvalidAttributes)
+	}
+	return nil
+}
+
+// normalizeList trims and lowercases values, dropping blanks and repeats. It
+// never returns nil so products encode an empty JSON array.
+func normalizeList(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if v != "" && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Empty reports whether the filter matches every product.
+func (f MenuFilter) Empty() bool {
+	return len(f.Tags) == 0 && len(f.ExcludeAllergens) == 0
+}
+
+// Match reports whether p passes the filter.
+func (f MenuFilter) Match(p Product) bool {
+	for _, tag := range f.Tags {
+		if !slices.Contains(p.Tags, tag) {
+			return false
+		}
+	}
+	for _, allergen := range f.ExcludeAllergens {
+		if slices.Contains(p.Allergens, allergen) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns a copy of the menu with only matching products. Categories
+// left without products are dropped.
+func (r *MenuResponse) Filter(f MenuFilter) *MenuResponse {
+	if f.Empty() {
+		return r
+	}
+	f.Tags = normalizeList(f.Tags)
+	f.ExcludeAllergens = normalizeList(f.ExcludeAllergens)
+	out := &MenuResponse{Version: r.Version, PricesChangeAt: r.PricesChangeAt, Categories: []MenuCategory{}}
+	for _, cat := range r.Categories {
+		products := []Product{}
+		for _, p := range cat.Products {
+			if f.Match(p) {
+				products = append(products, p)
+			}
+		}
+		if len(products) == 0 {
+			continue
+		}
+		cat.Products = products
+		out.Categories = append(out.Categories, cat)
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package menu
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeAttributes(t *testing.T) {
+	p := Product{Tags: []string{" Spicy", "halal", "spicy", ""}, Allergens: []string{"Nuts", "nuts ", "Milk"}}
+	if err := NormalizeAttributes(&p); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Tags) != 2 || p.Tags[0] != "spicy" || p.Tags[1] != "halal" {
+		t.Fatalf("unexpected tags %v", p.Tags)
+	}
+	if len(p.Allergens) != 2 || p.Allergens[0] != "nuts" || p.Allergens[1] != "milk" {
+		t.Fatalf("unexpected allergens %v", p.Allergens)
+	}
+
+	empty := Product{}
+	if err := NormalizeAttributes(&empty); err != nil || empty.Tags == nil || empty.Allergens == nil {
+		t.Fatalf("nil lists must become empty, got %v %v (%v)", empty.Tags, empty.Allergens, err)
+	}
+
+	negative := -1
+	for name, bad := range map[string]Product{
+		"unknown tag":    {Tags: []string{"cheap"}},
+		"comma allergen": {Allergens: []string{"nuts,milk"}},
+		"negative kcal":  {Kcal: &negative},
+		"negative mass":  {Weight: &negative},
+	} {
+		if err := NormalizeAttributes(&bad); !errors.Is(err, ErrInvalidAttributes) {
+			t.Errorf("%s: expected ErrInvalidAttributes, got %v", name, err)
+		}
+	}
+}
+
+func TestMenuFilter(t *testing.T) {
+	menu := &MenuResponse{Categories: []MenuCategory{
+		{ID: 1, Name: "Шашлык", Products: []Product{
+			{ID: 10, Name: "Баранина", Tags: []string{"spicy", "halal"}},
+			{ID: 11, Name: "Курица", Tags: []string{"halal"}},
+			{ID: 12, Name: "С орехами", Tags: []string{"spicy"}, Allergens: []string{"nuts"}},
+		}},
+		{ID: 2, Name: "Напитки", Products: []Product{{ID: 20, Name: "Чай"}}},
+	}}
+
+	if got := menu.Filter(MenuFilter{}); got != menu {
+		t.Fatal("empty filter must return the menu as is")
+	}
+
+	got := menu.Filter(MenuFilter{Tags: []string{"Spicy"}, ExcludeAllergens: []string{"nuts"}})
+	if len(got.Categories) != 1 || len(got.Categories[0].Products) != 1 || got.Categories[0].Products[0].ID != 10 {
+		t.Fatalf("unexpected filtered menu %+v", got)
+	}
+	if len(menu.Categories[0].Products) != 3 {
+		t.Fatal("filter must not modify the source menu")
+	}
+
+	got = menu.Filter(MenuFilter{ExcludeAllergens: []string{"nuts"}})
+	if len(got.Categories) != 2 || len(got.Categories[0].Products) != 2 {
+		t.Fatalf("unexpected filtered menu %+v", got)
+	}
+}
@@ -0,0 +1,59 @@
+package menu
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidBundle is returned for malformed bundle slots, options that are
+// bundles themselves and bundles used as options.
+var ErrInvalidBundle = errors.New("invalid bundle")
+
+// NormalizeBundle trims slot names, defaults Qty to 1 and validates slots of
+// bundleID. An empty list turns the bundle back into a regular product.
+func NormalizeBundle(bundleID int64, slots []BundleSlot) error {
+	for i := range slots {
+		slot := &slots[i]
+		slot.Name = strings.TrimSpace(slot.Name)
+		if slot.Name == "" {
+			return fmt.Errorf("%w: slot %d has no name", ErrInvalidBundle, i+1)
+		}
+		if slot.Qty == 0 {
+			slot.Qty = 1
+		}
+		if slot.Qty < 0 {
+			return fmt.Errorf("%w: slot %q qty must be > 0", ErrInvalidBundle, slot.Name)
+		}
+		if len(slot.Options) == 0 {
+			return fmt.Errorf("%w: slot %q has no options", ErrInvalidBundle, slot.Name)
+		}
+		seen := make(map[int64]bool, len(slot.Options))
+		for _, opt := range slot.Options {
+			switch {
+			case opt.ProductID == bundleID:
+				return fmt.Errorf("%w: slot %q contains the bundle itself", ErrInvalidBundle, slot.Name)
+			case seen[opt.ProductID]:
+				return fmt.Errorf("%w: slot %q lists product %d twice", ErrInvalidBundle, slot.Name, opt.ProductID)
+			case opt.ExtraPrice < 0:
+				return fmt.Errorf("%w: slot %q extra_price must be >= 0", ErrInvalidBundle, slot.Name)
+			}
+			seen[opt.ProductID] = true
+		}
+	}
+	return nil
+}
+
+// bundleAvailable reports whether every slot has an option that can be ordered.
+func bundleAvailable(slots []BundleSlot) bool {
+	for _, slot := range slots {
+		ok := false
+		for _, opt := range slot.Options {
+			ok = ok || opt.Available
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,29 @@
+package menu
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeBundle(t *testing.T) {
+	slots := []BundleSlot{{Name: " Напиток ", Options: []BundleOption{{ProductID: 2}, {ProductID: 3, ExtraPrice: 2000}}}}
+	if err := NormalizeBundle(1, slots); err != nil {
+		t.Fatal(err)
+	}
+	if slots[0].Name != "Напиток" || slots[0].Qty != 1 {
+		t.Fatalf("unexpected slot %+v", slots[0])
+	}
+
+	for name, bad := range map[string]BundleSlot{
+		"no name":        {Options: []BundleOption{{ProductID: 2}}},
+		"no options":     {Name: "Напиток"},
+		"self":           {Name: "Напиток", Options: []BundleOption{{ProductID: 1}}},
+		"repeated":       {Name: "Напиток", Options: []BundleOption{{ProductID: 2}, {ProductID: 2}}},
+		"negative price": {Name: "Напиток", Options: []BundleOption{{ProductID: 2, ExtraPrice: -1}}},
+		"negative qty":   {Name: "Напиток", Qty: -1, Options: []BundleOption{{ProductID: 2}}},
+	} {
+		if err := NormalizeBundle(1, []BundleSlot{bad}); !errors.Is(err, ErrInvalidBundle) {
+			t.Errorf("%s: expected ErrInvalidBundle, got %v", name, err)
+		}
+	}
+}
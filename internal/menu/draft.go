@@ -0,0 +1,274 @@
+package menu
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidDraft is returned for drafts with missing names, bad prices or
+	// products of unknown categories.
+	ErrInvalidDraft = errors.New("invalid menu draft")
+	// ErrDraftOutdated is returned when another version was published, or the
+	// live menu was edited directly, after the draft was started.
+	ErrDraftOutdated = errors.New("menu draft is based on an outdated version")
+)
+
+// Draft change actions and entities.
+const (
+	ChangeCreate  = "create"
+	ChangeUpdate  = "update"
+	ChangeArchive = "archive"
+
+	ChangeCategory = "category"
+	ChangeProduct  = "product"
+)
+
+// Snapshot is the published content of the menu: live categories and products
+// with the fields a draft controls. Stock, images, bundles and price rules are
+// not versioned.
+type Snapshot struct {
+	Categories []Category `json:"categories"`
+	Products   []Product  `json:"products"`
+}
+
+// Draft is the staged menu workspace. It lists every category and product as
+// they should be after publishing: new entries carry negative ids, products of
+// a new category reference its negative id, and live entries left out are
+// archived. BaseRevision is the Revision of the live menu the draft was
+// started from. UpdatedAt is nil until the draft is saved.
+type Draft struct {
+	BaseVersion  int64  `json:"base_version"`
+	BaseRevision string `json:"base_revision"`
+	Snapshot
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// MenuVersion describes a publish or a rollback; RestoredFrom is set for rollbacks.
+type MenuVersion struct {
+	Version      int64     `json:"version"`
+	RestoredFrom *int64    `json:"restored_from,omitempty"`
+	Categories   int       `json:"categories"`
+	Products     int       `json:"products"`
+	PublishedAt  time.Time `json:"published_at"`
+}
+
+// DraftChange is one difference between a draft and the live menu.
+type DraftChange struct {
+	Entity string `json:"entity"`
+	Action string `json:"action"`
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+}
+
+// Preview is the menu customers would get after publishing the draft.
+type Preview struct {
+	BaseVersion int64         `json:"base_version"`
+	Menu        *MenuResponse `json:"menu"`
+	Changes     []DraftChange `json:"changes"`
+}
+
+// SnapshotOf keeps the live entries of categories and products, reduced to the
+// fields a draft controls.
+func SnapshotOf(categories []Category, products []Product) Snapshot {
+	s := Snapshot{Categories: []Category{}, Products: []Product{}}
+	for _, c := range categories {
+		if c.ArchivedAt == nil {
+			s.Categories = append(s.Categories, c)
+		}
+	}
+	for _, p := range products {
+		if p.ArchivedAt == nil {
+			s.Products = append(s.Products, draftFields(p))
+		}
+	}
+	return s
+}
+
+// Revision fingerprints the content of s. Any edit of a field a draft controls
+// changes it, whether made through a draft or directly.
+func (s Snapshot) Revision() string {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// draftFields drops the operational fields of a product.
+func draftFields(p Product) Product {
+	return Product{
+		ID:          p.ID,
+		CategoryID:  p.CategoryID,
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		OldPrice:    p.OldPrice,
+		ImageURL:    p.ImageURL,
+		IsActive:    p.IsActive,
+		SortOrder:   p.SortOrder,
+		Tags:        p.Tags,
+		Allergens:   p.Allergens,
+		Kcal:        p.Kcal,
+		Weight:      p.Weight,
+	}
+}
+
+// NormalizeDraft trims names, strips operational fields and validates s.
+func NormalizeDraft(s *Snapshot) error {
+	categories := make(map[int64]bool, len(s.Categories))
+	for i := range s.Categories {
+		c := &s.Categories[i]
+		c.Name = strings.TrimSpace(c.Name)
+		c.ArchivedAt = nil
+		switch {
+		case c.ID == 0:
+			return fmt.Errorf("%w: category %q needs an id, negative for new ones", ErrInvalidDraft, c.Name)
+		case categories[c.ID]:
+			return fmt.Errorf("%w: category %d is listed twice", ErrInvalidDraft, c.ID)
+		case c.Name == "":
+			return fmt.Errorf("%w: category %d has no name", ErrInvalidDraft, c.ID)
+		}
+		categories[c.ID] = true
+	}
+
+	products := make(map[int64]bool, len(s.Products))
+	for i := range s.Products {
+		p := draftFields(s.Products[i])
+		p.Name = strings.TrimSpace(p.Name)
+		switch {
+		case p.ID == 0:
+			return fmt.Errorf("%w: product %q needs an id, negative for new ones", ErrInvalidDraft, p.Name)
+		case products[p.ID]:
+			return fmt.Errorf("%w: product %d is listed twice", ErrInvalidDraft, p.ID)
+		case p.Name == "":
+			return fmt.Errorf("%w: product %d has no name", ErrInvalidDraft, p.ID)
+		case p.Price <= 0:
+			return fmt.Errorf("%w: price of %q must be greater than zero", ErrInvalidDraft, p.Name)
+		case p.OldPrice < 0:
+			return fmt.Errorf("%w: old_price of %q must not be negative", ErrInvalidDraft, p.Name)
+		case !categories[p.CategoryID]:
+			return fmt.Errorf("%w: %q belongs to category %d, which is not in the draft", ErrInvalidDraft, p.Name, p.CategoryID)
+		}
+		if err := NormalizeAttributes(&p); err != nil {
+			return fmt.Errorf("%w: %q: %w", ErrInvalidDraft, p.Name, err)
+		}
+		products[p.ID] = true
+		s.Products[i] = p
+	}
+	return nil
+}
+
+// DiffDraft lists what publishing draft would change in live.
+func DiffDraft(live, draft Snapshot) []DraftChange {
+	changes := []DraftChange{}
+
+	liveCategories := make(map[int64]Category, len(live.Categories))
+	for _, c := range live.Categories {
+		liveCategories[c.ID] = c
+	}
+	kept := make(map[int64]bool, len(draft.Categories))
+	for _, c := range draft.Categories {
+		kept[c.ID] = true
+		before, ok := liveCategories[c.ID]
+		switch {
+		case !ok && c.ID < 0:
+			changes = append(changes, DraftChange{Entity: ChangeCategory, Action: ChangeCreate, ID: c.ID, Name: c.Name})
+		case !ok || before.Name != c.Name || before.Emoji != c.Emoji || before.SortOrder != c.SortOrder || before.IsActive != c.IsActive:
+			changes = append(changes, DraftChange{Entity: ChangeCategory, Action: ChangeUpdate, ID: c.ID, Name: c.Name})
+		}
+	}
+	for _, c := range live.Categories {
+		if !kept[c.ID] {
+			changes = append(changes, DraftChange{Entity: ChangeCategory, Action: ChangeArchive, ID: c.ID, Name: c.Name})
+		}
+	}
+
+	liveProducts := make(map[int64]Product, len(live.Products))
+	for _, p := range live.Products {
+		liveProducts[p.ID] = p
+	}
+	kept = make(map[int64]bool, len(draft.Products))
+	for _, p := range draft.Products {
+		kept[p.ID] = true
+		before, ok := liveProducts[p.ID]
+		switch {
+		case !ok && p.ID < 0:
+			changes = append(changes, DraftChange{Entity: ChangeProduct, Action: ChangeCreate, ID: p.ID, Name: p.Name})
+		case !ok || draftProductChanged(before, p):
+			changes = append(changes, DraftChange{Entity: ChangeProduct, Action: ChangeUpdate, ID: p.ID, Name: p.Name})
+		}
+	}
+	for _, p := range live.Products {
+		if !kept[p.ID] {
+			changes = append(changes, DraftChange{Entity: ChangeProduct, Action: ChangeArchive, ID: p.ID, Name: p.Name})
+		}
+	}
+	return changes
+}
+
+func draftProductChanged(a, b Product) bool {
+	return productChanged(a, b) ||
+		!slices.Equal(a.Tags, b.Tags) ||
+		!slices.Equal(a.Allergens, b.Allergens) ||
+		!equalInt(a.Kcal, b.Kcal) ||
+		!equalInt(a.Weight, b.Weight)
+}
+
+func equalInt(a, b *int) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+// PreviewMenu builds the customer menu of a draft. Existing products keep their
+// live images, stock and stop-list at now; prices are shown before price rules.
+func PreviewMenu(draft Snapshot, live []Product, now time.Time) *MenuResponse {
+	liveByID := make(map[int64]Product, len(live))
+	for _, p := range live {
+		liveByID[p.ID] = p
+	}
+
+	categories := make([]Category, 0, len(draft.Categories))
+	for _, c := range draft.Categories {
+		if c.IsActive {
+			categories = append(categories, c)
+		}
+	}
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].SortOrder != categories[j].SortOrder {
+			return categories[i].SortOrder < categories[j].SortOrder
+		}
+		return categories[i].ID < categories[j].ID
+	})
+
+	products := make([]Product, 0, len(draft.Products))
+	for _, d := range draft.Products {
+		if !d.IsActive {
+			continue
+		}
+		p, ok := liveByID[d.ID]
+		if ok && p.ImageURL != d.ImageURL {
+			p.Images = nil
+		}
+		p.ID, p.CategoryID, p.Name, p.Description = d.ID, d.CategoryID, d.Name, d.Description
+		p.Price, p.OldPrice, p.ImageURL, p.SortOrder = d.Price, d.OldPrice, d.ImageURL, d.SortOrder
+		p.Tags, p.Allergens, p.Kcal, p.Weight = d.Tags, d.Allergens, d.Kcal, d.Weight
+		p.IsActive, p.ArchivedAt = true, nil
+		p.Available = (p.Stock == nil || *p.Stock > 0) && (p.UnavailableUntil == nil || !p.UnavailableUntil.After(now))
+		products = append(products, p)
+	}
+	sort.SliceStable(products, func(i, j int) bool {
+		if products[i].SortOrder != products[j].SortOrder {
+			return products[i].SortOrder < products[j].SortOrder
+		}
+		return products[i].ID < products[j].ID
+	})
+	return buildMenuResponse(categories, products)
+}
@@ -0,0 +1,104 @@
+package menu
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNormalizeDraft(t *testing.T) {
+	categories, products := transferFixture()
+	s := SnapshotOf(categories, products)
+	s.Products = append(s.Products, Product{ID: -1, CategoryID: -1, Name: " Люля ", Price: 25000, IsActive: true})
+	s.Categories = append(s.Categories, Category{ID: -1, Name: "Новинки", IsActive: true})
+	if err := NormalizeDraft(&s); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if s.Products[3].Name != "Люля" || s.Products[3].Tags == nil {
+		t.Fatalf("product not normalized: %+v", s.Products[3])
+	}
+
+	bad := []Snapshot{
+		{Categories: []Category{{ID: 0, Name: "Без id"}}},
+		{Categories: []Category{{ID: 1, Name: "A"}, {ID: 1, Name: "B"}}},
+		{Categories: []Category{{ID: 1, Name: "A"}}, Products: []Product{{ID: 2, CategoryID: 3, Name: "X", Price: 100}}},
+		{Categories: []Category{{ID: 1, Name: "A"}}, Products: []Product{{ID: 2, CategoryID: 1, Name: "X"}}},
+	}
+	for i := range bad {
+		if err := NormalizeDraft(&bad[i]); !errors.Is(err, ErrInvalidDraft) {
+			t.Fatalf("case %d: expected ErrInvalidDraft, got %v", i, err)
+		}
+	}
+}
+
+func TestDiffDraft(t *testing.T) {
+	categories, products := transferFixture()
+	live := SnapshotOf(categories, products)
+	draft := SnapshotOf(categories, products)
+	if changes := DiffDraft(live, draft); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %+v", changes)
+	}
+
+	draft.Products = draft.Products[:2]
+	draft.Products[0].Price = 32000
+	draft.Products = append(draft.Products, Product{ID: -1, CategoryID: 1, Name: "Люля", Price: 25000})
+	changes := DiffDraft(live, draft)
+	want := []DraftChange{
+		{Entity: ChangeProduct, Action: ChangeUpdate, ID: 10, Name: "Баранина"},
+		{Entity: ChangeProduct, Action: ChangeCreate, ID: -1, Name: "Люля"},
+		{Entity: ChangeProduct, Action: ChangeArchive, ID: 20, Name: "Чай"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("change %d: got %+v, want %+v", i, changes[i], want[i])
+		}
+	}
+}
+
+func TestSnapshotRevision(t *testing.T) {
+	categories, products := transferFixture()
+	live := SnapshotOf(categories, products)
+	if live.Revision() == "" || live.Revision() != SnapshotOf(categories, products).Revision() {
+		t.Fatal("revision must be stable for the same menu")
+	}
+
+	stock := 5
+	products[0].Stock = &stock
+	if SnapshotOf(categories, products).Revision() != live.Revision() {
+		t.Fatal("operational fields must not change the revision")
+	}
+	products[0].ImageURL = "https://cdn.example/new.jpg"
+	if SnapshotOf(categories, products).Revision() == live.Revision() {
+		t.Fatal("a direct image edit must change the revision")
+	}
+	categories[1].IsActive = false
+	products[0].ImageURL = ""
+	if SnapshotOf(categories, products).Revision() == live.Revision() {
+		t.Fatal("a category edit must change the revision")
+	}
+}
+
+func TestPreviewMenu(t *testing.T) {
+	now := time.Now()
+	stock := 0
+	categories, products := transferFixture()
+	products[1].Stock = &stock
+	draft := SnapshotOf(categories, products)
+	draft.Categories[1].IsActive = false
+	draft.Products[0].Name = "Баранина на кости"
+
+	resp := PreviewMenu(draft, products, now)
+	if len(resp.Categories) != 1 || len(resp.Categories[0].Products) != 2 {
+		t.Fatalf("unexpected preview %+v", resp)
+	}
+	lamb, chicken := resp.Categories[0].Products[0], resp.Categories[0].Products[1]
+	if lamb.Name != "Баранина на кости" || !lamb.Available {
+		t.Fatalf("unexpected product %+v", lamb)
+	}
+	if chicken.Available {
+		t.Fatalf("product out of stock must be unavailable: %+v", chicken)
+	}
+}
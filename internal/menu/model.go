@@ -1,25 +1,75 @@
 package menu
 
+import "time"
+
 // Category represents menu category metadata.
 type Category struct {
-	ID        int64  `json:"id"`
-	Name      string `json:"name"`
-	Emoji     string `json:"emoji"`
-	SortOrder int    `json:"sort_order"`
-	IsActive  bool   `json:"is_active"`
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Emoji      string     `json:"emoji"`
+	SortOrder  int        `json:"sort_order"`
+	IsActive   bool       `json:"is_active"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
 }
 
-// Product represents an item that belongs to a category.
+// Product represents an item that belongs to a category. Stock is nil for
+// products that are not counted; UnavailableUntil puts a product on the
+// stop-list. Available is false for sold-out and stop-listed products, which
+// the menu still lists. Kcal and Weight (grams) are nil when unknown. Bundle
+// lists the slots of combo products and is empty for regular ones.
 type Product struct {
-	ID          int64   `json:"id"`
-	CategoryID  int64   `json:"category_id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	OldPrice    float64 `json:"old_price"`
-	ImageURL    string  `json:"image_url"`
-	IsActive    bool    `json:"is_active"`
-	SortOrder   int     `json:"sort_order"`
+	ID               int64          `json:"id"`
+	CategoryID       int64          `json:"category_id"`
+	Name             string         `json:"name"`
+	Description      string         `json:"description"`
+	Price            float64        `json:"price"`
+	OldPrice         float64        `json:"old_price"`
+	ImageURL         string         `json:"image_url"`
+	Images           *ProductImages `json:"images,omitempty"`
+	IsActive         bool           `json:"is_active"`
+	SortOrder        int            `json:"sort_order"`
+	Tags             []string       `json:"tags"`
+	Allergens        []string       `json:"allergens"`
+	Kcal             *int           `json:"kcal,omitempty"`
+	Weight           *int           `json:"weight,omitempty"`
+	Stock            *int           `json:"stock,omitempty"`
+	UnavailableUntil *time.Time     `json:"unavailable_until,omitempty"`
+	Available        bool           `json:"available"`
+	Bundle           []BundleSlot   `json:"bundle,omitempty"`
+	ArchivedAt       *time.Time     `json:"archived_at,omitempty"`
+}
+
+// BundleSlot is one component of a bundle. A slot with a single option is
+// fixed; with several the customer picks one. Qty units of the chosen product
+// go into every bundle.
+type BundleSlot struct {
+	ID      int64          `json:"id"`
+	Name    string         `json:"name"`
+	Qty     int            `json:"qty"`
+	Options []BundleOption `json:"options"`
+}
+
+// BundleOption is a product that can fill a slot; ExtraPrice is added to the
+// bundle price when it is chosen. Name and Available are read-only.
+type BundleOption struct {
+	ProductID  int64   `json:"product_id"`
+	Name       string  `json:"name"`
+	ExtraPrice float64 `json:"extra_price"`
+	Available  bool    `json:"available"`
+}
+
+// Availability sets stock and the stop-list of a product.
+type Availability struct {
+	Stock            *int       `json:"stock"`
+	UnavailableUntil *time.Time `json:"unavailable_until"`
+}
+
+// ProductImages holds resized copies of an uploaded ImageURL. It is nil for
+// images hosted elsewhere.
+type ProductImages struct {
+	Thumbnail string `json:"thumbnail"`
+	Medium    string `json:"medium"`
+	Large     string `json:"large"`
 }
 
 // MenuCategory wraps category info with its products.
@@ -31,7 +81,58 @@ type MenuCategory struct {
 	Products  []Product `json:"products"`
 }
 
-// MenuResponse holds categories with products for /menu.
+// MenuResponse holds categories with products for /menu. Version is the
+// published menu version, 0 before the first publish. PricesChangeAt is the
+// next price rule boundary, after which the prices are no longer valid.
 type MenuResponse struct {
-	Categories []MenuCategory `json:"categories"`
+	Version        int64          `json:"version"`
+	PricesChangeAt *time.Time     `json:"prices_change_at,omitempty"`
+	Categories     []MenuCategory `json:"categories"`
+}
+
+// MaxAge caps maxAge so clients do not reuse the menu past its next price change.
+func (r *MenuResponse) MaxAge(maxAge time.Duration, now time.Time) time.Duration {
+	if r.PricesChangeAt == nil {
+		return maxAge
+	}
+	return max(min(maxAge, r.PricesChangeAt.Sub(now)), 0)
+}
+
+// MenuFilter narrows GET /menu to products carrying every tag in Tags and none
+// of ExcludeAllergens.
+type MenuFilter struct {
+	Tags             []string
+	ExcludeAllergens []string
+}
+
+// AdminProductParams filters the admin product listing. Nil flags match both states.
+type AdminProductParams struct {
+	Query      string
+	CategoryID int64
+	Active     *bool
+	Archived   *bool
+	Available  *bool
+	Limit      int
+	Offset     int
+}
+
+// ProductPage is a page of the admin product listing.
+type ProductPage struct {
+	Products []Product `json:"products"`
+	Total    int       `json:"total"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+}
+
+// SortOrderUpdate sets the sort_order of one category or product.
+type SortOrderUpdate struct {
+	ID        int64 `json:"id"`
+	SortOrder int   `json:"sort_order"`
+}
+
+// SortOrderChange reports the previous and new sort_order after a reorder.
+type SortOrderChange struct {
+	ID     int64
+	Before int
+	After  int
 }
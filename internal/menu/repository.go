@@ -3,7 +3,11 @@ package menu
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,6 +23,30 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 
 var errNilPool = errors.New("menu repository: nil pool")
 
+// availableExpr is true for products that can be ordered right now.
+const availableExpr = `(is_active AND COALESCE(stock, 1) > 0 AND (unavailable_until IS NULL OR unavailable_until <= NOW()))`
+
+const (
+	categoryColumns = `id, name, COALESCE(emoji,''), sort_order, is_active, archived_at`
+	productColumns  = `id, category_id, name, COALESCE(description,''), price, COALESCE(old_price,0), COALESCE(image_url,''), images, is_active, sort_order, tags, allergens, kcal, weight, stock, unavailable_until, ` + availableExpr + `, archived_at`
+)
+
+func scanCategory(row pgx.Row) (*Category, error) {
+	var res Category
+	if err := row.Scan(&res.ID, &res.Name, &res.Emoji, &res.SortOrder, &res.IsActive, &res.ArchivedAt); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func scanProduct(row pgx.Row) (*Product, error) {
+	var res Product
+	if err := row.Scan(&res.ID, &res.CategoryID, &res.Name, &res.Description, &res.Price, &res.OldPrice, &res.ImageURL, &res.Images, &res.IsActive, &res.SortOrder, &res.Tags, &res.Allergens, &res.Kcal, &res.Weight, &res.Stock, &res.UnavailableUntil, &res.Available, &res.ArchivedAt); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
 // InsertCategory creates a category.
 func (r *Repository) InsertCategory(ctx context.Context, cat Category) (*Category, error) {
 	if r.pool == nil {
@@ -27,14 +55,9 @@ func (r *Repository) InsertCategory(ctx context.Context, cat Category) (*Categor
 	const query = `
 INSERT INTO categories (name, emoji, sort_order, is_active)
 VALUES ($1,$2,$3,$4)
-RETURNING id, name, emoji, sort_order, is_active;
+RETURNING ` + categoryColumns + `;
 `
-	row := r.pool.QueryRow(ctx, query, cat.Name, cat.Emoji, cat.SortOrder, cat.IsActive)
-	var res Category
-	if err := row.Scan(&res.ID, &res.Name, &res.Emoji, &res.SortOrder, &res.IsActive); err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return scanCategory(r.pool.QueryRow(ctx, query, cat.Name, cat.Emoji, cat.SortOrder, cat.IsActive))
 }
 
 // UpdateCategory updates category values.
@@ -46,94 +69,163 @@ func (r *Repository) UpdateCategory(ctx context.Context, cat Category) (*Categor
 UPDATE categories
 SET name=$1, emoji=$2, sort_order=$3, is_active=$4
 WHERE id=$5
-RETURNING id, name, emoji, sort_order, is_active;
+RETURNING ` + categoryColumns + `;
 `
-	row := r.pool.QueryRow(ctx, query, cat.Name, cat.Emoji, cat.SortOrder, cat.IsActive, cat.ID)
-	var res Category
-	if err := row.Scan(&res.ID, &res.Name, &res.Emoji, &res.SortOrder, &res.IsActive); err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return scanCategory(r.pool.QueryRow(ctx, query, cat.Name, cat.Emoji, cat.SortOrder, cat.IsActive, cat.ID))
 }
 
-// DeleteCategory removes category.
-func (r *Repository) DeleteCategory(ctx context.Context, id int64) error {
+// GetCategory returns a category by id, archived or not.
+func (r *Repository) GetCategory(ctx context.Context, id int64) (*Category, error) {
 	if r.pool == nil {
-		return errNilPool
+		return nil, errNilPool
 	}
-	const query = `DELETE FROM categories WHERE id=$1;`
-	_, err := r.pool.Exec(ctx, query, id)
-	return err
+	return scanCategory(r.pool.QueryRow(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id=$1;`, id))
 }
 
-// InsertProduct creates a product.
-func (r *Repository) InsertProduct(ctx context.Context, product Product) (*Product, error) {
+// ArchiveCategory hides a category from the public menu; archiving twice keeps the first timestamp.
+func (r *Repository) ArchiveCategory(ctx context.Context, id int64) (*Category, error) {
 	if r.pool == nil {
 		return nil, errNilPool
 	}
 	const query = `
-INSERT INTO products (category_id, name, description, price, old_price, image_url, is_active, sort_order)
-VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
-RETURNING id, category_id, name, description, price, COALESCE(old_price,0), image_url, is_active, sort_order;
+UPDATE categories SET archived_at = COALESCE(archived_at, NOW())
+WHERE id=$1
+RETURNING ` + categoryColumns + `;
 `
-	row := r.pool.QueryRow(ctx, query, product.CategoryID, product.Name, product.Description, product.Price, product.OldPrice, product.ImageURL, product.IsActive, product.SortOrder)
-	var res Product
-	if err := row.Scan(&res.ID, &res.CategoryID, &res.Name, &res.Description, &res.Price, &res.OldPrice, &res.ImageURL, &res.IsActive, &res.SortOrder); err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return scanCategory(r.pool.QueryRow(ctx, query, id))
 }
 
-// UpdateProduct updates values.
-func (r *Repository) UpdateProduct(ctx context.Context, product Product) (*Product, error) {
+const restoreCategoryQuery = `
+UPDATE categories SET archived_at = NULL
+WHERE id=$1
+RETURNING ` + categoryColumns + `;
+`
+
+// RestoreCategory brings an archived category back.
+func (r *Repository) RestoreCategory(ctx context.Context, id int64) (*Category, error) {
 	if r.pool == nil {
 		return nil, errNilPool
 	}
-	const query = `
+	return scanCategory(r.pool.QueryRow(ctx, restoreCategoryQuery, id))
+}
+
+const insertProductQuery = `
+INSERT INTO products (category_id, name, description, price, old_price, image_url, is_active, sort_order, tags, allergens, kcal, weight)
+VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+RETURNING ` + productColumns + `;
+`
+
+const updateProductQuery = `
 UPDATE products
 SET category_id=$1,
     name=$2,
     description=$3,
     price=$4,
     old_price=$5,
+    images=CASE WHEN COALESCE(image_url,'') = $6 THEN images END,
     image_url=$6,
     is_active=$7,
-    sort_order=$8
-WHERE id=$9
-RETURNING id, category_id, name, description, price, COALESCE(old_price,0), image_url, is_active, sort_order;
+    sort_order=$8,
+    tags=$9,
+    allergens=$10,
+    kcal=$11,
+    weight=$12
+WHERE id=$13
+RETURNING ` + productColumns + `;
 `
-	row := r.pool.QueryRow(ctx, query, product.CategoryID, product.Name, product.Description, product.Price, product.OldPrice, product.ImageURL, product.IsActive, product.SortOrder, product.ID)
-	var res Product
-	if err := row.Scan(&res.ID, &res.CategoryID, &res.Name, &res.Description, &res.Price, &res.OldPrice, &res.ImageURL, &res.IsActive, &res.SortOrder); err != nil {
-		return nil, err
+
+// InsertProduct creates a product.
+func (r *Repository) InsertProduct(ctx context.Context, product Product) (*Product, error) {
+	if r.pool == nil {
+		return nil, errNilPool
 	}
-	return &res, nil
+	return scanProduct(r.pool.QueryRow(ctx, insertProductQuery, product.CategoryID, product.Name, product.Description, product.Price, product.OldPrice, product.ImageURL, product.IsActive, product.SortOrder, attributeList(product.Tags), attributeList(product.Allergens), product.Kcal, product.Weight))
 }
 
-// DeleteProduct removes product by id.
-func (r *Repository) DeleteProduct(ctx context.Context, id int64) error {
+// UpdateProduct updates values.
+func (r *Repository) UpdateProduct(ctx context.Context, product Product) (*Product, error) {
 	if r.pool == nil {
-		return errNilPool
+		return nil, errNilPool
 	}
-	const query = `DELETE FROM products WHERE id=$1;`
-	_, err := r.pool.Exec(ctx, query, id)
-	return err
+	return scanProduct(r.pool.QueryRow(ctx, updateProductQuery, product.CategoryID, product.Name, product.Description, product.Price, product.OldPrice, product.ImageURL, product.IsActive, product.SortOrder, attributeList(product.Tags), attributeList(product.Allergens), product.Kcal, product.Weight, product.ID))
 }
 
-// GetActiveCategories returns all active categories ordered by sort_order.
-func (r *Repository) GetActiveCategories(ctx context.Context) ([]Category, error) {
+// SetProductImage replaces image_url and its variants; an empty url clears both.
+func (r *Repository) SetProductImage(ctx context.Context, id int64, imageURL string, images *ProductImages) (*Product, error) {
 	if r.pool == nil {
 		return nil, errNilPool
 	}
+	const query = `
+UPDATE products SET image_url=NULLIF($2,''), images=$3, updated_at=NOW()
+WHERE id=$1
+RETURNING ` + productColumns + `;
+`
+	return scanProduct(r.pool.QueryRow(ctx, query, id, imageURL, images))
+}
 
+// SetAvailability replaces stock and the stop-list of a product.
+func (r *Repository) SetAvailability(ctx context.Context, id int64, a Availability) (*Product, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
 	const query = `
-SELECT id, name, emoji, sort_order, is_active
+UPDATE products SET stock=$2, unavailable_until=$3, updated_at=NOW()
+WHERE id=$1
+RETURNING ` + productColumns + `;
+`
+	return scanProduct(r.pool.QueryRow(ctx, query, id, a.Stock, a.UnavailableUntil))
+}
+
+// GetProduct returns a product by id, archived or not.
+func (r *Repository) GetProduct(ctx context.Context, id int64) (*Product, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	return scanProduct(r.pool.QueryRow(ctx, `SELECT `+productColumns+` FROM products WHERE id=$1;`, id))
+}
+
+// ArchiveProduct hides a product from the menu and from new orders. Existing
+// order items keep referencing it.
+func (r *Repository) ArchiveProduct(ctx context.Context, id int64) (*Product, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	const query = `
+UPDATE products SET archived_at = COALESCE(archived_at, NOW())
+WHERE id=$1
+RETURNING ` + productColumns + `;
+`
+	return scanProduct(r.pool.QueryRow(ctx, query, id))
+}
+
+const restoreProductQuery = `
+UPDATE products SET archived_at = NULL
+WHERE id=$1
+RETURNING ` + productColumns + `;
+`
+
+// RestoreProduct brings an archived product back.
+func (r *Repository) RestoreProduct(ctx context.Context, id int64) (*Product, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	return scanProduct(r.pool.QueryRow(ctx, restoreProductQuery, id))
+}
+
+const activeCategoriesQuery = `
+SELECT ` + categoryColumns + `
 FROM categories
-WHERE is_active = TRUE
+WHERE is_active = TRUE AND archived_at IS NULL
 ORDER BY sort_order ASC, id;
 `
 
-	rows, err := r.pool.Query(ctx, query)
+// GetActiveCategories returns active, non-archived categories ordered by sort_order.
+func (r *Repository) GetActiveCategories(ctx context.Context) ([]Category, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+
+	rows, err := r.pool.Query(ctx, activeCategoriesQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -141,11 +233,11 @@ ORDER BY sort_order ASC, id;
 
 	var categories []Category
 	for rows.Next() {
-		var cat Category
-		if err := rows.Scan(&cat.ID, &cat.Name, &cat.Emoji, &cat.SortOrder, &cat.IsActive); err != nil {
+		cat, err := scanCategory(rows)
+		if err != nil {
 			return nil, err
 		}
-		categories = append(categories, cat)
+		categories = append(categories, *cat)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -155,37 +247,775 @@ ORDER BY sort_order ASC, id;
 	return categories, nil
 }
 
-// GetActiveProducts returns active products ordered by category -> sort_order.
+const activeProductsQuery = `
+SELECT ` + productColumns + `
+FROM products
+WHERE is_active = TRUE AND archived_at IS NULL
+ORDER BY category_id, sort_order ASC, id;
+`
+
+// GetActiveProducts returns active, non-archived products ordered by category -> sort_order.
 func (r *Repository) GetActiveProducts(ctx context.Context) ([]Product, error) {
 	if r.pool == nil {
 		return nil, errNilPool
 	}
 
+	rows, err := r.pool.Query(ctx, activeProductsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+const searchProductsQuery = `
+SELECT ` + productColumns + `
+FROM products, to_tsquery('simple', replace($1, ' ', ':* & ') || ':*') AS tsq
+WHERE is_active = TRUE AND archived_at IS NULL
+  AND category_id IN (SELECT id FROM categories WHERE is_active = TRUE AND archived_at IS NULL)
+  AND (search_vector @@ tsq OR $1 <% search_text)
+ORDER BY ts_rank(search_vector, tsq) + word_similarity($1, search_name) DESC, sort_order, id
+LIMIT $2;
+`
+
+// SearchProducts returns active products of active categories matching a
+// NormalizeQuery result: every word as a full-text prefix, or the whole query
+// fuzzily by trigram word similarity. Name matches rank above description ones.
+func (r *Repository) SearchProducts(ctx context.Context, q string, limit int) ([]Product, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+
+	rows, err := r.pool.Query(ctx, searchProductsQuery, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetBundleSlots returns the slots of the given bundles, keyed by bundle id.
+// Products that are not bundles are absent. Options of hidden, archived,
+// sold-out or stop-listed products are reported with Available unset.
+func (r *Repository) GetBundleSlots(ctx context.Context, bundleIDs []int64) (map[int64][]BundleSlot, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	result := make(map[int64][]BundleSlot)
+	if len(bundleIDs) == 0 {
+		return result, nil
+	}
+
 	const query = `
-SELECT id, category_id, name, description, price, COALESCE(old_price, 0), image_url, is_active, sort_order
-FROM products
-WHERE is_active = TRUE
-ORDER BY category_id, sort_order ASC, id;
+SELECT s.bundle_id, s.id, s.name, s.qty, o.product_id, p.name, o.extra_price,
+       (p.is_active AND p.archived_at IS NULL AND COALESCE(p.stock, 1) > 0 AND (p.unavailable_until IS NULL OR p.unavailable_until <= NOW()))
+FROM bundle_slots s
+JOIN bundle_slot_options o ON o.slot_id = s.id
+JOIN products p ON p.id = o.product_id
+WHERE s.bundle_id = ANY($1)
+ORDER BY s.bundle_id, s.sort_order, s.id, o.sort_order, o.product_id;
 `
 
-	rows, err := r.pool.Query(ctx, query)
+	rows, err := r.pool.Query(ctx, query, bundleIDs)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var products []Product
 	for rows.Next() {
-		var p Product
-		if err := rows.Scan(&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.Price, &p.OldPrice, &p.ImageURL, &p.IsActive, &p.SortOrder); err != nil {
+		var (
+			bundleID int64
+			slot     BundleSlot
+			opt      BundleOption
+		)
+		if err := rows.Scan(&bundleID, &slot.ID, &slot.Name, &slot.Qty, &opt.ProductID, &opt.Name, &opt.ExtraPrice, &opt.Available); err != nil {
 			return nil, err
 		}
+		slots := result[bundleID]
+		if n := len(slots); n == 0 || slots[n-1].ID != slot.ID {
+			slots = append(slots, slot)
+		}
+		last := &slots[len(slots)-1]
+		last.Options = append(last.Options, opt)
+		result[bundleID] = slots
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetBundle replaces the slots of a bundle in one transaction and returns them
+// as stored. Options must not be bundles, and a product used as an option
+// cannot become a bundle; both fail with ErrInvalidBundle.
+func (r *Repository) SetBundle(ctx context.Context, bundleID int64, slots []BundleSlot) ([]BundleSlot, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if _, err := tx.Exec(ctx, `DELETE FROM bundle_slots WHERE bundle_id = $1;`, bundleID); err != nil {
+		return nil, err
+	}
+	if len(slots) > 0 {
+		var usedAsOption bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM bundle_slot_options WHERE product_id = $1);`, bundleID).Scan(&usedAsOption); err != nil {
+			return nil, err
+		}
+		if usedAsOption {
+			return nil, fmt.Errorf("%w: product is an option of another bundle", ErrInvalidBundle)
+		}
+	}
+
+	var optionIDs []int64
+	for i, slot := range slots {
+		var slotID int64
+		const insertSlot = `INSERT INTO bundle_slots (bundle_id, name, qty, sort_order) VALUES ($1,$2,$3,$4) RETURNING id;`
+		if err := tx.QueryRow(ctx, insertSlot, bundleID, slot.Name, slot.Qty, i).Scan(&slotID); err != nil {
+			return nil, err
+		}
+		for j, opt := range slot.Options {
+			const insertOption = `INSERT INTO bundle_slot_options (slot_id, product_id, extra_price, sort_order) VALUES ($1,$2,$3,$4);`
+			if _, err := tx.Exec(ctx, insertOption, slotID, opt.ProductID, opt.ExtraPrice, j); err != nil {
+				if isForeignKeyViolation(err) {
+					return nil, fmt.Errorf("%w: unknown product %d", ErrInvalidBundle, opt.ProductID)
+				}
+				return nil, err
+			}
+			optionIDs = append(optionIDs, opt.ProductID)
+		}
+	}
+	if len(optionIDs) > 0 {
+		var nested bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM bundle_slots WHERE bundle_id = ANY($1));`, optionIDs).Scan(&nested); err != nil {
+			return nil, err
+		}
+		if nested {
+			return nil, fmt.Errorf("%w: bundles cannot contain other bundles", ErrInvalidBundle)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	stored, err := r.GetBundleSlots(ctx, []int64{bundleID})
+	if err != nil {
+		return nil, err
+	}
+	if stored[bundleID] == nil {
+		return []BundleSlot{}, nil
+	}
+	return stored[bundleID], nil
+}
+
+// ListCategories returns all categories for the admin panel, including hidden ones.
+// A nil archived matches both archived and live categories.
+func (r *Repository) ListCategories(ctx context.Context, archived *bool) ([]Category, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	rows, err := r.pool.Query(ctx, categoryListQuery(archived))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []Category{}
+	for rows.Next() {
+		cat, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, *cat)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// ListProducts returns a page of products matching params and the total match count.
+func (r *Repository) ListProducts(ctx context.Context, params AdminProductParams) ([]Product, int, error) {
+	if r.pool == nil {
+		return nil, 0, errNilPool
+	}
+	query, args := productListQuery(params)
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	total := 0
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.Price, &p.OldPrice, &p.ImageURL, &p.Images, &p.IsActive, &p.SortOrder, &p.Tags, &p.Allergens, &p.Kcal, &p.Weight, &p.Stock, &p.UnavailableUntil, &p.Available, &p.ArchivedAt, &total); err != nil {
+			return nil, 0, err
+		}
 		products = append(products, p)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+// productListQuery builds the admin product listing query with its arguments.
+func productListQuery(params AdminProductParams) (string, []interface{}) {
+	query := `SELECT ` + productColumns + `, COUNT(*) OVER() FROM products WHERE 1=1`
+	args := []interface{}{}
+	idx := 1
+	if q := strings.TrimSpace(params.Query); q != "" {
+		query += fmt.Sprintf(" AND (name ILIKE $%d OR description ILIKE $%d)", idx, idx)
+		args = append(args, "%"+escapeLike(q)+"%")
+		idx++
+	}
+	if params.CategoryID != 0 {
+		query += fmt.Sprintf(" AND category_id = $%d", idx)
+		args = append(args, params.CategoryID)
+		idx++
+	}
+	if params.Active != nil {
+		query += fmt.Sprintf(" AND is_active = $%d", idx)
+		args = append(args, *params.Active)
+		idx++
+	}
+	if params.Archived != nil {
+		query += archivedCondition(*params.Archived)
+	}
+	if params.Available != nil {
+		query += fmt.Sprintf(" AND %s = $%d", availableExpr, idx)
+		args = append(args, *params.Available)
+		idx++
+	}
+	query += fmt.Sprintf(" ORDER BY category_id, sort_order ASC, id LIMIT $%d OFFSET $%d", idx, idx+1)
+	args = append(args, params.Limit, params.Offset)
+	return query, args
+}
 
+// ReorderCategories sets sort_order for several categories in one statement.
+// Unknown ids fail the whole update with pgx.ErrNoRows.
+func (r *Repository) ReorderCategories(ctx context.Context, updates []SortOrderUpdate) ([]SortOrderChange, error) {
+	return r.reorder(ctx, "categories", updates)
+}
+
+// ReorderProducts sets sort_order for several products in one statement.
+// Unknown ids fail the whole update with pgx.ErrNoRows.
+func (r *Repository) ReorderProducts(ctx context.Context, updates []SortOrderUpdate) ([]SortOrderChange, error) {
+	return r.reorder(ctx, "products", updates)
+}
+
+func (r *Repository) reorder(ctx context.Context, table string, updates []SortOrderUpdate) ([]SortOrderChange, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	ids := make([]int64, len(updates))
+	orders := make([]int32, len(updates))
+	for i, u := range updates {
+		ids[i], orders[i] = u.ID, int32(u.SortOrder)
+	}
+
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// old is read from the statement snapshot, so it still holds the previous order.
+	query := `
+UPDATE ` + table + ` t
+SET sort_order = v.sort_order
+FROM unnest($1::bigint[], $2::int[]) AS v(id, sort_order), ` + table + ` old
+WHERE t.id = v.id AND old.id = v.id
+RETURNING t.id, old.sort_order, t.sort_order;
+`
+	rows, err := tx.Query(ctx, query, ids, orders)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]SortOrderChange, 0, len(updates))
+	for rows.Next() {
+		var ch SortOrderChange
+		if err := rows.Scan(&ch.ID, &ch.Before, &ch.After); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		changes = append(changes, ch)
+	}
+	rows.Close()
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	if len(changes) != len(updates) {
+		return nil, pgx.ErrNoRows
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// ListAllProducts returns every product, archived ones included, for imports and exports.
+func (r *Repository) ListAllProducts(ctx context.Context) ([]Product, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	rows, err := r.pool.Query(ctx, `SELECT `+productColumns+` FROM products ORDER BY category_id, sort_order, id;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
+	products := []Product{}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return products, nil
 }
+
+// ApplyImport writes an import plan in one transaction: new categories first,
+// then created, updated and deactivated products. The plan is updated in place
+// with the stored rows, so created products carry their new ids.
+func (r *Repository) ApplyImport(ctx context.Context, plan *ImportPlan) error {
+	if r.pool == nil {
+		return errNilPool
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	const insertCategory = `
+INSERT INTO categories (name, emoji, sort_order, is_active)
+VALUES ($1, '', (SELECT COALESCE(MAX(sort_order), 0) + 1 FROM categories), TRUE)
+RETURNING id;
+`
+	newIDs := make(map[string]int64, len(plan.NewCategories))
+	for _, name := range plan.NewCategories {
+		var id int64
+		if err := tx.QueryRow(ctx, insertCategory, name).Scan(&id); err != nil {
+			return err
+		}
+		newIDs[normalizeName(name)] = id
+	}
+	resolve := func(ch *ImportChange) {
+		if ch.After.CategoryID == 0 {
+			ch.After.CategoryID = newIDs[normalizeName(ch.Category)]
+		}
+	}
+
+	for i := range plan.Created {
+		ch := &plan.Created[i]
+		resolve(ch)
+		p := ch.After
+		stored, err := scanProduct(tx.QueryRow(ctx, insertProductQuery, p.CategoryID, p.Name, p.Description, p.Price, p.OldPrice, p.ImageURL, p.IsActive, p.SortOrder, attributeList(p.Tags), attributeList(p.Allergens), p.Kcal, p.Weight))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", ch.Line, err)
+		}
+		ch.After = *stored
+	}
+	for i := range plan.Updated {
+		ch := &plan.Updated[i]
+		resolve(ch)
+		p := ch.After
+		stored, err := scanProduct(tx.QueryRow(ctx, updateProductQuery, p.CategoryID, p.Name, p.Description, p.Price, p.OldPrice, p.ImageURL, p.IsActive, p.SortOrder, attributeList(p.Tags), attributeList(p.Allergens), p.Kcal, p.Weight, p.ID))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", ch.Line, err)
+		}
+		ch.After = *stored
+	}
+	if len(plan.Deactivated) > 0 {
+		ids := make([]int64, len(plan.Deactivated))
+		for i, ch := range plan.Deactivated {
+			ids[i] = ch.After.ID
+		}
+		if _, err := tx.Exec(ctx, `UPDATE products SET is_active = FALSE WHERE id = ANY($1);`, ids); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// querier is implemented by both the pool and transactions.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// GetDraft returns the staged menu draft, or pgx.ErrNoRows when there is none.
+func (r *Repository) GetDraft(ctx context.Context) (*Draft, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	var d Draft
+	err := r.pool.QueryRow(ctx, `SELECT base_version, base_revision, document, updated_at FROM menu_drafts;`).Scan(&d.BaseVersion, &d.BaseRevision, &d.Snapshot, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// SaveDraft stores d as the only draft.
+func (r *Repository) SaveDraft(ctx context.Context, d Draft) (*Draft, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	const query = `
+INSERT INTO menu_drafts (base_version, base_revision, document) VALUES ($1, $2, $3)
+ON CONFLICT (id) DO UPDATE SET base_version = EXCLUDED.base_version, base_revision = EXCLUDED.base_revision, document = EXCLUDED.document, updated_at = NOW()
+RETURNING updated_at;
+`
+	if err := r.pool.QueryRow(ctx, query, d.BaseVersion, d.BaseRevision, d.Snapshot).Scan(&d.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// DeleteDraft discards the draft; deleting a missing draft is not an error.
+func (r *Repository) DeleteDraft(ctx context.Context) error {
+	if r.pool == nil {
+		return errNilPool
+	}
+	_, err := r.pool.Exec(ctx, `DELETE FROM menu_drafts;`)
+	return err
+}
+
+// CurrentVersion returns the latest published version, 0 before the first publish.
+func (r *Repository) CurrentVersion(ctx context.Context) (int64, error) {
+	if r.pool == nil {
+		return 0, errNilPool
+	}
+	return currentVersion(ctx, r.pool)
+}
+
+func currentVersion(ctx context.Context, q querier) (int64, error) {
+	var version int64
+	err := q.QueryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM menu_versions;`).Scan(&version)
+	return version, err
+}
+
+// LiveSnapshot returns the current menu content as a draft would see it.
+func (r *Repository) LiveSnapshot(ctx context.Context) (Snapshot, error) {
+	if r.pool == nil {
+		return Snapshot{}, errNilPool
+	}
+	return liveSnapshot(ctx, r.pool)
+}
+
+func liveSnapshot(ctx context.Context, q querier) (Snapshot, error) {
+	rows, err := q.Query(ctx, `SELECT `+categoryColumns+` FROM categories WHERE archived_at IS NULL ORDER BY sort_order, id;`)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var categories []Category
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			rows.Close()
+			return Snapshot{}, err
+		}
+		categories = append(categories, *c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Snapshot{}, err
+	}
+
+	rows, err = q.Query(ctx, `SELECT `+productColumns+` FROM products WHERE archived_at IS NULL ORDER BY category_id, sort_order, id;`)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var products []Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			rows.Close()
+			return Snapshot{}, err
+		}
+		products = append(products, *p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return Snapshot{}, err
+	}
+	return SnapshotOf(categories, products), nil
+}
+
+// PublishDraft applies d to the live menu, records it as a new version and
+// drops the draft, all in one transaction. It fails with ErrDraftOutdated when
+// another version was published since d.BaseVersion or the live menu no
+// longer matches d.BaseRevision, so direct edits are never silently undone.
+func (r *Repository) PublishDraft(ctx context.Context, d Draft) (*MenuVersion, error) {
+	return r.publish(ctx, d.Snapshot, &d, nil)
+}
+
+// RestoreVersion re-applies the snapshot of version as a new version. A zero
+// version restores the one before the current; pgx.ErrNoRows means there is
+// no such version.
+func (r *Repository) RestoreVersion(ctx context.Context, version int64) (*MenuVersion, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `SELECT version, snapshot FROM menu_versions WHERE version = $1;`
+	if version == 0 {
+		query = `SELECT version, snapshot FROM menu_versions ORDER BY version DESC OFFSET 1 LIMIT 1;`
+	}
+	var (
+		from     int64
+		snapshot Snapshot
+	)
+	args := []any{}
+	if version != 0 {
+		args = append(args, version)
+	}
+	if err := r.pool.QueryRow(ctx, query, args...).Scan(&from, &snapshot); err != nil {
+		return nil, err
+	}
+	return r.publish(ctx, snapshot, nil, &from)
+}
+
+// ImageReferenced reports whether a published version or the draft uses url
+// as a product image or one of its resized copies, so a rollback or publish
+// may still bring it back.
+func (r *Repository) ImageReferenced(ctx context.Context, url string) (bool, error) {
+	if r.pool == nil {
+		return false, errNilPool
+	}
+	const query = `
+SELECT EXISTS (
+    SELECT 1 FROM menu_versions v, jsonb_array_elements(v.snapshot->'products') p
+    WHERE $1 IN (p->>'image_url', p->'images'->>'thumbnail', p->'images'->>'medium', p->'images'->>'large')
+) OR EXISTS (
+    SELECT 1 FROM menu_drafts d, jsonb_array_elements(d.document->'products') p
+    WHERE $1 IN (p->>'image_url', p->'images'->>'thumbnail', p->'images'->>'medium', p->'images'->>'large')
+);
+`
+	var referenced bool
+	err := r.pool.QueryRow(ctx, query, url).Scan(&referenced)
+	return referenced, err
+}
+
+func (r *Repository) publish(ctx context.Context, s Snapshot, base *Draft, restoredFrom *int64) (*MenuVersion, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	// Serializes publishes and rollbacks.
+	if _, err := tx.Exec(ctx, `LOCK TABLE menu_versions IN EXCLUSIVE MODE;`); err != nil {
+		return nil, err
+	}
+	current, err := currentVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil && base.BaseVersion != current {
+		return nil, ErrDraftOutdated
+	}
+	if base != nil {
+		// Holds off direct edits between the revision check and the apply.
+		if _, err := tx.Exec(ctx, `LOCK TABLE categories, products IN SHARE ROW EXCLUSIVE MODE;`); err != nil {
+			return nil, err
+		}
+	}
+	before, err := liveSnapshot(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil && base.BaseRevision != before.Revision() {
+		return nil, ErrDraftOutdated
+	}
+	// The menu before the first publish becomes version 1, so it can be restored.
+	if current == 0 {
+		if _, err := tx.Exec(ctx, `INSERT INTO menu_versions (snapshot) VALUES ($1);`, before); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applySnapshot(ctx, tx, s); err != nil {
+		return nil, err
+	}
+	after, err := liveSnapshot(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	v := MenuVersion{RestoredFrom: restoredFrom, Categories: len(after.Categories), Products: len(after.Products)}
+	const insertVersion = `INSERT INTO menu_versions (snapshot, restored_from) VALUES ($1, $2) RETURNING version, published_at;`
+	if err := tx.QueryRow(ctx, insertVersion, after, restoredFrom).Scan(&v.Version, &v.PublishedAt); err != nil {
+		return nil, err
+	}
+	if base != nil {
+		if _, err := tx.Exec(ctx, `DELETE FROM menu_drafts;`); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// applySnapshot makes the live menu match s: listed entries are updated or,
+// with negative ids, created; live entries left out are archived.
+func applySnapshot(ctx context.Context, tx pgx.Tx, s Snapshot) error {
+	categoryIDs := make(map[int64]int64, len(s.Categories))
+	keep := make([]int64, 0, len(s.Categories))
+	for _, c := range s.Categories {
+		id := c.ID
+		if id > 0 {
+			const query = `UPDATE categories SET name=$1, emoji=$2, sort_order=$3, is_active=$4, archived_at=NULL WHERE id=$5;`
+			tag, err := tx.Exec(ctx, query, c.Name, c.Emoji, c.SortOrder, c.IsActive, c.ID)
+			if err != nil {
+				return err
+			}
+			if tag.RowsAffected() == 0 {
+				return fmt.Errorf("%w: unknown category %d", ErrInvalidDraft, c.ID)
+			}
+		} else {
+			const query = `INSERT INTO categories (name, emoji, sort_order, is_active) VALUES ($1,$2,$3,$4) RETURNING id;`
+			if err := tx.QueryRow(ctx, query, c.Name, c.Emoji, c.SortOrder, c.IsActive).Scan(&id); err != nil {
+				return err
+			}
+		}
+		categoryIDs[c.ID] = id
+		keep = append(keep, id)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE categories SET archived_at = NOW() WHERE archived_at IS NULL AND NOT (id = ANY($1));`, keep); err != nil {
+		return err
+	}
+
+	keep = make([]int64, 0, len(s.Products))
+	for _, p := range s.Products {
+		p.CategoryID = categoryIDs[p.CategoryID]
+		if p.ID > 0 {
+			tag, err := tx.Exec(ctx, updateProductQuery, p.CategoryID, p.Name, p.Description, p.Price, p.OldPrice, p.ImageURL, p.IsActive, p.SortOrder, attributeList(p.Tags), attributeList(p.Allergens), p.Kcal, p.Weight, p.ID)
+			if err != nil {
+				return err
+			}
+			if tag.RowsAffected() == 0 {
+				return fmt.Errorf("%w: unknown product %d", ErrInvalidDraft, p.ID)
+			}
+			keep = append(keep, p.ID)
+			continue
+		}
+		stored, err := scanProduct(tx.QueryRow(ctx, insertProductQuery, p.CategoryID, p.Name, p.Description, p.Price, p.OldPrice, p.ImageURL, p.IsActive, p.SortOrder, attributeList(p.Tags), attributeList(p.Allergens), p.Kcal, p.Weight))
+		if err != nil {
+			return err
+		}
+		keep = append(keep, stored.ID)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE products SET archived_at = NULL WHERE archived_at IS NOT NULL AND id = ANY($1);`, keep); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `UPDATE products SET archived_at = NOW() WHERE archived_at IS NULL AND NOT (id = ANY($1));`, keep)
+	return err
+}
+
+// ListVersions returns the latest published versions, newest first.
+func (r *Repository) ListVersions(ctx context.Context, limit int) ([]MenuVersion, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	const query = `
+SELECT version, restored_from, jsonb_array_length(snapshot->'categories'), jsonb_array_length(snapshot->'products'), published_at
+FROM menu_versions
+ORDER BY version DESC
+LIMIT $1;
+`
+	rows, err := r.pool.Query(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []MenuVersion{}
+	for rows.Next() {
+		var v MenuVersion
+		if err := rows.Scan(&v.Version, &v.RestoredFrom, &v.Categories, &v.Products, &v.PublishedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// categoryListQuery builds the ListCategories query; a nil archived adds no filter.
+func categoryListQuery(archived *bool) string {
+	query := `SELECT ` + categoryColumns + ` FROM categories WHERE 1=1`
+	if archived != nil {
+		query += archivedCondition(*archived)
+	}
+	return query + ` ORDER BY sort_order ASC, id;`
+}
+
+func archivedCondition(archived bool) string {
+	if archived {
+		return " AND archived_at IS NOT NULL"
+	}
+	return " AND archived_at IS NULL"
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+// attributeList keeps nil tag and allergen lists from violating NOT NULL.
+func attributeList(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// escapeLike escapes LIKE wildcards in user input.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,102 @@
+package menu
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProductListQuery(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name     string
+		params   AdminProductParams
+		contains []string
+		args     []interface{}
+	}{
+		{
+			name:   "no filters",
+			params: AdminProductParams{Limit: 50},
+			contains: []string{
+				"WHERE 1=1 ORDER BY category_id, sort_order ASC, id LIMIT $1 OFFSET $2",
+			},
+			args: []interface{}{50, 0},
+		},
+		{
+			name:   "search escapes wildcards",
+			params: AdminProductParams{Query: " 50%_off ", Limit: 10, Offset: 20},
+			contains: []string{
+				"(name ILIKE $1 OR description ILIKE $1)",
+				"LIMIT $2 OFFSET $3",
+			},
+			args: []interface{}{`%50\%\_off%`, 10, 20},
+		},
+		{
+			name:   "all filters",
+			params: AdminProductParams{Query: "plov", CategoryID: 3, Active: &yes, Archived: &no, Available: &no, Limit: 5},
+			contains: []string{
+				"AND category_id = $2",
+				"AND is_active = $3",
+				"AND archived_at IS NULL",
+				"AND " + availableExpr + " = $4",
+				"LIMIT $5 OFFSET $6",
+			},
+			args: []interface{}{"%plov%", int64(3), true, false, 5, 0},
+		},
+		{
+			name:     "archived only",
+			params:   AdminProductParams{Archived: &yes, Limit: 5},
+			contains: []string{"AND archived_at IS NOT NULL", "LIMIT $1 OFFSET $2"},
+			args:     []interface{}{5, 0},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			query, args := productListQuery(tc.params)
+			for _, part := range tc.contains {
+				if !strings.Contains(query, part) {
+					t.Errorf("query %q does not contain %q", query, part)
+				}
+			}
+			if !reflect.DeepEqual(args, tc.args) {
+				t.Errorf("args = %#v, want %#v", args, tc.args)
+			}
+		})
+	}
+}
+
+func TestPublicQueriesHideArchived(t *testing.T) {
+	for name, query := range map[string]string{
+		"categories": activeCategoriesQuery,
+		"products":   activeProductsQuery,
+		"search":     searchProductsQuery,
+	} {
+		if !strings.Contains(query, "archived_at IS NULL") {
+			t.Errorf("%s query must skip archived rows: %q", name, query)
+		}
+	}
+	if strings.Count(searchProductsQuery, "archived_at IS NULL") != 2 {
+		t.Error("search must skip products of archived categories")
+	}
+}
+
+func TestCategoryListQuery(t *testing.T) {
+	yes, no := true, false
+	if query := categoryListQuery(nil); strings.Contains(query, "archived_at IS") {
+		t.Errorf("listing without a filter must include archived categories: %q", query)
+	}
+	if query := categoryListQuery(&yes); !strings.Contains(query, "AND archived_at IS NOT NULL") {
+		t.Errorf("archived=true must list archived categories: %q", query)
+	}
+	if query := categoryListQuery(&no); !strings.Contains(query, "AND archived_at IS NULL") {
+		t.Errorf("archived=false must hide archived categories: %q", query)
+	}
+}
+
+func TestRestoreClearsArchivedAt(t *testing.T) {
+	for name, query := range map[string]string{"category": restoreCategoryQuery, "product": restoreProductQuery} {
+		if !strings.Contains(query, "SET archived_at = NULL") {
+			t.Errorf("%s restore must clear archived_at: %q", name, query)
+		}
+	}
+}
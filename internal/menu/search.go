@@ -0,0 +1,72 @@
+package menu
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrEmptyQuery is returned for search queries without letters or digits.
+var ErrEmptyQuery = errors.New("search query is empty")
+
+// SearchResponse holds products matching a search, best matches first. Query is
+// the normalized form the products were matched against.
+type SearchResponse struct {
+	Query    string    `json:"query"`
+	Products []Product `json:"products"`
+}
+
+// Search result limits.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 50
+	maxQueryLen        = 100
+)
+
+// translit spells Russian and Uzbek Cyrillic letters in Uzbek Latin. It must
+// match the menu_search_normalize SQL function of migration 000017.
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "j", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "x", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "sh", 'ъ': "",
+	'ы': "i", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya", 'ў': "o", 'қ': "k",
+	'ғ': "g", 'ҳ': "h", 'q': "k",
+	// Uzbek Latin apostrophes (oʻ, gʻ, tutuq belgisi) are dropped.
+	'ʻ': "", 'ʼ': "", '’': "", '‘': "", '\'': "", '`': "",
+}
+
+// NormalizeQuery folds a search query to the spelling products are indexed
+// under: lowercase Latin letters and digits separated by single spaces, so
+// "Шашлык", "шашлик" and "shashlik" become the same query.
+func NormalizeQuery(q string) string {
+	q = strings.ReplaceAll(strings.ToLower(q), "kh", "x")
+	var b strings.Builder
+	space := false
+	for _, r := range q {
+		if s, ok := translit[r]; ok {
+			if s == "" {
+				continue
+			}
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteString(s)
+			continue
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+			continue
+		}
+		space = true
+	}
+	out := b.String()
+	if len(out) > maxQueryLen {
+		out = strings.TrimSpace(out[:maxQueryLen])
+	}
+	return out
+}
@@ -0,0 +1,25 @@
+package menu
+
+import "testing"
+
+func TestNormalizeQuery(t *testing.T) {
+	cases := map[string]string{
+		"shashlik":             "shashlik",
+		"Шашлык":               "shashlik",
+		"шашлик":               "shashlik",
+		"  Плов,  по-узбекски": "plov po uzbekski",
+		"Oʻzbek palov":         "ozbek palov",
+		"Ўзбек палов":          "ozbek palov",
+		"qozon kabob":          "kozon kabob",
+		"Қозон кабоб":          "kozon kabob",
+		"Хачапури":             "xachapuri",
+		"khachapuri":           "xachapuri",
+		"Чай 0.5":              "chay 0 5",
+		"?!":                   "",
+	}
+	for in, want := range cases {
+		if got := NormalizeQuery(in); got != want {
+			t.Errorf("NormalizeQuery(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
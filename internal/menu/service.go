@@ -3,40 +3,63 @@ package menu
 import (
 	"context"
 	"encoding/json"
+	"strconv"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 
+	"github.com/rashidmailru/kabobfood/internal/cache"
+	"github.com/rashidmailru/kabobfood/internal/pricing"
 	"github.com/rashidmailru/kabobfood/internal/regions"
 )
 
+// Cache keys; admin writes delete them through the shared cache.Store.
 const (
-	menuCacheKey    = "menu:v1"
-	regionsCacheKey = "regions:v1"
+	MenuCacheKey    = "menu:v2"
+	RegionsCacheKey = "regions:v2"
+	// SearchCacheKey holds search responses as fields keyed by limit and
+	// query, so menu writes delete them as a whole.
+	SearchCacheKey = "menu:search:v2"
 )
 
-// Service aggregates menu and region data with caching.
+// loadTimeout bounds a shared load, which outlives the request that started it.
+const loadTimeout = 10 * time.Second
+
+// Service aggregates menu and region data with caching. Concurrent misses of
+// one key share a single load, and expired entries are served stale for
+// staleTTL while one background load refreshes them.
 type Service struct {
 	menuRepo   MenuRepository
 	regionRepo RegionRepository
-	cache      *redis.Client
+	cache      *cache.Store
+	prices     *pricing.Engine
+	flight     singleflight.Group
 	menuTTL    time.Duration
 	regionsTTL time.Duration
+	searchTTL  time.Duration
+	staleTTL   time.Duration
 }
 
-// ServiceConfig holds dependencies.
+// ServiceConfig holds dependencies; Prices is optional. Without Cache the
+// service keeps an in-process cache of its own.
 type ServiceConfig struct {
 	MenuRepo   MenuRepository
 	RegionRepo RegionRepository
-	Cache      *redis.Client
+	Cache      *cache.Store
+	Prices     *pricing.Engine
 	MenuTTL    time.Duration
 	RegionsTTL time.Duration
+	SearchTTL  time.Duration
+	StaleTTL   time.Duration
 }
 
 // MenuRepository defines menu storage access methods.
 type MenuRepository interface {
 	GetActiveCategories(ctx context.Context) ([]Category, error)
 	GetActiveProducts(ctx context.Context) ([]Product, error)
+	SearchProducts(ctx context.Context, query string, limit int) ([]Product, error)
+	GetBundleSlots(ctx context.Context, bundleIDs []int64) (map[int64][]BundleSlot, error)
+	CurrentVersion(ctx context.Context) (int64, error)
 }
 
 // RegionRepository defines region storage access methods.
@@ -60,27 +83,42 @@ func NewService(cfg ServiceConfig) *Service {
 	if ttlRegions <= 0 {
 		ttlRegions = 30 * time.Second
 	}
+	ttlSearch := cfg.SearchTTL
+	if ttlSearch <= 0 {
+		ttlSearch = 5 * time.Minute
+	}
+	store := cfg.Cache
+	if store == nil {
+		store = cache.NewStore(nil, cache.StoreOptions{})
+	}
 
 	return &Service{
 		menuRepo:   cfg.MenuRepo,
 		regionRepo: cfg.RegionRepo,
-		cache:      cfg.Cache,
+		cache:      store,
+		prices:     cfg.Prices,
 		menuTTL:    ttlMenu,
 		regionsTTL: ttlRegions,
+		searchTTL:  ttlSearch,
+		staleTTL:   max(cfg.StaleTTL, 0),
 	}
 }
 
-// GetMenu returns categories with products (cached).
-func (s *Service) GetMenu(ctx context.Context) (*MenuResponse, error) {
-	if s.cache != nil {
-		if data, err := s.cache.Get(ctx, menuCacheKey).Bytes(); err == nil {
-			var resp MenuResponse
-			if err := json.Unmarshal(data, &resp); err == nil {
-				return &resp, nil
-			}
-		}
+// GetMenu returns categories with products priced at request time, narrowed by
+// filter. The full menu is cached and filtered per request.
+func (s *Service) GetMenu(ctx context.Context, filter MenuFilter) (*MenuResponse, error) {
+	resp, err := s.fullMenu(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return resp.Filter(filter), nil
+}
+
+func (s *Service) fullMenu(ctx context.Context) (*MenuResponse, error) {
+	return cached(ctx, s, MenuCacheKey, "", s.loadMenu)
+}
 
+func (s *Service) loadMenu(ctx context.Context) (*MenuResponse, error) {
 	categories, err := s.menuRepo.GetActiveCategories(ctx)
 	if err != nil {
 		return nil, err
@@ -90,40 +128,149 @@ func (s *Service) GetMenu(ctx context.Context) (*MenuResponse, error) {
 		return nil, err
 	}
 
-	resp := buildMenuResponse(categories, products)
+	if err := s.attachBundles(ctx, products); err != nil {
+		return nil, err
+	}
+	prices, err := s.prices.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applyPrices(prices, products)
 
-	if s.cache != nil {
-		if bytes, err := json.Marshal(resp); err == nil {
-			_ = s.cache.Set(ctx, menuCacheKey, bytes, s.menuTTL).Err()
-		}
+	resp := buildMenuResponse(categories, products)
+	if resp.Version, err = s.menuRepo.CurrentVersion(ctx); err != nil {
+		return nil, err
+	}
+	if next := prices.NextChange(); !next.IsZero() {
+		resp.PricesChangeAt = &next
 	}
 
+	// Prices are baked into the cached menu, so it must expire at the next price change.
+	s.store(ctx, MenuCacheKey, "", resp, prices.TTL(s.menuTTL), s.menuTTL)
 	return resp, nil
 }
 
+// Search returns up to limit products matching q, best matches first, priced
+// at request time. Queries are normalized first, so transliterated spellings
+// share one cache entry.
+func (s *Service) Search(ctx context.Context, q string, limit int) (*SearchResponse, error) {
+	query := NormalizeQuery(q)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	if limit <= 0 || limit > MaxSearchLimit {
+		limit = DefaultSearchLimit
+	}
+	field := strconv.Itoa(limit) + ":" + query
+
+	return cached(ctx, s, SearchCacheKey, field, func(ctx context.Context) (*SearchResponse, error) {
+		products, err := s.menuRepo.SearchProducts(ctx, query, limit)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.attachBundles(ctx, products); err != nil {
+			return nil, err
+		}
+		prices, err := s.prices.Snapshot(ctx)
+		if err != nil {
+			return nil, err
+		}
+		applyPrices(prices, products)
+		resp := &SearchResponse{Query: query, Products: products}
+
+		s.store(ctx, SearchCacheKey, field, resp, prices.TTL(s.searchTTL), s.searchTTL)
+		return resp, nil
+	})
+}
+
 // GetRegions returns active regions (cached).
 func (s *Service) GetRegions(ctx context.Context) ([]regions.Region, error) {
-	if s.cache != nil {
-		if data, err := s.cache.Get(ctx, regionsCacheKey).Bytes(); err == nil {
-			var resp []regions.Region
-			if err := json.Unmarshal(data, &resp); err == nil {
-				return resp, nil
+	return cached(ctx, s, RegionsCacheKey, "", func(ctx context.Context) ([]regions.Region, error) {
+		list, err := s.regionRepo.GetActiveRegions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.store(ctx, RegionsCacheKey, "", list, s.regionsTTL, s.regionsTTL)
+		return list, nil
+	})
+}
+
+// cached returns the value under key and field. A stale entry is returned
+// while one background load refreshes it; on a miss concurrent callers wait
+// for one shared load. Loads run detached from the caller, so a cancelled
+// request does not fail the others waiting on it.
+func cached[T any](ctx context.Context, s *Service, key, field string, load func(context.Context) (T, error)) (T, error) {
+	run := func() (any, error) {
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		return load(loadCtx)
+	}
+	flightKey := key + "\x00" + field
+
+	if entry, ok := s.cache.Get(ctx, key, field); ok {
+		var v T
+		if err := json.Unmarshal(entry.Data, &v); err == nil {
+			if entry.Stale {
+				s.flight.DoChan(flightKey, run)
 			}
+			return v, nil
 		}
 	}
 
-	list, err := s.regionRepo.GetActiveRegions(ctx)
-	if err != nil {
-		return nil, err
+	var zero T
+	select {
+	case res := <-s.flight.DoChan(flightKey, run):
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		return res.Val.(T), nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// store caches v for ttl. Entries cut short by a price change get no stale
+// window, so old prices are never served past it.
+func (s *Service) store(ctx context.Context, key, field string, v any, ttl, fullTTL time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	stale := s.staleTTL
+	if ttl < fullTTL {
+		stale = 0
+	}
+	if data, err := json.Marshal(v); err == nil {
+		s.cache.Set(ctx, key, field, data, ttl, stale)
 	}
+}
 
-	if s.cache != nil {
-		if bytes, err := json.Marshal(list); err == nil {
-			_ = s.cache.Set(ctx, regionsCacheKey, bytes, s.regionsTTL).Err()
+// attachBundles fills the slots of bundle products. A bundle with a slot that
+// has no orderable option is unavailable.
+func (s *Service) attachBundles(ctx context.Context, products []Product) error {
+	ids := make([]int64, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+	bundles, err := s.menuRepo.GetBundleSlots(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		if slots, ok := bundles[products[i].ID]; ok {
+			products[i].Bundle = slots
+			products[i].Available = products[i].Available && bundleAvailable(slots)
 		}
 	}
+	return nil
+}
 
-	return list, nil
+// applyPrices replaces stored prices with the ones in effect at the snapshot time.
+func applyPrices(prices *pricing.Snapshot, products []Product) {
+	for i := range products {
+		p := &products[i]
+		quote := prices.Price(p.ID, p.CategoryID, p.Price, p.OldPrice)
+		p.Price, p.OldPrice = quote.Price, quote.OldPrice
+	}
 }
 
 func buildMenuResponse(categories []Category, products []Product) *MenuResponse {
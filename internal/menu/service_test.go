@@ -0,0 +1,130 @@
+package menu
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rashidmailru/kabobfood/internal/regions"
+)
+
+type countingRepo struct {
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (r *countingRepo) GetActiveCategories(context.Context) ([]Category, error) {
+	r.calls.Add(1)
+	<-r.release
+	return []Category{{ID: 1, Name: "Шашлык", IsActive: true}}, nil
+}
+
+func (r *countingRepo) GetActiveProducts(context.Context) ([]Product, error) {
+	return []Product{{ID: 10, CategoryID: 1, Name: "Баранина", Price: 30000, IsActive: true, Available: true}}, nil
+}
+
+func (r *countingRepo) SearchProducts(context.Context, string, int) ([]Product, error) {
+	return nil, nil
+}
+
+func (r *countingRepo) GetBundleSlots(context.Context, []int64) (map[int64][]BundleSlot, error) {
+	return nil, nil
+}
+
+func (r *countingRepo) CurrentVersion(context.Context) (int64, error) {
+	return 3, nil
+}
+
+type noRegions struct{}
+
+func (noRegions) GetActiveRegions(context.Context) ([]regions.Region, error) {
+	return nil, nil
+}
+
+func TestGetMenuCoalescesMisses(t *testing.T) {
+	repo := &countingRepo{release: make(chan struct{})}
+	s := NewService(ServiceConfig{MenuRepo: repo, RegionRepo: noRegions{}})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := s.GetMenu(context.Background(), MenuFilter{})
+			if err == nil && (resp.Version != 3 || len(resp.Categories[0].Products) != 1) {
+				t.Errorf("unexpected menu %+v", resp)
+			}
+			errs <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls := repo.calls.Load(); calls != 1 {
+		t.Fatalf("expected one load for concurrent misses, got %d", calls)
+	}
+
+	if _, err := s.GetMenu(context.Background(), MenuFilter{}); err != nil || repo.calls.Load() != 1 {
+		t.Fatalf("expected cached menu, got %v after %d loads", err, repo.calls.Load())
+	}
+}
+
+func TestMenuMaxAge(t *testing.T) {
+	now := time.Now()
+	soon, past := now.Add(10*time.Second), now.Add(-time.Second)
+	tests := []struct {
+		change *time.Time
+		want   time.Duration
+	}{
+		{change: nil, want: time.Minute},
+		{change: &soon, want: 10 * time.Second},
+		{change: &past, want: 0},
+	}
+	for _, tc := range tests {
+		resp := &MenuResponse{PricesChangeAt: tc.change}
+		if got := resp.MaxAge(time.Minute, now); got != tc.want {
+			t.Errorf("MaxAge with change at %v = %v, want %v", tc.change, got, tc.want)
+		}
+	}
+}
+
+func TestGetMenuServesStale(t *testing.T) {
+	repo := &countingRepo{release: make(chan struct{})}
+	close(repo.release)
+	s := NewService(ServiceConfig{MenuRepo: repo, RegionRepo: noRegions{}, MenuTTL: time.Millisecond, StaleTTL: time.Minute})
+
+	if _, err := s.GetMenu(context.Background(), MenuFilter{}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, err := s.GetMenu(context.Background(), MenuFilter{}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for repo.calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if calls := repo.calls.Load(); calls != 2 {
+		t.Fatalf("expected a background refresh of the stale menu, got %d loads", calls)
+	}
+}
+
+func TestBuildMenuResponseDropsProductsOfHiddenCategories(t *testing.T) {
+	// Category 2 is archived, so GetActiveCategories does not return it.
+	categories := []Category{{ID: 1, Name: "Plov"}}
+	products := []Product{{ID: 1, CategoryID: 1, Name: "Wedding plov"}, {ID: 2, CategoryID: 2, Name: "Somsa"}}
+
+	menu := buildMenuResponse(categories, products)
+	if len(menu.Categories) != 1 || len(menu.Categories[0].Products) != 1 || menu.Categories[0].Products[0].ID != 1 {
+		t.Fatalf("unexpected menu %+v", menu)
+	}
+}
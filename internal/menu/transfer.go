@@ -0,0 +1,433 @@
+package menu
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Transfer formats for menu import and export.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+var (
+	// ErrUnknownFormat is returned for formats other than csv and json.
+	ErrUnknownFormat = errors.New("format must be csv or json")
+	// ErrInvalidFile wraps errors for import files that cannot be read at all.
+	ErrInvalidFile = errors.New("invalid import file")
+)
+
+// transferColumns is the CSV header of exports; imports may order columns freely.
+var transferColumns = []string{"product_id", "category", "name", "description", "price", "old_price", "image_url", "is_active", "sort_order"}
+
+// TransferRow is one product line of a menu import or export. Products are matched
+// by product_id when set, otherwise by category and name.
+type TransferRow struct {
+	ProductID   int64   `json:"product_id,omitempty"`
+	Category    string  `json:"category"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	OldPrice    float64 `json:"old_price"`
+	ImageURL    string  `json:"image_url"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+	SortOrder   int     `json:"sort_order"`
+
+	line int
+}
+
+// RowError describes why a row of an import was rejected.
+type RowError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportChange is a product the import creates, updates or deactivates.
+type ImportChange struct {
+	Line     int      `json:"line,omitempty"`
+	Category string   `json:"category"`
+	Before   *Product `json:"before,omitempty"`
+	After    Product  `json:"after"`
+}
+
+// ImportPlan is the diff between an import file and the stored menu.
+type ImportPlan struct {
+	NewCategories []string       `json:"new_categories"`
+	Created       []ImportChange `json:"created"`
+	Updated       []ImportChange `json:"updated"`
+	Deactivated   []ImportChange `json:"deactivated"`
+	Unchanged     int            `json:"unchanged"`
+	Errors        []RowError     `json:"errors"`
+}
+
+// Empty reports whether applying the plan would change nothing.
+func (p *ImportPlan) Empty() bool {
+	return len(p.NewCategories) == 0 && len(p.Created) == 0 && len(p.Updated) == 0 && len(p.Deactivated) == 0
+}
+
+// ExportRows flattens categories and products into rows ordered like the menu.
+func ExportRows(categories []Category, products []Product) []TransferRow {
+	cats := append([]Category(nil), categories...)
+	sort.SliceStable(cats, func(i, j int) bool {
+		if cats[i].SortOrder != cats[j].SortOrder {
+			return cats[i].SortOrder < cats[j].SortOrder
+		}
+		return cats[i].ID < cats[j].ID
+	})
+	byCategory := make(map[int64][]Product, len(cats))
+	for _, p := range products {
+		byCategory[p.CategoryID] = append(byCategory[p.CategoryID], p)
+	}
+	rows := make([]TransferRow, 0, len(products))
+	for _, cat := range cats {
+		items := byCategory[cat.ID]
+		sort.SliceStable(items, func(i, j int) bool {
+			if items[i].SortOrder != items[j].SortOrder {
+				return items[i].SortOrder < items[j].SortOrder
+			}
+			return items[i].ID < items[j].ID
+		})
+		for _, p := range items {
+			active := p.IsActive
+			rows = append(rows, TransferRow{
+				ProductID:   p.ID,
+				Category:    cat.Name,
+				Name:        p.Name,
+				Description: p.Description,
+				Price:       p.Price,
+				OldPrice:    p.OldPrice,
+				ImageURL:    p.ImageURL,
+				IsActive:    &active,
+				SortOrder:   p.SortOrder,
+			})
+		}
+	}
+	return rows
+}
+
+// WriteRows encodes rows as csv or json.
+func WriteRows(w io.Writer, format string, rows []TransferRow) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(transferColumns); err != nil {
+			return err
+		}
+		for _, r := range rows {
+			active := r.IsActive == nil || *r.IsActive
+			record := []string{
+				strconv.FormatInt(r.ProductID, 10),
+				r.Category,
+				r.Name,
+				r.Description,
+				formatPrice(r.Price),
+				formatPrice(r.OldPrice),
+				r.ImageURL,
+				strconv.FormatBool(active),
+				strconv.Itoa(r.SortOrder),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+// ReadRows decodes an import file. Malformed values are reported per row; the
+// error is only set when the file as a whole cannot be read.
+func ReadRows(r io.Reader, format string) ([]TransferRow, []RowError, error) {
+	switch format {
+	case FormatJSON:
+		var rows []TransferRow
+		if err := json.NewDecoder(r).Decode(&rows); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+		}
+		for i := range rows {
+			rows[i].line = i + 1
+		}
+		return rows, nil, nil
+	case FormatCSV:
+		return readCSV(r)
+	default:
+		return nil, nil, ErrUnknownFormat
+	}
+}
+
+func readCSV(r io.Reader) ([]TransferRow, []RowError, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+	}
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"category", "name", "price"} {
+		if _, ok := index[required]; !ok {
+			return nil, nil, fmt.Errorf("%w: csv header is missing %q", ErrInvalidFile, required)
+		}
+	}
+
+	var (
+		rows []TransferRow
+		errs []RowError
+	)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, fmt.Errorf("%w: %w", ErrInvalidFile, err)
+			}
+			errs = append(errs, RowError{Line: parseErr.StartLine, Message: parseErr.Err.Error()})
+			continue
+		}
+		lineNo, _ := cr.FieldPos(0)
+		get := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row := TransferRow{
+			Category:    get("category"),
+			Name:        get("name"),
+			Description: get("description"),
+			ImageURL:    get("image_url"),
+			line:        lineNo,
+		}
+		bad := false
+		fail := func(field, msg string) {
+			errs = append(errs, RowError{Line: lineNo, Field: field, Message: msg})
+			bad = true
+		}
+		if v := get("product_id"); v != "" && v != "0" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || id < 0 {
+				fail("product_id", "must be a positive integer")
+			}
+			row.ProductID = id
+		}
+		if v := get("price"); v != "" {
+			price, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				fail("price", "must be a number")
+			}
+			row.Price = price
+		}
+		if v := get("old_price"); v != "" {
+			price, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				fail("old_price", "must be a number")
+			}
+			row.OldPrice = price
+		}
+		if v := get("is_active"); v != "" {
+			active, err := strconv.ParseBool(v)
+			if err != nil {
+				fail("is_active", "must be true or false")
+			}
+			row.IsActive = &active
+		}
+		if v := get("sort_order"); v != "" {
+			order, err := strconv.Atoi(v)
+			if err != nil {
+				fail("sort_order", "must be an integer")
+			}
+			row.SortOrder = order
+		}
+		if !bad {
+			rows = append(rows, row)
+		}
+	}
+	return rows, errs, nil
+}
+
+// PlanImport diffs rows against the stored menu. When deactivateMissing is set,
+// live products absent from the file are switched off. Rows with validation
+// errors are reported in plan.Errors and left out of the diff, as are rows that
+// target an archived product or only match an archived category: those must be
+// restored first.
+func PlanImport(rows []TransferRow, categories []Category, products []Product, deactivateMissing bool) *ImportPlan {
+	plan := &ImportPlan{
+		NewCategories: []string{},
+		Created:       []ImportChange{},
+		Updated:       []ImportChange{},
+		Deactivated:   []ImportChange{},
+		Errors:        []RowError{},
+	}
+
+	catByName := make(map[string]Category, len(categories))
+	catByID := make(map[int64]Category, len(categories))
+	for _, cat := range categories {
+		catByID[cat.ID] = cat
+		key := normalizeName(cat.Name)
+		// Prefer live categories over archived namesakes.
+		if existing, ok := catByName[key]; !ok || (existing.ArchivedAt != nil && cat.ArchivedAt == nil) {
+			catByName[key] = cat
+		}
+	}
+	productByID := make(map[int64]Product, len(products))
+	productByKey := make(map[string]Product, len(products))
+	for _, p := range products {
+		productByID[p.ID] = p
+		if p.ArchivedAt == nil {
+			productByKey[productKey(catByID[p.CategoryID].Name, p.Name)] = p
+		}
+	}
+
+	seenRows := make(map[string]int, len(rows))
+	matched := make(map[int64]bool, len(rows))
+	newCategories := map[string]bool{}
+	for _, row := range rows {
+		if errs := validateRow(row); len(errs) > 0 {
+			plan.Errors = append(plan.Errors, errs...)
+			continue
+		}
+		key := productKey(row.Category, row.Name)
+		if first, dup := seenRows[key]; dup {
+			plan.Errors = append(plan.Errors, RowError{Line: row.line, Field: "name", Message: fmt.Sprintf("duplicates line %d", first)})
+			continue
+		}
+		seenRows[key] = row.line
+		cat, catFound := catByName[normalizeName(row.Category)]
+		if catFound && cat.ArchivedAt != nil {
+			plan.Errors = append(plan.Errors, RowError{Line: row.line, Field: "category", Message: "category is archived; restore it first"})
+			continue
+		}
+
+		var current *Product
+		if row.ProductID != 0 {
+			p, ok := productByID[row.ProductID]
+			if !ok {
+				plan.Errors = append(plan.Errors, RowError{Line: row.line, Field: "product_id", Message: "unknown product"})
+				continue
+			}
+			if p.ArchivedAt != nil {
+				plan.Errors = append(plan.Errors, RowError{Line: row.line, Field: "product_id", Message: "product is archived; restore it first"})
+				continue
+			}
+			current = &p
+		} else if p, ok := productByKey[key]; ok {
+			current = &p
+		}
+		if current != nil {
+			if matched[current.ID] {
+				plan.Errors = append(plan.Errors, RowError{Line: row.line, Field: "product_id", Message: "product appears more than once"})
+				continue
+			}
+			matched[current.ID] = true
+		}
+
+		next := Product{
+			Name:        strings.TrimSpace(row.Name),
+			Description: row.Description,
+			Price:       row.Price,
+			OldPrice:    row.OldPrice,
+			ImageURL:    row.ImageURL,
+			IsActive:    row.IsActive == nil || *row.IsActive,
+			SortOrder:   row.SortOrder,
+		}
+		category := strings.TrimSpace(row.Category)
+		if catFound {
+			next.CategoryID = cat.ID
+			category = cat.Name
+		} else if !newCategories[normalizeName(category)] {
+			newCategories[normalizeName(category)] = true
+			plan.NewCategories = append(plan.NewCategories, category)
+		}
+
+		change := ImportChange{Line: row.line, Category: category, After: next}
+		if current == nil {
+			plan.Created = append(plan.Created, change)
+			continue
+		}
+		next.ID = current.ID
+		next.ArchivedAt = current.ArchivedAt
+		// Tags, allergens and nutrition are not part of the file format.
+		next.Tags, next.Allergens = current.Tags, current.Allergens
+		next.Kcal, next.Weight = current.Kcal, current.Weight
+		change.After = next
+		if productChanged(*current, next) {
+			before := *current
+			change.Before = &before
+			plan.Updated = append(plan.Updated, change)
+		} else {
+			plan.Unchanged++
+		}
+	}
+
+	if deactivateMissing {
+		for _, p := range products {
+			if matched[p.ID] || !p.IsActive || p.ArchivedAt != nil {
+				continue
+			}
+			before := p
+			after := p
+			after.IsActive = false
+			plan.Deactivated = append(plan.Deactivated, ImportChange{Category: catByID[p.CategoryID].Name, Before: &before, After: after})
+		}
+	}
+	return plan
+}
+
+func validateRow(row TransferRow) []RowError {
+	var errs []RowError
+	if strings.TrimSpace(row.Category) == "" {
+		errs = append(errs, RowError{Line: row.line, Field: "category", Message: "is required"})
+	}
+	if strings.TrimSpace(row.Name) == "" {
+		errs = append(errs, RowError{Line: row.line, Field: "name", Message: "is required"})
+	}
+	if row.Price <= 0 {
+		errs = append(errs, RowError{Line: row.line, Field: "price", Message: "must be greater than zero"})
+	}
+	if row.OldPrice < 0 {
+		errs = append(errs, RowError{Line: row.line, Field: "old_price", Message: "must not be negative"})
+	}
+	return errs
+}
+
+func productChanged(a, b Product) bool {
+	return a.CategoryID != b.CategoryID ||
+		a.Name != b.Name ||
+		a.Description != b.Description ||
+		formatPrice(a.Price) != formatPrice(b.Price) ||
+		formatPrice(a.OldPrice) != formatPrice(b.OldPrice) ||
+		a.ImageURL != b.ImageURL ||
+		a.IsActive != b.IsActive ||
+		a.SortOrder != b.SortOrder
+}
+
+func normalizeName(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+func productKey(category, name string) string {
+	return normalizeName(category) + "\x00" + normalizeName(name)
+}
+
+func formatPrice(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,122 @@
+package menu
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func transferFixture() ([]Category, []Product) {
+	categories := []Category{
+		{ID: 1, Name: "Шашлык", SortOrder: 1, IsActive: true},
+		{ID: 2, Name: "Напитки", SortOrder: 2, IsActive: true},
+	}
+	products := []Product{
+		{ID: 10, CategoryID: 1, Name: "Баранина", Price: 30000, IsActive: true, SortOrder: 1},
+		{ID: 11, CategoryID: 1, Name: "Курица", Price: 22000, IsActive: true, SortOrder: 2},
+		{ID: 20, CategoryID: 2, Name: "Чай", Price: 5000, IsActive: true, SortOrder: 1},
+	}
+	return categories, products
+}
+
+func TestTransferCSVRoundTrip(t *testing.T) {
+	categories, products := transferFixture()
+	var buf bytes.Buffer
+	if err := WriteRows(&buf, FormatCSV, ExportRows(categories, products)); err != nil {
+		t.Fatal(err)
+	}
+	rows, errs, err := ReadRows(&buf, FormatCSV)
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("unexpected errors %v %v", err, errs)
+	}
+	if len(rows) != 3 || rows[0].Name != "Баранина" || rows[2].Category != "Напитки" {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+
+	plan := PlanImport(rows, categories, products, true)
+	if !plan.Empty() || plan.Unchanged != 3 || len(plan.Errors) != 0 {
+		t.Fatalf("re-importing an export must be a no-op, got %+v", plan)
+	}
+}
+
+func TestPlanImportDiff(t *testing.T) {
+	categories, products := transferFixture()
+	input := strings.Join([]string{
+		"category,name,price,is_active",
+		"шашлык,Баранина,32000,true",
+		"Шашлык,Люля,25000,",
+		"Салаты,Ачичук,12000,true",
+	}, "\n")
+	rows, errs, err := ReadRows(strings.NewReader(input), FormatCSV)
+	if err != nil || len(errs) != 0 {
+		t.Fatalf("unexpected errors %v %v", err, errs)
+	}
+
+	plan := PlanImport(rows, categories, products, true)
+	if len(plan.Updated) != 1 || plan.Updated[0].After.ID != 10 || plan.Updated[0].Before.Price != 30000 {
+		t.Fatalf("expected price update of product 10, got %+v", plan.Updated)
+	}
+	if len(plan.Created) != 2 || plan.Created[0].After.CategoryID != 1 || plan.Created[1].After.CategoryID != 0 {
+		t.Fatalf("unexpected creates %+v", plan.Created)
+	}
+	if len(plan.NewCategories) != 1 || plan.NewCategories[0] != "Салаты" {
+		t.Fatalf("unexpected new categories %v", plan.NewCategories)
+	}
+	if len(plan.Deactivated) != 2 {
+		t.Fatalf("expected Курица and Чай to be deactivated, got %+v", plan.Deactivated)
+	}
+
+	if plan := PlanImport(rows, categories, products, false); len(plan.Deactivated) != 0 {
+		t.Fatalf("missing products must be kept without deactivateMissing, got %+v", plan.Deactivated)
+	}
+}
+
+func TestPlanImportRowErrors(t *testing.T) {
+	categories, products := transferFixture()
+	input := `[
+		{"category": "Шашлык", "name": "", "price": 1000},
+		{"category": "Шашлык", "name": "Люля", "price": 0},
+		{"product_id": 99, "category": "Шашлык", "name": "Призрак", "price": 1000},
+		{"category": "Напитки", "name": "Компот", "price": 4000},
+		{"category": "напитки", "name": "компот", "price": 4500}
+	]`
+	rows, _, err := ReadRows(strings.NewReader(input), FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := PlanImport(rows, categories, products, false)
+	lines := map[int]string{}
+	for _, e := range plan.Errors {
+		lines[e.Line] = e.Field
+	}
+	want := map[int]string{1: "name", 2: "price", 3: "product_id", 5: "name"}
+	for line, field := range want {
+		if lines[line] != field {
+			t.Fatalf("expected error on line %d field %s, got %+v", line, field, plan.Errors)
+		}
+	}
+	if len(plan.Created) != 1 || plan.Created[0].After.Name != "Компот" {
+		t.Fatalf("valid rows must still be planned, got %+v", plan.Created)
+	}
+
+	archivedAt := time.Now()
+	categories = append(categories, Category{ID: 3, Name: "Сезонное", ArchivedAt: &archivedAt})
+	products = append(products, Product{ID: 12, CategoryID: 1, Name: "Утка", Price: 40000, ArchivedAt: &archivedAt})
+	rows, _, err = ReadRows(strings.NewReader(`[
+		{"product_id": 12, "category": "Шашлык", "name": "Утка", "price": 41000},
+		{"category": "сезонное", "name": "Окрошка", "price": 9000}
+	]`), FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan = PlanImport(rows, categories, products, false)
+	if len(plan.Errors) != 2 || plan.Errors[0].Field != "product_id" || plan.Errors[1].Field != "category" || !plan.Empty() {
+		t.Fatalf("rows targeting archived entries must be rejected, got %+v", plan)
+	}
+
+	_, errs, err := ReadRows(strings.NewReader("category,name,price\nШашлык,Люля,abc\n"), FormatCSV)
+	if err != nil || len(errs) != 1 || errs[0].Line != 2 || errs[0].Field != "price" {
+		t.Fatalf("expected price error on line 2, got %v %v", err, errs)
+	}
+}
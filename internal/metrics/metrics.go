@@ -12,6 +12,10 @@ type Metrics struct {
 	RequestDuration *prometheus.HistogramVec
 	RequestTotal    *prometheus.CounterVec
 	OrdersCreated   prometheus.Counter
+	AuthFailures    *prometheus.CounterVec
+	AuthLockouts    *prometheus.CounterVec
+	AuthBlocked     *prometheus.CounterVec
+	CacheLookups    *prometheus.CounterVec
 }
 
 // New constructs and registers Prometheus metrics.
@@ -33,9 +37,29 @@ func New() *Metrics {
 			Name:      "order_created_total",
 			Help:      "Orders created",
 		}),
+		AuthFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kabobfood",
+			Name:      "auth_failures_total",
+			Help:      "Failed login or registration attempts",
+		}, []string{"scope", "kind"}),
+		AuthLockouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kabobfood",
+			Name:      "auth_lockouts_total",
+			Help:      "Temporary lockouts after repeated failures",
+		}, []string{"scope", "kind"}),
+		AuthBlocked: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kabobfood",
+			Name:      "auth_blocked_total",
+			Help:      "Attempts rejected because of a delay or lockout",
+		}, []string{"scope"}),
+		CacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "kabobfood",
+			Name:      "cache_lookups_total",
+			Help:      "Cache lookups by key, serving tier (local, redis, none) and result (hit, stale, miss)",
+		}, []string{"cache", "tier", "result"}),
 	}
 
-	prometheus.MustRegister(m.RequestDuration, m.RequestTotal, m.OrdersCreated)
+	prometheus.MustRegister(m.RequestDuration, m.RequestTotal, m.OrdersCreated, m.AuthFailures, m.AuthLockouts, m.AuthBlocked, m.CacheLookups)
 	return m
 }
 
@@ -68,6 +68,14 @@ func (n *TelegramNotifier) NotifyStatusChanged(ctx context.Context, info OrderIn
 	}
 }
 
+// NotifyAdmin sends a free-form message to the admin chat.
+func (n *TelegramNotifier) NotifyAdmin(ctx context.Context, message string) {
+	if n.botToken == "" || n.adminChatID == "" {
+		return
+	}
+	n.sendMessage(ctx, n.adminChatID, message)
+}
+
 func (n *TelegramNotifier) sendMessage(ctx context.Context, chatID, message string) {
 	if chatID == "" || n.botToken == "" {
 		return
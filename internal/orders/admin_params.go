@@ -2,11 +2,14 @@ package orders
 
 import "time"
 
-// AdminListParams defines filters for admin order listing.
+// AdminListParams defines filters for admin order listing. Orders come newest
+// first unless OldestFirst is set.
 type AdminListParams struct {
-	Status string
-	From   *time.Time
-	To     *time.Time
-	Limit  int
-	Offset int
+	Status      string
+	Statuses    []string
+	From        *time.Time
+	To          *time.Time
+	Limit       int
+	Offset      int
+	OldestFirst bool
 }
@@ -0,0 +1,98 @@
+package orders
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rashidmailru/kabobfood/internal/products"
+)
+
+var errInvalidBundle = errors.New("invalid bundle choice")
+
+// resolveComponents matches choices against the slots of a bundle and returns
+// the components to snapshot. Fixed slots fill themselves; choice groups need
+// a choice. catalog must hold every option product.
+func resolveComponents(slots []products.BundleSlot, choices []ChoiceInput, catalog map[int64]products.Product) ([]OrderComponent, error) {
+	known := make(map[int64]bool, len(slots))
+	for _, slot := range slots {
+		known[slot.ID] = true
+	}
+	chosen := make(map[int64]int64, len(choices))
+	for _, ch := range choices {
+		if !known[ch.SlotID] {
+			return nil, fmt.Errorf("%w: unknown slot %d", errInvalidBundle, ch.SlotID)
+		}
+		if _, dup := chosen[ch.SlotID]; dup {
+			return nil, fmt.Errorf("%w: slot %d is chosen twice", errInvalidBundle, ch.SlotID)
+		}
+		chosen[ch.SlotID] = ch.ProductID
+	}
+
+	components := make([]OrderComponent, 0, len(slots))
+	for _, slot := range slots {
+		productID, ok := chosen[slot.ID]
+		if !ok {
+			if len(slot.Options) != 1 {
+				return nil, fmt.Errorf("%w: choose an option for %q", errInvalidBundle, slot.Name)
+			}
+			productID = slot.Options[0].ProductID
+		}
+		var option *products.BundleOption
+		for i := range slot.Options {
+			if slot.Options[i].ProductID == productID {
+				option = &slot.Options[i]
+				break
+			}
+		}
+		if option == nil {
+			return nil, fmt.Errorf("%w: product %d is not an option of %q", errInvalidBundle, productID, slot.Name)
+		}
+		product, ok := catalog[productID]
+		if !ok || !product.IsActive {
+			return nil, fmt.Errorf("%w: option %d of %q is not available", errInvalidBundle, productID, slot.Name)
+		}
+		components = append(components, OrderComponent{
+			SlotID:      slot.ID,
+			SlotName:    slot.Name,
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			Qty:         slot.Qty,
+			ExtraPrice:  option.ExtraPrice,
+		})
+	}
+	return components, nil
+}
+
+// itemKey identifies order lines that can be merged: the same product with the
+// same bundle choices.
+func itemKey(item ItemInput) string {
+	choices := append([]ChoiceInput(nil), item.Choices...)
+	sort.Slice(choices, func(i, j int) bool { return choices[i].SlotID < choices[j].SlotID })
+	var b strings.Builder
+	b.WriteString(strconv.FormatInt(item.ProductID, 10))
+	for _, ch := range choices {
+		fmt.Fprintf(&b, ";%d=%d", ch.SlotID, ch.ProductID)
+	}
+	return b.String()
+}
+
+// stockDemand sums the units items take from each product, bundle components
+// included. ids are sorted so rows are always locked in the same order.
+func stockDemand(items []OrderItem) ([]int64, map[int64]int32) {
+	qty := make(map[int64]int32, len(items))
+	for _, item := range items {
+		qty[item.ProductID] += item.Qty
+		for _, c := range item.Components {
+			qty[c.ProductID] += item.Qty * c.Qty
+		}
+	}
+	ids := make([]int64, 0, len(qty))
+	for id := range qty {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, qty
+}
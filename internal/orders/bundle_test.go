@@ -0,0 +1,69 @@
+package orders
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rashidmailru/kabobfood/internal/products"
+)
+
+func comboFixture() ([]products.BundleSlot, map[int64]products.Product) {
+	slots := []products.BundleSlot{
+		{ID: 1, Name: "Шашлык", Qty: 2, Options: []products.BundleOption{{ProductID: 10}}},
+		{ID: 2, Name: "Напиток", Qty: 1, Options: []products.BundleOption{{ProductID: 20}, {ProductID: 21, ExtraPrice: 3000}}},
+	}
+	catalog := map[int64]products.Product{
+		10: {ID: 10, Name: "Кебаб", IsActive: true},
+		20: {ID: 20, Name: "Чай", IsActive: true},
+		21: {ID: 21, Name: "Лимонад", IsActive: true},
+	}
+	return slots, catalog
+}
+
+func TestResolveComponents(t *testing.T) {
+	slots, catalog := comboFixture()
+
+	components, err := resolveComponents(slots, []ChoiceInput{{SlotID: 2, ProductID: 21}}, catalog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(components) != 2 || components[0].ProductID != 10 || components[0].Qty != 2 || components[1].ProductName != "Лимонад" {
+		t.Fatalf("unexpected components %+v", components)
+	}
+	if extra := (OrderItem{Components: components}).ExtraPrice(); extra != 3000 {
+		t.Fatalf("expected 3000 extra, got %v", extra)
+	}
+
+	inactive := map[int64]products.Product{10: catalog[10], 20: {ID: 20, Name: "Чай"}, 21: catalog[21]}
+	for name, tc := range map[string]struct {
+		choices []ChoiceInput
+		catalog map[int64]products.Product
+	}{
+		"missing choice":  {nil, catalog},
+		"foreign option":  {[]ChoiceInput{{SlotID: 2, ProductID: 10}}, catalog},
+		"unknown slot":    {[]ChoiceInput{{SlotID: 2, ProductID: 20}, {SlotID: 9, ProductID: 20}}, catalog},
+		"repeated slot":   {[]ChoiceInput{{SlotID: 2, ProductID: 20}, {SlotID: 2, ProductID: 21}}, catalog},
+		"inactive option": {[]ChoiceInput{{SlotID: 2, ProductID: 20}}, inactive},
+	} {
+		if _, err := resolveComponents(slots, tc.choices, tc.catalog); !errors.Is(err, errInvalidBundle) {
+			t.Errorf("%s: expected errInvalidBundle, got %v", name, err)
+		}
+	}
+}
+
+func TestItemKeyAndStockDemand(t *testing.T) {
+	a := ItemInput{ProductID: 5, Choices: []ChoiceInput{{SlotID: 2, ProductID: 21}, {SlotID: 1, ProductID: 10}}}
+	b := ItemInput{ProductID: 5, Choices: []ChoiceInput{{SlotID: 1, ProductID: 10}, {SlotID: 2, ProductID: 21}}}
+	c := ItemInput{ProductID: 5, Choices: []ChoiceInput{{SlotID: 1, ProductID: 10}, {SlotID: 2, ProductID: 20}}}
+	if itemKey(a) != itemKey(b) || itemKey(a) == itemKey(c) {
+		t.Fatalf("unexpected keys %q %q %q", itemKey(a), itemKey(b), itemKey(c))
+	}
+
+	ids, qty := stockDemand([]OrderItem{
+		{ProductID: 5, Qty: 3, Components: []OrderComponent{{ProductID: 10, Qty: 2}, {ProductID: 20, Qty: 1}}},
+		{ProductID: 10, Qty: 1},
+	})
+	if len(ids) != 3 || ids[0] != 5 || ids[1] != 10 || qty[5] != 3 || qty[10] != 7 || qty[20] != 3 {
+		t.Fatalf("unexpected demand %v %v", ids, qty)
+	}
+}
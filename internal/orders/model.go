@@ -2,6 +2,17 @@ package orders
 
 import "time"
 
+// Order statuses.
+const (
+	StatusAwaitingPayment = "awaiting_payment"
+	StatusNew             = "new"
+	StatusAccepted        = "accepted"
+	StatusCooking         = "cooking"
+	StatusDelivery        = "delivery"
+	StatusDelivered       = "delivered"
+	StatusCanceled        = "canceled"
+)
+
 // Order represents persisted order with items.
 type Order struct {
 	ID              int64       `json:"id"`
@@ -23,21 +34,52 @@ type Order struct {
 	Items           []OrderItem `json:"items"`
 }
 
-// OrderItem represents product snapshot inside order.
+// OrderItem represents product snapshot inside order. For bundles Price
+// includes the extra prices of the chosen Components.
 type OrderItem struct {
-	ID          int64   `json:"id"`
-	OrderID     int64   `json:"order_id"`
+	ID          int64            `json:"id"`
+	OrderID     int64            `json:"order_id"`
+	ProductID   int64            `json:"product_id"`
+	ProductName string           `json:"product_name"`
+	Qty         int32            `json:"qty"`
+	Price       float64          `json:"price"`
+	Total       float64          `json:"total"`
+	Components  []OrderComponent `json:"components,omitempty"`
+	DoneAt      *time.Time       `json:"done_at,omitempty"`
+}
+
+// OrderComponent snapshots the product that filled a bundle slot. Qty is per
+// bundle, so an item needs Qty times its own Qty units.
+type OrderComponent struct {
+	SlotID      int64   `json:"slot_id"`
+	SlotName    string  `json:"slot_name"`
 	ProductID   int64   `json:"product_id"`
 	ProductName string  `json:"product_name"`
 	Qty         int32   `json:"qty"`
-	Price       float64 `json:"price"`
-	Total       float64 `json:"total"`
+	ExtraPrice  float64 `json:"extra_price"`
 }
 
-// ItemInput from API request.
+// ExtraPrice sums the surcharges of the chosen components.
+func (i OrderItem) ExtraPrice() float64 {
+	var extra float64
+	for _, c := range i.Components {
+		extra += c.ExtraPrice
+	}
+	return extra
+}
+
+// ItemInput from API request. Choices pick the options of bundle slots; fixed
+// slots may be omitted.
 type ItemInput struct {
+	ProductID int64         `json:"product_id"`
+	Qty       int32         `json:"qty"`
+	Choices   []ChoiceInput `json:"choices,omitempty"`
+}
+
+// ChoiceInput selects ProductID for a bundle slot.
+type ChoiceInput struct {
+	SlotID    int64 `json:"slot_id"`
 	ProductID int64 `json:"product_id"`
-	Qty       int32 `json:"qty"`
 }
 
 // CreateOrderInput from user request.
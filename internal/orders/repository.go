@@ -8,23 +8,39 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/rashidmailru/kabobfood/internal/cache"
+	"github.com/rashidmailru/kabobfood/internal/menu"
 )
 
 // Repository persists orders and items.
 type Repository struct {
-	pool *pgxpool.Pool
+	pool  *pgxpool.Pool
+	cache *cache.Store
 }
 
-// NewRepository creates repository.
-func NewRepository(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+// NewRepository creates repository. store may be nil; with it the cached menu
+// is dropped whenever an order sells out a product or returns stock.
+func NewRepository(pool *pgxpool.Pool, store *cache.Store) *Repository {
+	return &Repository{pool: pool, cache: store}
 }
 
 var (
 	errNilPool       = errors.New("orders repository: nil pool")
 	errOrderNotFound = errors.New("order not found")
+	// ErrOrderCanceled is returned when a canceled order is moved to another
+	// status: its stock was already returned and its payment refunded.
+	ErrOrderCanceled = errors.New("canceled order cannot change status")
 )
 
+// invalidateMenu drops cached menu and search responses after availability changed.
+func (r *Repository) invalidateMenu(ctx context.Context) {
+	if r.cache == nil {
+		return
+	}
+	r.cache.Delete(ctx, menu.MenuCacheKey, menu.SearchCacheKey)
+}
+
 // CreateParams contains all fields to insert order + items.
 type CreateParams struct {
 	ClientRequestID string
@@ -43,7 +59,8 @@ type CreateParams struct {
 	Items           []OrderItem
 }
 
-// Create inserts order and items, returns complete record.
+// Create inserts order and items and decrements stock of counted products in one
+// transaction; it returns a *StockError when an item ran out or is stop-listed.
 func (r *Repository) Create(ctx context.Context, params CreateParams) (*Order, error) {
 	if r.pool == nil {
 		return nil, errNilPool
@@ -109,13 +126,24 @@ RETURNING id, client_request_id, user_id, COALESCE(address_id,0), type, payment_
 		return nil, err
 	}
 
+	// Stock is reserved after the order row so idempotent retries never count twice.
+	soldOut, err := reserveStock(ctx, tx, params.Items)
+	if err != nil {
+		return nil, err
+	}
+
 	batch := &pgx.Batch{}
 	for _, item := range params.Items {
+		// Regular items store NULL components.
+		var components interface{}
+		if len(item.Components) > 0 {
+			components = item.Components
+		}
 		batch.Queue(`
-INSERT INTO order_items (order_id, product_id, product_name, qty, price, total)
-VALUES ($1,$2,$3,$4,$5,$6)
+INSERT INTO order_items (order_id, product_id, product_name, qty, price, total, components)
+VALUES ($1,$2,$3,$4,$5,$6,$7)
 RETURNING id;
-`, order.ID, item.ProductID, item.ProductName, item.Qty, item.Price, item.Total)
+`, order.ID, item.ProductID, item.ProductName, item.Qty, item.Price, item.Total, components)
 	}
 
 	br := tx.SendBatch(ctx, batch)
@@ -137,6 +165,9 @@ RETURNING id;
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
+	if soldOut {
+		r.invalidateMenu(ctx)
+	}
 
 	return order, nil
 }
@@ -162,6 +193,14 @@ func (r *Repository) GetByID(ctx context.Context, id, userID int64) (*Order, err
 	return r.fetchOrder(ctx, `id = $1 AND user_id = $2`, id, userID)
 }
 
+// GetAdminByID fetches order by id regardless of owner.
+func (r *Repository) GetAdminByID(ctx context.Context, id int64) (*Order, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	return r.fetchOrder(ctx, `id = $1`, id)
+}
+
 func (r *Repository) fetchOrder(ctx context.Context, where string, args ...interface{}) (*Order, error) {
 	query := `
 SELECT id, client_request_id, user_id, COALESCE(address_id,0), type, payment_method, status, region_id, delivery_price, items_total, total_price, COALESCE(comment,''), customer_name, customer_phone, created_at, updated_at
@@ -184,17 +223,67 @@ LIMIT 1;
 	return &order, nil
 }
 
-// UpdateStatus updates order status and returns updated order.
+// UpdateStatus updates order status and returns updated order. Canceling an
+// order returns its items to stock; a canceled order keeps its status and
+// other statuses return ErrOrderCanceled.
 func (r *Repository) UpdateStatus(ctx context.Context, orderID int64, status string) (*Order, error) {
 	if r.pool == nil {
 		return nil, errNilPool
 	}
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous string
+	if err := tx.QueryRow(ctx, `SELECT status FROM orders WHERE id=$1 FOR UPDATE;`, orderID).Scan(&previous); err != nil {
+		return nil, err
+	}
+	if previous == StatusCanceled && status != StatusCanceled {
+		return nil, ErrOrderCanceled
+	}
 	const query = `
 UPDATE orders SET status=$1, updated_at=NOW()
 WHERE id=$2
 RETURNING id, client_request_id, user_id, COALESCE(address_id,0), type, payment_method, status, region_id, delivery_price, items_total, total_price, COALESCE(comment,''), customer_name, customer_phone, created_at, updated_at;
 `
-	row := r.pool.QueryRow(ctx, query, status, orderID)
+	row := tx.QueryRow(ctx, query, status, orderID)
+	var order Order
+	if err := row.Scan(&order.ID, &order.ClientRequestID, &order.UserID, &order.AddressID, &order.Type, &order.PaymentMethod, &order.Status, &order.RegionID, &order.DeliveryPrice, &order.ItemsTotal, &order.TotalPrice, &order.Comment, &order.CustomerName, &order.CustomerPhone, &order.CreatedAt, &order.UpdatedAt); err != nil {
+		return nil, err
+	}
+	restocked := false
+	if status == StatusCanceled && previous != StatusCanceled {
+		if restocked, err = releaseStock(ctx, tx, orderID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	if restocked {
+		r.invalidateMenu(ctx)
+	}
+	items, err := r.fetchItems(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	order.Items = items
+	return &order, nil
+}
+
+// TransitionStatus moves order to status only if it is currently in from; returns pgx.ErrNoRows otherwise.
+func (r *Repository) TransitionStatus(ctx context.Context, orderID int64, from, to string) (*Order, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	const query = `
+UPDATE orders SET status=$1, updated_at=NOW()
+WHERE id=$2 AND status=$3
+RETURNING id, client_request_id, user_id, COALESCE(address_id,0), type, payment_method, status, region_id, delivery_price, items_total, total_price, COALESCE(comment,''), customer_name, customer_phone, created_at, updated_at;
+`
+	row := r.pool.QueryRow(ctx, query, to, orderID, from)
 	var order Order
 	if err := row.Scan(&order.ID, &order.ClientRequestID, &order.UserID, &order.AddressID, &order.Type, &order.PaymentMethod, &order.Status, &order.RegionID, &order.DeliveryPrice, &order.ItemsTotal, &order.TotalPrice, &order.Comment, &order.CustomerName, &order.CustomerPhone, &order.CreatedAt, &order.UpdatedAt); err != nil {
 		return nil, err
@@ -274,6 +363,11 @@ WHERE 1=1`
 		args = append(args, params.Status)
 		idx++
 	}
+	if len(params.Statuses) > 0 {
+		query += fmt.Sprintf(" AND status = ANY($%d)", idx)
+		args = append(args, params.Statuses)
+		idx++
+	}
 	if params.From != nil {
 		query += fmt.Sprintf(" AND created_at >= $%d", idx)
 		args = append(args, *params.From)
@@ -284,7 +378,11 @@ WHERE 1=1`
 		args = append(args, *params.To)
 		idx++
 	}
-	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", idx, idx+1)
+	order := "DESC"
+	if params.OldestFirst {
+		order = "ASC"
+	}
+	query += fmt.Sprintf(" ORDER BY created_at %s LIMIT $%d OFFSET $%d", order, idx, idx+1)
 	args = append(args, limit, offset)
 
 	rows, err := r.pool.Query(ctx, query, args...)
@@ -316,11 +414,32 @@ WHERE 1=1`
 	return ordersList, nil
 }
 
+// MarkItemDone sets or clears the kitchen done flag on an item while the order is in one of statuses.
+func (r *Repository) MarkItemDone(ctx context.Context, orderID, itemID int64, done bool, statuses []string) (*OrderItem, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	const query = `
+UPDATE order_items oi
+SET done_at = CASE WHEN $3 THEN COALESCE(oi.done_at, NOW()) ELSE NULL END
+FROM orders o
+WHERE oi.id = $1 AND oi.order_id = $2 AND o.id = oi.order_id AND o.status = ANY($4)
+RETURNING oi.id, oi.order_id, oi.product_id, oi.product_name, oi.qty, oi.price, oi.total, oi.components, oi.done_at;
+`
+	row := r.pool.QueryRow(ctx, query, itemID, orderID, done, statuses)
+	var oi OrderItem
+	if err := row.Scan(&oi.ID, &oi.OrderID, &oi.ProductID, &oi.ProductName, &oi.Qty, &oi.Price, &oi.Total, &oi.Components, &oi.DoneAt); err != nil {
+		return nil, err
+	}
+	return &oi, nil
+}
+
 func (r *Repository) fetchItems(ctx context.Context, orderID int64) ([]OrderItem, error) {
 	const query = `
-SELECT id, order_id, product_id, product_name, qty, price, total
+SELECT id, order_id, product_id, product_name, qty, price, total, components, done_at
 FROM order_items
-WHERE order_id = $1;
+WHERE order_id = $1
+ORDER BY id;
 `
 
 	rows, err := r.pool.Query(ctx, query, orderID)
@@ -332,7 +451,7 @@ WHERE order_id = $1;
 	var items []OrderItem
 	for rows.Next() {
 		var oi OrderItem
-		if err := rows.Scan(&oi.ID, &oi.OrderID, &oi.ProductID, &oi.ProductName, &oi.Qty, &oi.Price, &oi.Total); err != nil {
+		if err := rows.Scan(&oi.ID, &oi.OrderID, &oi.ProductID, &oi.ProductName, &oi.Qty, &oi.Price, &oi.Total, &oi.Components, &oi.DoneAt); err != nil {
 			return nil, err
 		}
 		items = append(items, oi)
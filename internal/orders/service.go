@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/rashidmailru/kabobfood/internal/addresses"
+	"github.com/rashidmailru/kabobfood/internal/audit"
 	"github.com/rashidmailru/kabobfood/internal/metrics"
 	"github.com/rashidmailru/kabobfood/internal/notifications"
 	"github.com/rashidmailru/kabobfood/internal/products"
@@ -16,20 +18,42 @@ import (
 	"github.com/rashidmailru/kabobfood/internal/users"
 )
 
+// PaymentGateway connects the order workflow with payment processing.
+type PaymentGateway interface {
+	// RequiresPrepayment validates method and reports whether the order must be paid before cooking.
+	RequiresPrepayment(method string) (bool, error)
+	// OrderCreated registers a payment for a freshly created order.
+	OrderCreated(ctx context.Context, order *Order) error
+	// OrderStatusChanged reacts to operator status updates.
+	OrderStatusChanged(ctx context.Context, order *Order) error
+}
+
+// productSource is what order creation reads from products.Repository.
+type productSource interface {
+	GetActiveByIDs(ctx context.Context, ids []int64) (map[int64]products.Product, error)
+	GetBundleSlots(ctx context.Context, ids []int64) (map[int64][]products.BundleSlot, error)
+}
+
+// regionSource is what order creation reads from regions.Repository.
+type regionSource interface {
+	GetByID(ctx context.Context, id int64) (*regions.Region, error)
+}
+
 // Service handles order workflows.
 type Service struct {
 	repo        *Repository
-	productRepo *products.Repository
+	productRepo productSource
 	addressRepo *addresses.Repository
-	regionRepo  *regions.Repository
+	regionRepo  regionSource
 	userRepo    *users.Repository
 	notifier    *notifications.TelegramNotifier
 	metrics     *metrics.Metrics
+	payments    PaymentGateway
 }
 
-// NewService builds Service.
-func NewService(repo *Repository, productRepo *products.Repository, addressRepo *addresses.Repository, regionRepo *regions.Repository, userRepo *users.Repository, notifier *notifications.TelegramNotifier, m *metrics.Metrics) *Service {
-	return &Service{repo: repo, productRepo: productRepo, addressRepo: addressRepo, regionRepo: regionRepo, userRepo: userRepo, notifier: notifier, metrics: m}
+// NewService builds Service; payments is optional.
+func NewService(repo *Repository, productRepo *products.Repository, addressRepo *addresses.Repository, regionRepo *regions.Repository, userRepo *users.Repository, notifier *notifications.TelegramNotifier, m *metrics.Metrics, payments PaymentGateway) *Service {
+	return &Service{repo: repo, productRepo: productRepo, addressRepo: addressRepo, regionRepo: regionRepo, userRepo: userRepo, notifier: notifier, metrics: m, payments: payments}
 }
 
 var (
@@ -59,13 +83,23 @@ func (s *Service) Create(ctx context.Context, userID int64, input CreateOrderInp
 	if strings.TrimSpace(input.PaymentMethod) == "" {
 		return nil, errInvalidPayment
 	}
+	status := StatusNew
+	if s.payments != nil {
+		prepaid, err := s.payments.RequiresPrepayment(input.PaymentMethod)
+		if err != nil {
+			return nil, err
+		}
+		if prepaid {
+			status = StatusAwaitingPayment
+		}
+	}
 
 	if orderType == "delivery" && input.AddressID == 0 {
 		return nil, errors.New("address is required for delivery")
 	}
 
 	region, err := s.regionRepo.GetByID(ctx, input.RegionID)
-	if err != nil || !region.IsActive {
+	if err != nil || !region.IsActive || region.ArchivedAt != nil {
 		return nil, errInvalidRegion
 	}
 
@@ -78,17 +112,36 @@ func (s *Service) Create(ctx context.Context, userID int64, input CreateOrderInp
 		addressID = &addr.ID
 	}
 
-	mergedItems := make(map[int64]int32)
+	// Lines of the same product and bundle choices are merged.
+	var merged []ItemInput
+	index := make(map[string]int, len(input.Items))
 	for _, item := range input.Items {
 		if item.Qty <= 0 {
 			return nil, errInvalidQuantity
 		}
-		mergedItems[item.ProductID] += item.Qty
+		key := itemKey(item)
+		if i, ok := index[key]; ok {
+			merged[i].Qty += item.Qty
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, item)
 	}
 
-	productIDs := make([]int64, 0, len(mergedItems))
-	for id := range mergedItems {
-		productIDs = append(productIDs, id)
+	productIDs := make([]int64, 0, len(merged))
+	for _, item := range merged {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	bundles, err := s.productRepo.GetBundleSlots(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, slots := range bundles {
+		for _, slot := range slots {
+			for _, opt := range slot.Options {
+				productIDs = append(productIDs, opt.ProductID)
+			}
+		}
 	}
 
 	dbProducts, err := s.productRepo.GetActiveByIDs(ctx, productIDs)
@@ -96,22 +149,39 @@ func (s *Service) Create(ctx context.Context, userID int64, input CreateOrderInp
 		return nil, err
 	}
 
-	orderItems := make([]OrderItem, 0, len(mergedItems))
+	orderItems := make([]OrderItem, 0, len(merged))
 	var itemsTotal float64
-	for productID, qty := range mergedItems {
-		product, ok := dbProducts[productID]
+	for _, item := range merged {
+		product, ok := dbProducts[item.ProductID]
 		if !ok || !product.IsActive {
 			return nil, errProductNotFound
 		}
-		total := product.Price * float64(qty)
-		itemsTotal += total
-		orderItems = append(orderItems, OrderItem{
+		orderItem := OrderItem{
 			ProductID:   product.ID,
 			ProductName: product.Name,
-			Qty:         qty,
-			Price:       product.Price,
-			Total:       total,
-		})
+			Qty:         item.Qty,
+		}
+		if slots, isBundle := bundles[product.ID]; isBundle {
+			if orderItem.Components, err = resolveComponents(slots, item.Choices, dbProducts); err != nil {
+				return nil, err
+			}
+		} else if len(item.Choices) > 0 {
+			return nil, fmt.Errorf("%w: %s is not a bundle", errInvalidBundle, product.Name)
+		}
+		orderItem.Price = product.Price + orderItem.ExtraPrice()
+		orderItem.Total = orderItem.Price * float64(item.Qty)
+		itemsTotal += orderItem.Total
+		orderItems = append(orderItems, orderItem)
+	}
+
+	// Fails early; the repository re-checks under a row lock.
+	now := time.Now()
+	ids, demand := stockDemand(orderItems)
+	for _, id := range ids {
+		p := dbProducts[id]
+		if err := checkStock(p.ID, p.Name, p.Stock, p.UnavailableUntil, demand[id], now); err != nil {
+			return nil, err
+		}
 	}
 
 	deliveryPrice := 0.0
@@ -127,7 +197,7 @@ func (s *Service) Create(ctx context.Context, userID int64, input CreateOrderInp
 		AddressID:       addressID,
 		Type:            orderType,
 		PaymentMethod:   input.PaymentMethod,
-		Status:          "new",
+		Status:          status,
 		RegionID:        region.ID,
 		DeliveryPrice:   deliveryPrice,
 		ItemsTotal:      itemsTotal,
@@ -142,10 +212,16 @@ func (s *Service) Create(ctx context.Context, userID int64, input CreateOrderInp
 	if err != nil {
 		return nil, err
 	}
+	if s.payments != nil {
+		if err := s.payments.OrderCreated(ctx, order); err != nil {
+			return nil, err
+		}
+	}
 	if s.metrics != nil {
 		s.metrics.OrdersCreated.Inc()
 	}
-	if s.notifier != nil && s.userRepo != nil {
+	// Prepaid orders are announced once the payment succeeds.
+	if s.notifier != nil && s.userRepo != nil && order.Status != StatusAwaitingPayment {
 		if user, err := s.userRepo.GetByID(ctx, userID); err == nil && user.TelegramID != 0 {
 			info := notifications.OrderInfo{
 				OrderID:       order.ID,
@@ -171,16 +247,41 @@ func (s *Service) Get(ctx context.Context, userID, orderID int64) (*Order, error
 	return s.repo.GetByID(ctx, orderID, userID)
 }
 
+// OrderPrinter prints accepted orders, e.g. on a kitchen printer.
+type OrderPrinter interface {
+	PrintOrder(ctx context.Context, order *Order) error
+}
+
+// printTimeout bounds background printing started by status changes.
+const printTimeout = 15 * time.Second
+
+// errAwaitingPayment blocks kitchen statuses until an online payment succeeds.
+var errAwaitingPayment = errors.New("order is awaiting online payment")
+
+// checkStatusChange validates an operator moving an order from one status to another.
+func checkStatusChange(from, to string) error {
+	switch {
+	case from == StatusCanceled && to != StatusCanceled:
+		return ErrOrderCanceled
+	case from == StatusAwaitingPayment && to != StatusCanceled:
+		return errAwaitingPayment
+	}
+	return nil
+}
+
 // AdminService exposes operations for operators.
 type AdminService struct {
 	repo     *Repository
 	userRepo *users.Repository
 	notifier *notifications.TelegramNotifier
+	printer  OrderPrinter
+	payments PaymentGateway
+	audit    *audit.Service
 }
 
-// NewAdminService builds admin service.
-func NewAdminService(repo *Repository, userRepo *users.Repository, notifier *notifications.TelegramNotifier) *AdminService {
-	return &AdminService{repo: repo, userRepo: userRepo, notifier: notifier}
+// NewAdminService builds admin service; printer, payments and auditLog are optional.
+func NewAdminService(repo *Repository, userRepo *users.Repository, notifier *notifications.TelegramNotifier, printer OrderPrinter, payments PaymentGateway, auditLog *audit.Service) *AdminService {
+	return &AdminService{repo: repo, userRepo: userRepo, notifier: notifier, printer: printer, payments: payments, audit: auditLog}
 }
 
 // List returns latest orders regardless of user.
@@ -188,23 +289,44 @@ func (s *AdminService) List(ctx context.Context, params AdminListParams) ([]Orde
 	return s.repo.ListAdmin(ctx, params)
 }
 
+// Get returns a single order regardless of user.
+func (s *AdminService) Get(ctx context.Context, orderID int64) (*Order, error) {
+	return s.repo.GetAdminByID(ctx, orderID)
+}
+
 // UpdateStatus updates and returns order.
 func (s *AdminService) UpdateStatus(ctx context.Context, orderID int64, status string) (*Order, error) {
 	allowed := map[string]struct{}{
-		"new":       {},
-		"accepted":  {},
-		"cooking":   {},
-		"delivery":  {},
-		"delivered": {},
-		"canceled":  {},
-	}
-	if _, ok := allowed[strings.ToLower(status)]; !ok {
+		StatusNew:       {},
+		StatusAccepted:  {},
+		StatusCooking:   {},
+		StatusDelivery:  {},
+		StatusDelivered: {},
+		StatusCanceled:  {},
+	}
+	status = strings.ToLower(status)
+	if _, ok := allowed[status]; !ok {
 		return nil, errors.New("invalid status")
 	}
+	current, err := s.repo.GetAdminByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatusChange(current.Status, status); err != nil {
+		return nil, err
+	}
 	order, err := s.repo.UpdateStatus(ctx, orderID, status)
 	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, audit.ActionStatusChange, audit.EntityOrder, order.ID, current, order)
+	if s.printer != nil && order.Status == StatusAccepted && current.Status != StatusAccepted {
+		go s.print(order)
+	}
+	if s.payments != nil {
+		// The status change is already applied; the gateway reports its own bookkeeping failures.
+		_ = s.payments.OrderStatusChanged(ctx, order)
+	}
 	if s.notifier != nil && s.userRepo != nil {
 		if user, err := s.userRepo.GetByID(ctx, order.UserID); err == nil && user.TelegramID != 0 {
 			info := notifications.OrderInfo{
@@ -219,3 +341,10 @@ func (s *AdminService) UpdateStatus(ctx context.Context, orderID int64, status s
 	}
 	return order, nil
 }
+
+// print sends order to the printer in background; the printer reports its own failures.
+func (s *AdminService) print(order *Order) {
+	ctx, cancel := context.WithTimeout(context.Background(), printTimeout)
+	defer cancel()
+	_ = s.printer.PrintOrder(ctx, order)
+}
@@ -0,0 +1,102 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/rashidmailru/kabobfood/internal/products"
+	"github.com/rashidmailru/kabobfood/internal/regions"
+)
+
+func TestCheckStatusChange(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     error
+	}{
+		{from: StatusNew, to: StatusAccepted},
+		{from: StatusDelivered, to: StatusCanceled},
+		{from: StatusCanceled, to: StatusCanceled},
+		{from: StatusCanceled, to: StatusNew, want: ErrOrderCanceled},
+		{from: StatusCanceled, to: StatusCooking, want: ErrOrderCanceled},
+		{from: StatusAwaitingPayment, to: StatusCanceled},
+		{from: StatusAwaitingPayment, to: StatusAccepted, want: errAwaitingPayment},
+	}
+	for _, tc := range tests {
+		if err := checkStatusChange(tc.from, tc.to); !errors.Is(err, tc.want) {
+			t.Errorf("%s -> %s: got %v, want %v", tc.from, tc.to, err, tc.want)
+		}
+	}
+}
+
+// catalog serves products and regions the way their repositories do: archived
+// products come back with IsActive false.
+type catalog struct {
+	products map[int64]products.Product
+	regions  map[int64]regions.Region
+}
+
+func (c catalog) GetActiveByIDs(_ context.Context, ids []int64) (map[int64]products.Product, error) {
+	result := make(map[int64]products.Product)
+	for _, id := range ids {
+		if p, ok := c.products[id]; ok {
+			result[id] = p
+		}
+	}
+	return result, nil
+}
+
+func (c catalog) GetBundleSlots(context.Context, []int64) (map[int64][]products.BundleSlot, error) {
+	return map[int64][]products.BundleSlot{}, nil
+}
+
+func (c catalog) GetByID(_ context.Context, id int64) (*regions.Region, error) {
+	r, ok := c.regions[id]
+	if !ok {
+		return nil, errors.New("no rows in result set")
+	}
+	return &r, nil
+}
+
+func TestCreateRejectsArchived(t *testing.T) {
+	archivedAt := time.Now()
+	c := catalog{
+		products: map[int64]products.Product{
+			1: {ID: 1, Name: "Plov", Price: 100, IsActive: true},
+			2: {ID: 2, Name: "Somsa", Price: 50, IsActive: false},
+		},
+		regions: map[int64]regions.Region{
+			1: {ID: 1, Name: "Center", IsActive: true},
+			2: {ID: 2, Name: "Old town", IsActive: true, ArchivedAt: &archivedAt},
+		},
+	}
+	s := &Service{repo: NewRepository(nil, nil), productRepo: c, regionRepo: c}
+
+	tests := []struct {
+		name      string
+		regionID  int64
+		productID int64
+		want      error
+	}{
+		{name: "archived region", regionID: 2, productID: 1, want: errInvalidRegion},
+		{name: "archived product", regionID: 1, productID: 2, want: errProductNotFound},
+		{name: "live region and product reach the repository", regionID: 1, productID: 1, want: errNilPool},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.Create(context.Background(), 1, CreateOrderInput{
+				ClientRequestID: uuid.NewString(),
+				Type:            "pickup",
+				RegionID:        tc.regionID,
+				PaymentMethod:   "cash",
+				Items:           []ItemInput{{ProductID: tc.productID, Qty: 1}},
+			})
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("got %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
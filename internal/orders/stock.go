@@ -0,0 +1,131 @@
+package orders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrOutOfStock is wrapped by StockError.
+var ErrOutOfStock = errors.New("product is not available in the requested quantity")
+
+// StockError names the product that cannot be ordered: it has fewer than
+// Requested units left or is on the stop-list until UnavailableUntil.
+type StockError struct {
+	ProductID        int64      `json:"product_id"`
+	ProductName      string     `json:"product_name"`
+	Requested        int32      `json:"requested"`
+	Available        int        `json:"available"`
+	UnavailableUntil *time.Time `json:"unavailable_until,omitempty"`
+}
+
+func (e *StockError) Error() string {
+	switch {
+	case e.UnavailableUntil != nil:
+		return fmt.Sprintf("%s is unavailable until %s", e.ProductName, e.UnavailableUntil.Format(time.RFC3339))
+	case e.Available == 0:
+		return fmt.Sprintf("%s is sold out", e.ProductName)
+	default:
+		return fmt.Sprintf("only %d of %s left, %d requested", e.Available, e.ProductName, e.Requested)
+	}
+}
+
+func (e *StockError) Unwrap() error { return ErrOutOfStock }
+
+// checkStock validates requested quantities against a product's stock and stop-list.
+func checkStock(id int64, name string, stock *int, until *time.Time, qty int32, now time.Time) error {
+	if until != nil && until.After(now) {
+		return &StockError{ProductID: id, ProductName: name, Requested: qty, UnavailableUntil: until}
+	}
+	if stock != nil && *stock < int(qty) {
+		return &StockError{ProductID: id, ProductName: name, Requested: qty, Available: *stock}
+	}
+	return nil
+}
+
+// reserveStock locks the counted and stop-listed products of items and their
+// bundle components, re-checks them and decrements stock. Rows are locked in
+// id order to avoid deadlocks between concurrent orders. soldOut reports that
+// a product's stock reached zero.
+func reserveStock(ctx context.Context, tx pgx.Tx, items []OrderItem) (soldOut bool, err error) {
+	ids, qty := stockDemand(items)
+
+	rows, err := tx.Query(ctx, `
+SELECT id, name, stock, unavailable_until
+FROM products
+WHERE id = ANY($1) AND (stock IS NOT NULL OR unavailable_until IS NOT NULL)
+ORDER BY id
+FOR UPDATE;
+`, ids)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	var counted []int64
+	var amounts []int32
+	for rows.Next() {
+		var (
+			id    int64
+			name  string
+			stock *int
+			until *time.Time
+		)
+		if err := rows.Scan(&id, &name, &stock, &until); err != nil {
+			return false, err
+		}
+		if err := checkStock(id, name, stock, until, qty[id], now); err != nil {
+			return false, err
+		}
+		if stock != nil {
+			counted = append(counted, id)
+			amounts = append(amounts, qty[id])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	rows.Close()
+	if len(counted) == 0 {
+		return false, nil
+	}
+	var emptied int
+	err = tx.QueryRow(ctx, `
+WITH updated AS (
+    UPDATE products SET stock = stock - u.qty, updated_at = NOW()
+    FROM unnest($1::bigint[], $2::int[]) AS u(id, qty)
+    WHERE products.id = u.id
+    RETURNING products.stock
+)
+SELECT COUNT(*) FROM updated WHERE stock = 0;
+`, counted, amounts).Scan(&emptied)
+	return emptied > 0, err
+}
+
+// releaseStock returns the items of a canceled order and their bundle
+// components to counted products. restocked reports that any stock changed.
+func releaseStock(ctx context.Context, tx pgx.Tx, orderID int64) (restocked bool, err error) {
+	tag, err := tx.Exec(ctx, `
+UPDATE products SET stock = products.stock + i.qty, updated_at = NOW()
+FROM (
+    SELECT product_id, SUM(qty) AS qty
+    FROM (
+        SELECT product_id, qty FROM order_items WHERE order_id = $1
+        UNION ALL
+        SELECT c.product_id, oi.qty * c.qty
+        FROM order_items oi, jsonb_to_recordset(oi.components) AS c(product_id BIGINT, qty INT)
+        WHERE oi.order_id = $1
+    ) AS units
+    GROUP BY product_id
+) AS i
+WHERE products.id = i.product_id AND products.stock IS NOT NULL;
+`, orderID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,33 @@
+package orders
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckStock(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	stock := 2
+	if err := checkStock(1, "Plov", &stock, nil, 2, now); err != nil {
+		t.Fatalf("exact stock must be enough, got %v", err)
+	}
+	if err := checkStock(1, "Plov", nil, nil, 100, now); err != nil {
+		t.Fatalf("uncounted products have no limit, got %v", err)
+	}
+
+	err := checkStock(1, "Plov", &stock, nil, 3, now)
+	var stockErr *StockError
+	if !errors.As(err, &stockErr) || !errors.Is(err, ErrOutOfStock) || stockErr.Available != 2 || stockErr.Requested != 3 {
+		t.Fatalf("expected stock error with 2 left, got %v", err)
+	}
+
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	if err := checkStock(1, "Plov", nil, &past, 1, now); err != nil {
+		t.Fatalf("expired stop-list must not block, got %v", err)
+	}
+	err = checkStock(1, "Plov", &stock, &future, 1, now)
+	if !errors.As(err, &stockErr) || stockErr.UnavailableUntil == nil {
+		t.Fatalf("expected stop-list error, got %v", err)
+	}
+}
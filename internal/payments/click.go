@@ -0,0 +1,154 @@
+package payments
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ClickProviderName identifies Click SHOP-API.
+const ClickProviderName = "click"
+
+// Click SHOP-API error codes.
+const (
+	clickErrSign          = -1
+	clickErrAmount        = -2
+	clickErrAction        = -3
+	clickErrAlreadyPaid   = -4
+	clickErrOrderNotFound = -5
+	clickErrTxNotFound    = -6
+	clickErrRequest       = -8
+	clickErrTxCanceled    = -9
+	clickActionPrepare    = "0"
+	clickActionComplete   = "1"
+	clickDefaultCheckout  = "https://my.click.uz/services/pay"
+	clickAmountTolerance  = 0.005
+)
+
+// ClickConfig holds merchant credentials.
+type ClickConfig struct {
+	ServiceID   string
+	MerchantID  string
+	SecretKey   string
+	CheckoutURL string
+	ReturnURL   string
+}
+
+// ClickProvider implements the Click redirect checkout with prepare/complete callbacks.
+type ClickProvider struct {
+	cfg ClickConfig
+}
+
+// NewClickProvider builds provider.
+func NewClickProvider(cfg ClickConfig) *ClickProvider {
+	if cfg.CheckoutURL == "" {
+		cfg.CheckoutURL = clickDefaultCheckout
+	}
+	return &ClickProvider{cfg: cfg}
+}
+
+func (p *ClickProvider) Name() string { return ClickProviderName }
+
+func (p *ClickProvider) Online() bool { return true }
+
+func (p *ClickProvider) CreateCheckout(_ context.Context, payment Payment) (*Checkout, error) {
+	q := url.Values{}
+	q.Set("service_id", p.cfg.ServiceID)
+	q.Set("merchant_id", p.cfg.MerchantID)
+	q.Set("amount", strconv.FormatFloat(payment.Amount, 'f', 2, 64))
+	q.Set("transaction_param", strconv.FormatInt(payment.OrderID, 10))
+	if p.cfg.ReturnURL != "" {
+		q.Set("return_url", p.cfg.ReturnURL)
+	}
+	return &Checkout{RedirectURL: p.cfg.CheckoutURL + "?" + q.Encode()}, nil
+}
+
+// Sign computes sign_string for callback values (merchant_prepare_id is included only for complete).
+func (p *ClickProvider) Sign(v url.Values) string {
+	parts := []string{v.Get("click_trans_id"), v.Get("service_id"), p.cfg.SecretKey, v.Get("merchant_trans_id")}
+	if v.Get("action") == clickActionComplete {
+		parts = append(parts, v.Get("merchant_prepare_id"))
+	}
+	parts = append(parts, v.Get("amount"), v.Get("action"), v.Get("sign_time"))
+	sum := md5.Sum([]byte(strings.Join(parts, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (p *ClickProvider) HandleCallback(ctx context.Context, r *http.Request, store CallbackStore) CallbackResponse {
+	if err := r.ParseForm(); err != nil {
+		return clickReply(nil, clickErrRequest, "invalid request", nil)
+	}
+	v := r.PostForm
+	if subtle.ConstantTimeCompare([]byte(p.Sign(v)), []byte(strings.ToLower(v.Get("sign_string")))) != 1 {
+		return clickReply(v, clickErrSign, "SIGN CHECK FAILED!", nil)
+	}
+	orderID, err := strconv.ParseInt(v.Get("merchant_trans_id"), 10, 64)
+	if err != nil {
+		return clickReply(v, clickErrOrderNotFound, "order not found", nil)
+	}
+	payment, err := store.PaymentForOrder(ctx, orderID)
+	if err != nil || payment.Provider != ClickProviderName {
+		return clickReply(v, clickErrOrderNotFound, "order not found", nil)
+	}
+	amount, err := strconv.ParseFloat(v.Get("amount"), 64)
+	if err != nil || math.Abs(amount-payment.Amount) > clickAmountTolerance {
+		return clickReply(v, clickErrAmount, "incorrect amount", nil)
+	}
+	switch payment.Status {
+	case StatusPaid:
+		return clickReply(v, clickErrAlreadyPaid, "already paid", nil)
+	case StatusFailed, StatusRefunded:
+		return clickReply(v, clickErrTxCanceled, "transaction cancelled", nil)
+	}
+
+	switch v.Get("action") {
+	case clickActionPrepare:
+		updated, err := store.Transition(ctx, payment.ID, StatusPending, v.Get("click_trans_id"))
+		if err != nil {
+			return clickReply(v, clickErrRequest, err.Error(), nil)
+		}
+		return clickReply(v, 0, "Success", map[string]any{"merchant_prepare_id": updated.ID})
+	case clickActionComplete:
+		if v.Get("merchant_prepare_id") != strconv.FormatInt(payment.ID, 10) {
+			return clickReply(v, clickErrTxNotFound, "transaction not found", nil)
+		}
+		target := StatusPaid
+		if code, err := strconv.Atoi(v.Get("error")); err == nil && code < 0 {
+			target = StatusFailed
+		}
+		updated, err := store.Transition(ctx, payment.ID, target, "")
+		if err != nil {
+			return clickReply(v, clickErrRequest, err.Error(), nil)
+		}
+		if target == StatusFailed {
+			return clickReply(v, clickErrTxCanceled, "transaction cancelled", nil)
+		}
+		return clickReply(v, 0, "Success", map[string]any{"merchant_confirm_id": updated.ID})
+	default:
+		return clickReply(v, clickErrAction, "action not found", nil)
+	}
+}
+
+func clickReply(v url.Values, code int, note string, extra map[string]any) CallbackResponse {
+	body := map[string]any{"error": code, "error_note": note}
+	if v != nil {
+		body["click_trans_id"] = v.Get("click_trans_id")
+		body["merchant_trans_id"] = v.Get("merchant_trans_id")
+	}
+	for k, val := range extra {
+		body[k] = val
+	}
+	return CallbackResponse{StatusCode: http.StatusOK, Body: body}
+}
+
+// String hides the secret when the provider is logged.
+func (p *ClickProvider) String() string {
+	return fmt.Sprintf("click(service=%s)", p.cfg.ServiceID)
+}
@@ -0,0 +1,104 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// FakeProviderName identifies the local testing provider.
+const FakeProviderName = "fake"
+
+// FakeSignatureHeader carries hex HMAC-SHA256 of the callback body.
+const FakeSignatureHeader = "X-Fake-Signature"
+
+// FakeProvider simulates an online gateway for local development.
+type FakeProvider struct {
+	secret  []byte
+	baseURL string
+}
+
+// NewFakeProvider builds fake provider; baseURL is the public API address used in redirect links.
+func NewFakeProvider(secret, baseURL string) *FakeProvider {
+	return &FakeProvider{secret: []byte(secret), baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// FakeCallback is the JSON body accepted by the fake callback endpoint.
+type FakeCallback struct {
+	ExternalID string `json:"external_id"`
+	Status     string `json:"status"`
+}
+
+func (p *FakeProvider) Name() string { return FakeProviderName }
+
+func (p *FakeProvider) Online() bool { return true }
+
+func (p *FakeProvider) CreateCheckout(_ context.Context, _ Payment) (*Checkout, error) {
+	externalID := "fake-" + uuid.NewString()
+	return &Checkout{
+		ExternalID:  externalID,
+		RedirectURL: p.baseURL + "/payments/fake/checkout/" + externalID,
+	}, nil
+}
+
+// Refund always succeeds for the fake gateway.
+func (p *FakeProvider) Refund(_ context.Context, _ Payment, _ float64) (string, error) {
+	return "fake-refund-" + uuid.NewString(), nil
+}
+
+// Sign returns the signature expected for body.
+func (p *FakeProvider) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *FakeProvider) HandleCallback(ctx context.Context, r *http.Request, store CallbackStore) CallbackResponse {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<16))
+	if err != nil {
+		return fakeError(http.StatusBadRequest, "cannot read body")
+	}
+	if !hmac.Equal([]byte(p.Sign(body)), []byte(strings.ToLower(r.Header.Get(FakeSignatureHeader)))) {
+		return fakeError(http.StatusUnauthorized, ErrInvalidSignature.Error())
+	}
+	var cb FakeCallback
+	if err := json.Unmarshal(body, &cb); err != nil || cb.ExternalID == "" {
+		return fakeError(http.StatusBadRequest, "invalid payload")
+	}
+	if cb.Status != StatusPaid && cb.Status != StatusFailed {
+		return fakeError(http.StatusBadRequest, "status must be paid or failed")
+	}
+	payment, err := store.PaymentByExternalID(ctx, FakeProviderName, cb.ExternalID)
+	if err != nil {
+		return fakeError(http.StatusNotFound, "payment not found")
+	}
+	updated, err := store.Transition(ctx, payment.ID, cb.Status, "")
+	if err != nil {
+		return fakeError(http.StatusConflict, err.Error())
+	}
+	return CallbackResponse{StatusCode: http.StatusOK, Body: updated}
+}
+
+func fakeError(code int, msg string) CallbackResponse {
+	return CallbackResponse{StatusCode: code, Body: map[string]string{"error": msg}}
+}
+
+// Simulate settles the fake payment as if the customer completed checkout; it goes through
+// the same signed callback path as a real notification.
+func (p *FakeProvider) Simulate(ctx context.Context, externalID, status string, store CallbackStore) CallbackResponse {
+	body, _ := json.Marshal(FakeCallback{ExternalID: externalID, Status: status})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/payments/fake/callback", bytes.NewReader(body))
+	if err != nil {
+		return fakeError(http.StatusInternalServerError, err.Error())
+	}
+	req.Header.Set(FakeSignatureHeader, p.Sign(body))
+	return p.HandleCallback(ctx, req, store)
+}
@@ -0,0 +1,102 @@
+package payments
+
+import "time"
+
+// Payment statuses.
+const (
+	StatusIntent   = "intent"
+	StatusPending  = "pending"
+	StatusPaid     = "paid"
+	StatusFailed   = "failed"
+	StatusRefunded = "refunded"
+)
+
+// Payment tracks money collection for an order through a provider.
+type Payment struct {
+	ID          int64     `json:"id"`
+	OrderID     int64     `json:"order_id"`
+	Provider    string    `json:"provider"`
+	Status      string    `json:"status"`
+	Amount      float64   `json:"amount"`
+	Currency    string    `json:"currency"`
+	ExternalID  string    `json:"external_id,omitempty"`
+	RedirectURL string    `json:"redirect_url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Refund statuses.
+const (
+	RefundPending   = "pending"
+	RefundSucceeded = "succeeded"
+	RefundFailed    = "failed"
+)
+
+// Refund methods: provider refunds go through the gateway API, manual ones are handed back by staff.
+const (
+	RefundMethodProvider = "provider"
+	RefundMethodManual   = "manual"
+)
+
+// Refund records money returned for an order payment.
+type Refund struct {
+	ID         int64        `json:"id"`
+	OrderID    int64        `json:"order_id"`
+	PaymentID  int64        `json:"payment_id"`
+	Amount     float64      `json:"amount"`
+	Reason     string       `json:"reason"`
+	Method     string       `json:"method"`
+	Status     string       `json:"status"`
+	ExternalID string       `json:"external_id,omitempty"`
+	Error      string       `json:"error,omitempty"`
+	CreatedBy  int64        `json:"created_by,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+	Items      []RefundItem `json:"items,omitempty"`
+}
+
+// RefundItem is the refunded part of an order item.
+type RefundItem struct {
+	OrderItemID int64   `json:"order_item_id"`
+	Qty         int32   `json:"qty"`
+	Amount      float64 `json:"amount"`
+}
+
+// RefundInput describes an admin refund request. Without items and amount the
+// remaining paid amount is refunded.
+type RefundInput struct {
+	Amount float64           `json:"amount"`
+	Reason string            `json:"reason"`
+	Items  []RefundItemInput `json:"items"`
+}
+
+// RefundItemInput selects quantity of an order item to refund.
+type RefundItemInput struct {
+	OrderItemID int64 `json:"order_item_id"`
+	Qty         int32 `json:"qty"`
+}
+
+// ReportParams bounds the payments report.
+type ReportParams struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// ProviderTotals aggregates money flow of one provider.
+type ProviderTotals struct {
+	Provider  string  `json:"provider"`
+	Payments  int64   `json:"payments"`
+	Collected float64 `json:"collected"`
+	Refunded  float64 `json:"refunded"`
+	Net       float64 `json:"net"`
+}
+
+// Report summarizes collected money net of refunds.
+type Report struct {
+	Payments  int64            `json:"payments"`
+	Collected float64          `json:"collected"`
+	Refunds   int64            `json:"refunds"`
+	Refunded  float64          `json:"refunded"`
+	Net       float64          `json:"net"`
+	Providers []ProviderTotals `json:"providers"`
+}
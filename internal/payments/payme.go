@@ -0,0 +1,233 @@
+package payments
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PaymeProviderName identifies Payme (Paycom) merchant API.
+const PaymeProviderName = "payme"
+
+// Payme transaction states as defined by the merchant API.
+const (
+	paymeStateCreated           = 1
+	paymeStatePerformed         = 2
+	paymeStateCanceled          = -1
+	paymeStateCanceledAfterPaid = -2
+)
+
+// Payme JSON-RPC error codes.
+const (
+	paymeErrAuth            = -32504
+	paymeErrMethod          = -32601
+	paymeErrParse           = -32700
+	paymeErrAmount          = -31001
+	paymeErrTxNotFound      = -31003
+	paymeErrCannotPerform   = -31008
+	paymeErrOrderNotFound   = -31050
+	paymeErrOrderNotPayable = -31051
+)
+
+// PaymeConfig holds merchant credentials.
+type PaymeConfig struct {
+	MerchantID  string
+	Key         string
+	CheckoutURL string
+	ReturnURL   string
+}
+
+// PaymeProvider implements the Payme redirect checkout and merchant JSON-RPC callbacks.
+type PaymeProvider struct {
+	cfg PaymeConfig
+}
+
+// NewPaymeProvider builds provider.
+func NewPaymeProvider(cfg PaymeConfig) *PaymeProvider {
+	if cfg.CheckoutURL == "" {
+		cfg.CheckoutURL = "https://checkout.paycom.uz"
+	}
+	cfg.CheckoutURL = strings.TrimRight(cfg.CheckoutURL, "/")
+	return &PaymeProvider{cfg: cfg}
+}
+
+func (p *PaymeProvider) Name() string { return PaymeProviderName }
+
+func (p *PaymeProvider) Online() bool { return true }
+
+func (p *PaymeProvider) CreateCheckout(_ context.Context, payment Payment) (*Checkout, error) {
+	params := fmt.Sprintf("m=%s;ac.order_id=%d;a=%d", p.cfg.MerchantID, payment.OrderID, toMinorUnits(payment.Amount))
+	if p.cfg.ReturnURL != "" {
+		params += ";c=" + p.cfg.ReturnURL
+	}
+	return &Checkout{RedirectURL: p.cfg.CheckoutURL + "/" + base64.StdEncoding.EncodeToString([]byte(params))}, nil
+}
+
+type paymeRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params paymeParams     `json:"params"`
+}
+
+type paymeParams struct {
+	ID      string `json:"id"`
+	Time    int64  `json:"time"`
+	Amount  int64  `json:"amount"`
+	Reason  *int   `json:"reason"`
+	Account struct {
+		OrderID string `json:"order_id"`
+	} `json:"account"`
+}
+
+type paymeError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (p *PaymeProvider) HandleCallback(ctx context.Context, r *http.Request, store CallbackStore) CallbackResponse {
+	var req paymeRequest
+	if !p.authorized(r.Header.Get("Authorization")) {
+		return paymeFail(req.ID, paymeErrAuth, ErrInvalidSignature.Error())
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<16))
+	if err != nil || json.Unmarshal(body, &req) != nil {
+		return paymeFail(req.ID, paymeErrParse, "invalid json")
+	}
+
+	switch req.Method {
+	case "CheckPerformTransaction":
+		if _, code := p.payableForOrder(ctx, store, req.Params); code != 0 {
+			return paymeFail(req.ID, code, "order is not payable")
+		}
+		return paymeOK(req.ID, map[string]any{"allow": true})
+	case "CreateTransaction":
+		if existing, err := store.PaymentByExternalID(ctx, PaymeProviderName, req.Params.ID); err == nil {
+			return paymeOK(req.ID, paymeTxResult(existing))
+		}
+		payment, code := p.payableForOrder(ctx, store, req.Params)
+		if code != 0 {
+			return paymeFail(req.ID, code, "order is not payable")
+		}
+		if payment.ExternalID != "" && payment.ExternalID != req.Params.ID {
+			return paymeFail(req.ID, paymeErrOrderNotPayable, "order has another pending transaction")
+		}
+		updated, err := store.Transition(ctx, payment.ID, StatusPending, req.Params.ID)
+		if err != nil {
+			return paymeFail(req.ID, paymeErrCannotPerform, err.Error())
+		}
+		return paymeOK(req.ID, paymeTxResult(updated))
+	case "PerformTransaction":
+		payment, err := store.PaymentByExternalID(ctx, PaymeProviderName, req.Params.ID)
+		if err != nil {
+			return paymeFail(req.ID, paymeErrTxNotFound, "transaction not found")
+		}
+		updated, err := store.Transition(ctx, payment.ID, StatusPaid, "")
+		if err != nil {
+			return paymeFail(req.ID, paymeErrCannotPerform, err.Error())
+		}
+		return paymeOK(req.ID, paymeTxResult(updated))
+	case "CancelTransaction":
+		payment, err := store.PaymentByExternalID(ctx, PaymeProviderName, req.Params.ID)
+		if err != nil {
+			return paymeFail(req.ID, paymeErrTxNotFound, "transaction not found")
+		}
+		var updated *Payment
+		switch payment.Status {
+		case StatusPaid:
+			updated, err = store.RefundPaid(ctx, payment.ID, req.Params.ID)
+		case StatusRefunded:
+			updated, err = store.Transition(ctx, payment.ID, StatusRefunded, "")
+		default:
+			updated, err = store.Transition(ctx, payment.ID, StatusFailed, "")
+		}
+		if err != nil {
+			return paymeFail(req.ID, paymeErrCannotPerform, err.Error())
+		}
+		return paymeOK(req.ID, paymeTxResult(updated))
+	case "CheckTransaction":
+		payment, err := store.PaymentByExternalID(ctx, PaymeProviderName, req.Params.ID)
+		if err != nil {
+			return paymeFail(req.ID, paymeErrTxNotFound, "transaction not found")
+		}
+		return paymeOK(req.ID, paymeTxResult(payment))
+	default:
+		return paymeFail(req.ID, paymeErrMethod, "method not found")
+	}
+}
+
+func (p *PaymeProvider) authorized(header string) bool {
+	const prefix = "Basic "
+	if !strings.HasPrefix(header, prefix) {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(header, prefix))
+	if err != nil {
+		return false
+	}
+	expected := "Paycom:" + p.cfg.Key
+	return subtle.ConstantTimeCompare(decoded, []byte(expected)) == 1
+}
+
+// payableForOrder resolves the waiting payment referenced by account.order_id and checks the amount.
+func (p *PaymeProvider) payableForOrder(ctx context.Context, store CallbackStore, params paymeParams) (*Payment, int) {
+	orderID, err := strconv.ParseInt(params.Account.OrderID, 10, 64)
+	if err != nil {
+		return nil, paymeErrOrderNotFound
+	}
+	payment, err := store.PaymentForOrder(ctx, orderID)
+	if err != nil || payment.Provider != PaymeProviderName {
+		return nil, paymeErrOrderNotFound
+	}
+	if payment.Status != StatusIntent && payment.Status != StatusPending {
+		return nil, paymeErrOrderNotPayable
+	}
+	if toMinorUnits(payment.Amount) != params.Amount {
+		return nil, paymeErrAmount
+	}
+	return payment, 0
+}
+
+func paymeTxResult(p *Payment) map[string]any {
+	state := paymeStateCreated
+	switch p.Status {
+	case StatusPaid:
+		state = paymeStatePerformed
+	case StatusFailed:
+		state = paymeStateCanceled
+	case StatusRefunded:
+		state = paymeStateCanceledAfterPaid
+	}
+	res := map[string]any{
+		"transaction": strconv.FormatInt(p.ID, 10),
+		"state":       state,
+		"create_time": p.CreatedAt.UnixMilli(),
+	}
+	switch state {
+	case paymeStatePerformed:
+		res["perform_time"] = p.UpdatedAt.UnixMilli()
+	case paymeStateCanceled, paymeStateCanceledAfterPaid:
+		res["cancel_time"] = p.UpdatedAt.UnixMilli()
+	}
+	return res
+}
+
+func paymeOK(id json.RawMessage, result any) CallbackResponse {
+	return CallbackResponse{StatusCode: http.StatusOK, Body: map[string]any{"jsonrpc": "2.0", "id": id, "result": result}}
+}
+
+// paymeFail answers with HTTP 200 as the merchant API expects errors inside the JSON-RPC envelope.
+func paymeFail(id json.RawMessage, code int, msg string) CallbackResponse {
+	return CallbackResponse{StatusCode: http.StatusOK, Body: map[string]any{"jsonrpc": "2.0", "id": id, "error": paymeError{Code: code, Message: msg}}}
+}
+
+// toMinorUnits converts an amount to tiyin.
+func toMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
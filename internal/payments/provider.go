@@ -0,0 +1,79 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Provider integrates a payment method. Offline providers (cash, card on delivery)
+// only track status; online providers redirect the customer and confirm via callbacks.
+type Provider interface {
+	Name() string
+	Online() bool
+	// CreateCheckout prepares a redirect for the customer to pay the payment.
+	CreateCheckout(ctx context.Context, p Payment) (*Checkout, error)
+	// HandleCallback verifies the provider signature and applies the notification through store.
+	HandleCallback(ctx context.Context, r *http.Request, store CallbackStore) CallbackResponse
+}
+
+// Refunder is implemented by providers able to return money through their API.
+// Providers without it get refunds recorded as manual and pending until staff
+// confirm they handed the money back.
+type Refunder interface {
+	Refund(ctx context.Context, p Payment, amount float64) (externalID string, err error)
+}
+
+// Checkout describes where to send the customer to pay.
+type Checkout struct {
+	ExternalID  string
+	RedirectURL string
+}
+
+// CallbackResponse is the provider-specific reply to a callback.
+type CallbackResponse struct {
+	StatusCode int
+	Body       any
+}
+
+// CallbackStore gives providers access to payments while handling callbacks.
+type CallbackStore interface {
+	PaymentForOrder(ctx context.Context, orderID int64) (*Payment, error)
+	PaymentByExternalID(ctx context.Context, provider, externalID string) (*Payment, error)
+	Transition(ctx context.Context, paymentID int64, status, externalID string) (*Payment, error)
+	// RefundPaid records that the provider returned a paid payment and marks it refunded.
+	RefundPaid(ctx context.Context, paymentID int64, externalID string) (*Payment, error)
+	// CheckOrder re-validates that the order can still be paid and cooked as priced.
+	CheckOrder(ctx context.Context, orderID int64) error
+}
+
+var (
+	// ErrUnknownMethod is returned for payment methods without a registered provider.
+	ErrUnknownMethod = errors.New("unsupported payment method")
+	// ErrOfflineProvider is returned when an online operation is requested for an offline method.
+	ErrOfflineProvider = errors.New("payment method does not support online checkout")
+	// ErrInvalidSignature is returned when a callback signature does not verify.
+	ErrInvalidSignature = errors.New("invalid payment callback signature")
+)
+
+// offlineProvider covers methods settled in person: cash, card terminal or bank transfer.
+type offlineProvider struct {
+	name string
+}
+
+// NewOfflineProvider returns provider for a method collected on delivery or pickup.
+func NewOfflineProvider(name string) Provider {
+	return offlineProvider{name: name}
+}
+
+func (p offlineProvider) Name() string { return p.name }
+
+func (p offlineProvider) Online() bool { return false }
+
+func (p offlineProvider) CreateCheckout(context.Context, Payment) (*Checkout, error) {
+	return nil, ErrOfflineProvider
+}
+
+func (p offlineProvider) HandleCallback(context.Context, *http.Request, CallbackStore) CallbackResponse {
+	return CallbackResponse{StatusCode: http.StatusNotFound, Body: map[string]string{"error": ErrOfflineProvider.Error()}}
+}
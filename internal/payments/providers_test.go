@@ -0,0 +1,222 @@
+package payments
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	payments map[int64]*Payment
+	refunds  map[int64]string
+	checkErr error
+}
+
+func newMemoryStore(list ...Payment) *memoryStore {
+	s := &memoryStore{payments: map[int64]*Payment{}, refunds: map[int64]string{}}
+	for i := range list {
+		p := list[i]
+		s.payments[p.ID] = &p
+	}
+	return s
+}
+
+func (s *memoryStore) PaymentForOrder(_ context.Context, orderID int64) (*Payment, error) {
+	for _, p := range s.payments {
+		if p.OrderID == orderID {
+			cp := *p
+			return &cp, nil
+		}
+	}
+	return nil, ErrPaymentNotFound
+}
+
+func (s *memoryStore) PaymentByExternalID(_ context.Context, provider, externalID string) (*Payment, error) {
+	for _, p := range s.payments {
+		if p.Provider == provider && p.ExternalID == externalID {
+			cp := *p
+			return &cp, nil
+		}
+	}
+	return nil, ErrPaymentNotFound
+}
+
+func (s *memoryStore) Transition(_ context.Context, id int64, status, externalID string) (*Payment, error) {
+	p, ok := s.payments[id]
+	if !ok {
+		return nil, ErrPaymentNotFound
+	}
+	allowed := false
+	for _, from := range allowedFrom[status] {
+		allowed = allowed || p.Status == from
+	}
+	if !allowed {
+		return nil, ErrInvalidTransition
+	}
+	p.Status = status
+	if externalID != "" {
+		p.ExternalID = externalID
+	}
+	p.UpdatedAt = time.Now()
+	cp := *p
+	return &cp, nil
+}
+
+func (s *memoryStore) RefundPaid(ctx context.Context, id int64, externalID string) (*Payment, error) {
+	if p, ok := s.payments[id]; ok && p.Status == StatusPaid {
+		s.refunds[id] = externalID
+	}
+	return s.Transition(ctx, id, StatusRefunded, "")
+}
+
+func (s *memoryStore) CheckOrder(context.Context, int64) error { return s.checkErr }
+
+func TestFakeProviderRejectsBadSignature(t *testing.T) {
+	provider := NewFakeProvider("secret", "http://api")
+	store := newMemoryStore(Payment{ID: 1, OrderID: 10, Provider: FakeProviderName, Status: StatusIntent, ExternalID: "fake-1"})
+
+	req := httptest.NewRequest(http.MethodPost, "/payments/fake/callback", strings.NewReader(`{"external_id":"fake-1","status":"paid"}`))
+	req.Header.Set(FakeSignatureHeader, "deadbeef")
+	if resp := provider.HandleCallback(context.Background(), req, store); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+
+	resp := provider.Simulate(context.Background(), "fake-1", StatusPaid, store)
+	if resp.StatusCode != http.StatusOK || store.payments[1].Status != StatusPaid {
+		t.Fatalf("expected paid, got %d %s", resp.StatusCode, store.payments[1].Status)
+	}
+	// Replayed notification stays successful.
+	if resp := provider.Simulate(context.Background(), "fake-1", StatusPaid, store); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected idempotent retry, got %d", resp.StatusCode)
+	}
+}
+
+func TestPaymeLifecycle(t *testing.T) {
+	provider := NewPaymeProvider(PaymeConfig{MerchantID: "m", Key: "k"})
+	store := newMemoryStore(Payment{ID: 5, OrderID: 42, Provider: PaymeProviderName, Status: StatusIntent, Amount: 15000})
+	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte("Paycom:k"))
+
+	call := func(method, body, authHeader string) map[string]any {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/payments/payme/callback", strings.NewReader(`{"id":1,"method":"`+method+`","params":`+body+`}`))
+		req.Header.Set("Authorization", authHeader)
+		resp := provider.HandleCallback(context.Background(), req, store)
+		raw, _ := json.Marshal(resp.Body)
+		var out map[string]any
+		_ = json.Unmarshal(raw, &out)
+		return out
+	}
+
+	if out := call("CheckPerformTransaction", `{"amount":1500000,"account":{"order_id":"42"}}`, "Basic bad"); out["error"] == nil {
+		t.Fatalf("expected auth error, got %v", out)
+	}
+	if out := call("CheckPerformTransaction", `{"amount":100,"account":{"order_id":"42"}}`, auth); out["error"] == nil {
+		t.Fatalf("expected amount error, got %v", out)
+	}
+	if out := call("CreateTransaction", `{"id":"tx1","amount":1500000,"account":{"order_id":"42"}}`, auth); out["error"] != nil {
+		t.Fatalf("create failed: %v", out)
+	}
+	if out := call("PerformTransaction", `{"id":"tx1"}`, auth); out["error"] != nil {
+		t.Fatalf("perform failed: %v", out)
+	}
+	if got := store.payments[5]; got.Status != StatusPaid || got.ExternalID != "tx1" {
+		t.Fatalf("unexpected payment %+v", got)
+	}
+	if out := call("CancelTransaction", `{"id":"tx1","reason":5}`, auth); out["error"] != nil {
+		t.Fatalf("cancel failed: %v", out)
+	}
+	if got := store.payments[5]; got.Status != StatusRefunded || store.refunds[5] != "tx1" {
+		t.Fatalf("expected a recorded refund, got %+v %v", got, store.refunds)
+	}
+}
+
+func TestClickSignature(t *testing.T) {
+	provider := NewClickProvider(ClickConfig{ServiceID: "7", SecretKey: "s"})
+	store := newMemoryStore(Payment{ID: 3, OrderID: 9, Provider: ClickProviderName, Status: StatusIntent, Amount: 5000})
+
+	form := url.Values{
+		"click_trans_id":    {"100"},
+		"service_id":        {"7"},
+		"merchant_trans_id": {"9"},
+		"amount":            {"5000.00"},
+		"action":            {clickActionPrepare},
+		"sign_time":         {"2024-01-01 10:00:00"},
+	}
+	post := func(v url.Values) map[string]any {
+		req := httptest.NewRequest(http.MethodPost, "/payments/click/callback", strings.NewReader(v.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return provider.HandleCallback(context.Background(), req, store).Body.(map[string]any)
+	}
+
+	form.Set("sign_string", "wrong")
+	if out := post(form); out["error"] != clickErrSign {
+		t.Fatalf("expected sign error, got %v", out)
+	}
+	form.Set("sign_string", provider.Sign(form))
+	if out := post(form); out["error"] != 0 {
+		t.Fatalf("prepare failed: %v", out)
+	}
+
+	form.Set("action", clickActionComplete)
+	form.Set("merchant_prepare_id", "3")
+	form.Set("sign_string", provider.Sign(form))
+	if out := post(form); out["error"] != 0 {
+		t.Fatalf("complete failed: %v", out)
+	}
+	if store.payments[3].Status != StatusPaid {
+		t.Fatalf("expected paid, got %s", store.payments[3].Status)
+	}
+}
+
+func TestTelegramInvoiceEvents(t *testing.T) {
+	provider := NewTelegramProvider(TelegramConfig{BotToken: "123:abc"})
+	store := newMemoryStore(Payment{ID: 7, OrderID: 21, Provider: TelegramProviderName, Status: StatusIntent, Amount: 32000, Currency: "UZS"})
+
+	send := func(cb TelegramCallback, sign bool) CallbackResponse {
+		body, _ := json.Marshal(cb)
+		req := httptest.NewRequest(http.MethodPost, "/payments/telegram/callback", strings.NewReader(string(body)))
+		if sign {
+			req.Header.Set(TelegramSignatureHeader, provider.Sign(body))
+		}
+		return provider.HandleCallback(context.Background(), req, store)
+	}
+	event := TelegramCallback{Type: TelegramEventPreCheckout, InvoicePayload: InvoicePayload(21), Currency: "UZS", TotalAmount: 3200000}
+
+	if resp := send(event, false); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+
+	store.checkErr = errors.New("sold out")
+	if res := send(event, true).Body.(TelegramCallbackResult); res.OK || res.Error != "sold out" {
+		t.Fatalf("expected rejected checkout, got %+v", res)
+	}
+	store.checkErr = nil
+
+	wrong := event
+	wrong.TotalAmount = 100
+	if res := send(wrong, true).Body.(TelegramCallbackResult); res.OK {
+		t.Fatal("expected amount mismatch to be rejected")
+	}
+	if res := send(event, true).Body.(TelegramCallbackResult); !res.OK || res.OrderID != 21 {
+		t.Fatalf("expected accepted checkout, got %+v", res)
+	}
+
+	paid := event
+	paid.Type = TelegramEventSuccessfulPayment
+	paid.TelegramPaymentChargeID = "charge-1"
+	for i := 0; i < 2; i++ {
+		if res := send(paid, true).Body.(TelegramCallbackResult); !res.OK {
+			t.Fatalf("attempt %d: expected ok, got %+v", i, res)
+		}
+	}
+	if got := store.payments[7]; got.Status != StatusPaid || got.ExternalID != "charge-1" {
+		t.Fatalf("unexpected payment %+v", got)
+	}
+}
@@ -0,0 +1,221 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/rashidmailru/kabobfood/internal/orders"
+)
+
+var (
+	// ErrNothingToRefund is returned when the order has no collected payment.
+	ErrNothingToRefund = errors.New("order has no paid payment to refund")
+	// ErrInvalidRefund is returned for malformed refund requests.
+	ErrInvalidRefund = errors.New("refund must specify either amount or items")
+	// ErrRefundFailed is returned when the provider rejected the refund; the failed record is kept.
+	ErrRefundFailed = errors.New("provider refund failed")
+	// ErrRefundNotPending is returned when confirming a refund that is not a pending manual one.
+	ErrRefundNotPending = errors.New("refund is not awaiting confirmation")
+)
+
+// Refund returns money for an order: the whole remaining amount, a fixed amount or selected items.
+// adminID is 0 for automatic refunds. Providers without a refund API get a manual refund that
+// stays pending, and out of reports, until staff confirm the money was handed back.
+func (s *Service) Refund(ctx context.Context, orderID, adminID int64, input RefundInput) (*Refund, error) {
+	order, err := s.ordersRepo.GetAdminByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	payment, err := s.repo.LatestForOrder(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNothingToRefund
+		}
+		return nil, err
+	}
+	if payment.Status != StatusPaid {
+		return nil, ErrNothingToRefund
+	}
+
+	draft := Refund{
+		OrderID:   orderID,
+		PaymentID: payment.ID,
+		Reason:    input.Reason,
+		CreatedBy: adminID,
+	}
+	limits, err := refundAmount(order, payment, input, &draft)
+	if err != nil {
+		return nil, err
+	}
+	if draft.Amount == 0 {
+		refunded, err := s.repo.RefundedAmount(ctx, payment.ID)
+		if err != nil {
+			return nil, err
+		}
+		draft.Amount = payment.Amount - refunded
+		if toMinorUnits(draft.Amount) <= 0 {
+			return nil, ErrRefundExceedsPaid
+		}
+	}
+
+	provider, hasProvider := s.providers[payment.Provider]
+	refunder, canRefund := provider.(Refunder)
+	draft.Method = RefundMethodManual
+	if hasProvider && canRefund {
+		draft.Method = RefundMethodProvider
+	}
+	reserved, err := s.repo.ReserveRefund(ctx, draft, limits)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNothingToRefund
+		}
+		return nil, err
+	}
+	if draft.Method == RefundMethodManual {
+		return reserved, nil
+	}
+
+	status, errMsg := RefundSucceeded, ""
+	externalID, err := refunder.Refund(ctx, *payment, reserved.Amount)
+	if err != nil {
+		status, errMsg = RefundFailed, err.Error()
+		s.log.Warn("provider refund failed", zap.Int64("order_id", orderID), zap.Int64("refund_id", reserved.ID), zap.Error(err))
+	}
+	finished, err := s.repo.FinishRefund(ctx, reserved.ID, status, externalID, errMsg)
+	if err != nil {
+		return nil, err
+	}
+	finished.Items = reserved.Items
+	if status == RefundFailed {
+		return finished, ErrRefundFailed
+	}
+	if err := s.settleRefunds(ctx, payment); err != nil {
+		return nil, err
+	}
+	return finished, nil
+}
+
+// ConfirmRefund records whether staff handed back a pending manual refund. A confirmed
+// refund counts in reports and may complete the payment refund; a rejected one frees
+// its amount and items.
+func (s *Service) ConfirmRefund(ctx context.Context, orderID, refundID int64, handedBack bool) (*Refund, error) {
+	list, err := s.repo.ListRefunds(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(list, func(rf Refund) bool { return rf.ID == refundID })
+	if idx < 0 {
+		return nil, pgx.ErrNoRows
+	}
+	if list[idx].Method != RefundMethodManual || list[idx].Status != RefundPending {
+		return nil, ErrRefundNotPending
+	}
+
+	status, errMsg := RefundSucceeded, ""
+	if !handedBack {
+		status, errMsg = RefundFailed, "rejected by staff"
+	}
+	finished, err := s.repo.FinishRefund(ctx, refundID, status, "", errMsg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRefundNotPending
+		}
+		return nil, err
+	}
+	finished.Items = list[idx].Items
+	if status == RefundSucceeded {
+		payment, err := s.repo.GetByID(ctx, finished.PaymentID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.settleRefunds(ctx, payment); err != nil {
+			return nil, err
+		}
+	}
+	return finished, nil
+}
+
+// RefundPaid implements CallbackStore: the provider returned a paid payment on its side,
+// so the rest of it is recorded as a succeeded provider refund and the payment becomes refunded.
+func (s *Service) RefundPaid(ctx context.Context, paymentID int64, externalID string) (*Payment, error) {
+	if _, err := s.repo.RecordProviderRefund(ctx, paymentID, externalID, "canceled by provider"); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	return s.Transition(ctx, paymentID, StatusRefunded, "")
+}
+
+// settleRefunds marks the payment refunded once succeeded refunds cover it.
+func (s *Service) settleRefunds(ctx context.Context, payment *Payment) error {
+	refunded, err := s.repo.RefundedAmount(ctx, payment.ID)
+	if err != nil {
+		return err
+	}
+	if toMinorUnits(refunded) >= toMinorUnits(payment.Amount) {
+		if _, err := s.Transition(ctx, payment.ID, StatusRefunded, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refundAmount fills draft amount and items from input and returns ordered quantities
+// of the refunded items; a zero amount means the remaining paid amount.
+func refundAmount(order *orders.Order, payment *Payment, input RefundInput, draft *Refund) (map[int64]int32, error) {
+	if input.Amount < 0 || (input.Amount > 0 && len(input.Items) > 0) {
+		return nil, ErrInvalidRefund
+	}
+	if input.Amount > 0 {
+		if toMinorUnits(input.Amount) > toMinorUnits(payment.Amount) {
+			return nil, ErrRefundExceedsPaid
+		}
+		draft.Amount = input.Amount
+		return nil, nil
+	}
+	if len(input.Items) == 0 {
+		return nil, nil
+	}
+
+	byID := make(map[int64]orders.OrderItem, len(order.Items))
+	for _, item := range order.Items {
+		byID[item.ID] = item
+	}
+	limits := make(map[int64]int32, len(input.Items))
+	requested := make(map[int64]int32, len(input.Items))
+	var ids []int64
+	for _, in := range input.Items {
+		item, ok := byID[in.OrderItemID]
+		if !ok || in.Qty <= 0 {
+			return nil, fmt.Errorf("%w: unknown item %d or non-positive qty", ErrInvalidRefund, in.OrderItemID)
+		}
+		if _, seen := requested[item.ID]; !seen {
+			ids = append(ids, item.ID)
+		}
+		requested[item.ID] += in.Qty
+		limits[item.ID] = item.Qty
+	}
+	for _, id := range ids {
+		item, qty := byID[id], requested[id]
+		if qty > item.Qty {
+			return nil, ErrRefundExceedsPaid
+		}
+		amount := item.Price * float64(qty)
+		draft.Items = append(draft.Items, RefundItem{OrderItemID: id, Qty: qty, Amount: amount})
+		draft.Amount += amount
+	}
+	return limits, nil
+}
+
+// ListRefunds returns refunds of an order.
+func (s *Service) ListRefunds(ctx context.Context, orderID int64) ([]Refund, error) {
+	return s.repo.ListRefunds(ctx, orderID)
+}
+
+// Report summarizes collected money and refunds.
+func (s *Service) Report(ctx context.Context, params ReportParams) (*Report, error) {
+	return s.repo.Report(ctx, params)
+}
@@ -0,0 +1,47 @@
+package payments
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rashidmailru/kabobfood/internal/orders"
+)
+
+func TestRefundAmount(t *testing.T) {
+	order := &orders.Order{Items: []orders.OrderItem{
+		{ID: 1, Qty: 2, Price: 25000},
+		{ID: 2, Qty: 1, Price: 8000},
+	}}
+	payment := &Payment{Amount: 68000}
+
+	var draft Refund
+	limits, err := refundAmount(order, payment, RefundInput{Items: []RefundItemInput{{OrderItemID: 1, Qty: 1}, {OrderItemID: 2, Qty: 1}, {OrderItemID: 1, Qty: 1}}}, &draft)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if draft.Amount != 58000 || len(draft.Items) != 2 || draft.Items[0].Qty != 2 || limits[1] != 2 {
+		t.Fatalf("unexpected draft %+v limits %v", draft, limits)
+	}
+
+	cases := []struct {
+		name  string
+		input RefundInput
+		want  error
+	}{
+		{"too many items", RefundInput{Items: []RefundItemInput{{OrderItemID: 2, Qty: 2}}}, ErrRefundExceedsPaid},
+		{"unknown item", RefundInput{Items: []RefundItemInput{{OrderItemID: 9, Qty: 1}}}, ErrInvalidRefund},
+		{"amount and items", RefundInput{Amount: 10, Items: []RefundItemInput{{OrderItemID: 1, Qty: 1}}}, ErrInvalidRefund},
+		{"amount above paid", RefundInput{Amount: 70000}, ErrRefundExceedsPaid},
+	}
+	for _, tc := range cases {
+		var d Refund
+		if _, err := refundAmount(order, payment, tc.input, &d); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	var full Refund
+	if _, err := refundAmount(order, payment, RefundInput{}, &full); err != nil || full.Amount != 0 {
+		t.Fatalf("expected remaining-amount refund, got %+v %v", full, err)
+	}
+}
@@ -0,0 +1,379 @@
+package payments
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists payments.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository creates repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+var errNilPool = errors.New("payments repository: nil pool")
+
+const paymentColumns = `id, order_id, provider, status, amount, currency, COALESCE(external_id,''), COALESCE(redirect_url,''), created_at, updated_at`
+
+// Insert creates a payment record.
+func (r *Repository) Insert(ctx context.Context, p Payment) (*Payment, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `
+INSERT INTO payments (order_id, provider, status, amount, currency, external_id, redirect_url)
+VALUES ($1,$2,$3,$4,$5,NULLIF($6,''),NULLIF($7,''))
+RETURNING ` + paymentColumns + `;
+`
+	row := r.pool.QueryRow(ctx, query, p.OrderID, p.Provider, p.Status, p.Amount, p.Currency, p.ExternalID, p.RedirectURL)
+	return scanPayment(row)
+}
+
+// GetByID returns payment by id.
+func (r *Repository) GetByID(ctx context.Context, id int64) (*Payment, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE id = $1;`
+	return scanPayment(r.pool.QueryRow(ctx, query, id))
+}
+
+// GetByExternalID returns payment by provider transaction id.
+func (r *Repository) GetByExternalID(ctx context.Context, provider, externalID string) (*Payment, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE provider = $1 AND external_id = $2;`
+	return scanPayment(r.pool.QueryRow(ctx, query, provider, externalID))
+}
+
+// LatestForOrder returns the most recent payment attempt of an order.
+func (r *Repository) LatestForOrder(ctx context.Context, orderID int64) (*Payment, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE order_id = $1 ORDER BY id DESC LIMIT 1;`
+	return scanPayment(r.pool.QueryRow(ctx, query, orderID))
+}
+
+// ListByOrder returns every payment attempt of an order, newest first.
+func (r *Repository) ListByOrder(ctx context.Context, orderID int64) ([]Payment, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE order_id = $1 ORDER BY id DESC;`
+	rows, err := r.pool.Query(ctx, query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// UpdateStatus moves payment to status when its current status is one of from; returns pgx.ErrNoRows otherwise.
+// Empty externalID or redirectURL keep stored values.
+func (r *Repository) UpdateStatus(ctx context.Context, id int64, status string, from []string, externalID, redirectURL string) (*Payment, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `
+UPDATE payments
+SET status = $2,
+    external_id = COALESCE(NULLIF($4,''), external_id),
+    redirect_url = COALESCE(NULLIF($5,''), redirect_url),
+    paid_at = CASE WHEN $2 = 'paid' THEN COALESCE(paid_at, NOW()) ELSE paid_at END,
+    updated_at = NOW()
+WHERE id = $1 AND status = ANY($3)
+RETURNING ` + paymentColumns + `;
+`
+	return scanPayment(r.pool.QueryRow(ctx, query, id, status, from, externalID, redirectURL))
+}
+
+func scanPayment(row pgx.Row) (*Payment, error) {
+	var p Payment
+	if err := row.Scan(&p.ID, &p.OrderID, &p.Provider, &p.Status, &p.Amount, &p.Currency, &p.ExternalID, &p.RedirectURL, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ErrRefundExceedsPaid is returned when refunds would exceed what was paid.
+var ErrRefundExceedsPaid = errors.New("refund exceeds paid amount")
+
+const refundColumns = `id, order_id, payment_id, amount, reason, method, status, COALESCE(external_id,''), COALESCE(error,''), COALESCE(created_by,0), created_at, updated_at`
+
+// ReserveRefund inserts a pending refund after checking, under a payment row lock, that
+// amounts and item quantities stay within what was paid. itemLimits maps order item id to its ordered qty.
+func (r *Repository) ReserveRefund(ctx context.Context, refund Refund, itemLimits map[int64]int32) (*Refund, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var paid float64
+	if err := tx.QueryRow(ctx, `SELECT amount FROM payments WHERE id = $1 AND status = 'paid' FOR UPDATE;`, refund.PaymentID).Scan(&paid); err != nil {
+		return nil, err
+	}
+	var refunded float64
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(SUM(amount),0) FROM refunds WHERE payment_id = $1 AND status <> 'failed';`, refund.PaymentID).Scan(&refunded); err != nil {
+		return nil, err
+	}
+	if toMinorUnits(refunded+refund.Amount) > toMinorUnits(paid) {
+		return nil, ErrRefundExceedsPaid
+	}
+	if len(refund.Items) > 0 {
+		rows, err := tx.Query(ctx, `
+SELECT ri.order_item_id, SUM(ri.qty)
+FROM refund_items ri JOIN refunds rf ON rf.id = ri.refund_id
+WHERE rf.order_id = $1 AND rf.status <> 'failed'
+GROUP BY ri.order_item_id;
+`, refund.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		already := make(map[int64]int32)
+		for rows.Next() {
+			var itemID int64
+			var qty int32
+			if err := rows.Scan(&itemID, &qty); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			already[itemID] = qty
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		for _, item := range refund.Items {
+			if already[item.OrderItemID]+item.Qty > itemLimits[item.OrderItemID] {
+				return nil, ErrRefundExceedsPaid
+			}
+		}
+	}
+
+	var createdBy interface{}
+	if refund.CreatedBy != 0 {
+		createdBy = refund.CreatedBy
+	}
+	row := tx.QueryRow(ctx, `
+INSERT INTO refunds (order_id, payment_id, amount, reason, method, status, created_by)
+VALUES ($1,$2,$3,$4,$5,$6,$7)
+RETURNING `+refundColumns+`;
+`, refund.OrderID, refund.PaymentID, refund.Amount, refund.Reason, refund.Method, RefundPending, createdBy)
+	saved, err := scanRefund(row)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range refund.Items {
+		if _, err := tx.Exec(ctx, `INSERT INTO refund_items (refund_id, order_item_id, qty, amount) VALUES ($1,$2,$3,$4);`, saved.ID, item.OrderItemID, item.Qty, item.Amount); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	saved.Items = refund.Items
+	return saved, nil
+}
+
+// RecordProviderRefund records that the provider returned the rest of a paid payment on its
+// own: pending refunds of the payment are failed and one succeeded provider refund covers
+// what succeeded refunds had not. It returns pgx.ErrNoRows when the payment is not paid and
+// nil when nothing was left to refund.
+func (r *Repository) RecordProviderRefund(ctx context.Context, paymentID int64, externalID, reason string) (*Refund, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	tx, err := r.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var orderID int64
+	var paid float64
+	if err := tx.QueryRow(ctx, `SELECT order_id, amount FROM payments WHERE id = $1 AND status = 'paid' FOR UPDATE;`, paymentID).Scan(&orderID, &paid); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE refunds SET status = 'failed', error = 'superseded by provider refund', updated_at = NOW() WHERE payment_id = $1 AND status = 'pending';`, paymentID); err != nil {
+		return nil, err
+	}
+	var refunded float64
+	if err := tx.QueryRow(ctx, `SELECT COALESCE(SUM(amount),0) FROM refunds WHERE payment_id = $1 AND status = 'succeeded';`, paymentID).Scan(&refunded); err != nil {
+		return nil, err
+	}
+	var saved *Refund
+	if toMinorUnits(paid-refunded) > 0 {
+		row := tx.QueryRow(ctx, `
+INSERT INTO refunds (order_id, payment_id, amount, reason, method, status, external_id)
+VALUES ($1,$2,$3,$4,$5,$6,NULLIF($7,''))
+RETURNING `+refundColumns+`;
+`, orderID, paymentID, paid-refunded, reason, RefundMethodProvider, RefundSucceeded, externalID)
+		if saved, err = scanRefund(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// FinishRefund stores the outcome of a pending refund.
+func (r *Repository) FinishRefund(ctx context.Context, id int64, status, externalID, errMsg string) (*Refund, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `
+UPDATE refunds
+SET status = $2, external_id = NULLIF($3,''), error = NULLIF($4,''), updated_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING ` + refundColumns + `;
+`
+	return scanRefund(r.pool.QueryRow(ctx, query, id, status, externalID, errMsg))
+}
+
+// RefundedAmount sums succeeded refunds of a payment.
+func (r *Repository) RefundedAmount(ctx context.Context, paymentID int64) (float64, error) {
+	if r.pool == nil {
+		return 0, errNilPool
+	}
+	var total float64
+	err := r.pool.QueryRow(ctx, `SELECT COALESCE(SUM(amount),0) FROM refunds WHERE payment_id = $1 AND status = 'succeeded';`, paymentID).Scan(&total)
+	return total, err
+}
+
+// ListRefunds returns refunds of an order with their items, newest first.
+func (r *Repository) ListRefunds(ctx context.Context, orderID int64) ([]Refund, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	rows, err := r.pool.Query(ctx, `SELECT `+refundColumns+` FROM refunds WHERE order_id = $1 ORDER BY id DESC;`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	var list []Refund
+	index := make(map[int64]int)
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[refund.ID] = len(list)
+		list = append(list, *refund)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return list, nil
+	}
+
+	itemRows, err := r.pool.Query(ctx, `
+SELECT ri.refund_id, ri.order_item_id, ri.qty, ri.amount
+FROM refund_items ri JOIN refunds rf ON rf.id = ri.refund_id
+WHERE rf.order_id = $1
+ORDER BY ri.order_item_id;
+`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+	for itemRows.Next() {
+		var refundID int64
+		var item RefundItem
+		if err := itemRows.Scan(&refundID, &item.OrderItemID, &item.Qty, &item.Amount); err != nil {
+			return nil, err
+		}
+		if i, ok := index[refundID]; ok {
+			list[i].Items = append(list[i].Items, item)
+		}
+	}
+	return list, itemRows.Err()
+}
+
+// Report aggregates collected payments and succeeded refunds per provider within the period.
+func (r *Repository) Report(ctx context.Context, params ReportParams) (*Report, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	const query = `
+WITH collected AS (
+    SELECT provider, COUNT(*) AS cnt, SUM(amount) AS total
+    FROM payments
+    WHERE status IN ('paid', 'refunded')
+      AND ($1::timestamptz IS NULL OR paid_at >= $1)
+      AND ($2::timestamptz IS NULL OR paid_at < $2)
+    GROUP BY provider
+), returned AS (
+    SELECT p.provider, COUNT(*) AS cnt, SUM(rf.amount) AS total
+    FROM refunds rf JOIN payments p ON p.id = rf.payment_id
+    WHERE rf.status = 'succeeded'
+      AND ($1::timestamptz IS NULL OR rf.created_at >= $1)
+      AND ($2::timestamptz IS NULL OR rf.created_at < $2)
+    GROUP BY p.provider
+)
+SELECT COALESCE(c.provider, r.provider), COALESCE(c.cnt,0), COALESCE(c.total,0), COALESCE(r.cnt,0), COALESCE(r.total,0)
+FROM collected c FULL JOIN returned r ON r.provider = c.provider
+ORDER BY 1;
+`
+	rows, err := r.pool.Query(ctx, query, params.From, params.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := &Report{Providers: []ProviderTotals{}}
+	for rows.Next() {
+		var line ProviderTotals
+		var refunds int64
+		if err := rows.Scan(&line.Provider, &line.Payments, &line.Collected, &refunds, &line.Refunded); err != nil {
+			return nil, err
+		}
+		line.Net = line.Collected - line.Refunded
+		report.Payments += line.Payments
+		report.Collected += line.Collected
+		report.Refunds += refunds
+		report.Refunded += line.Refunded
+		report.Providers = append(report.Providers, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	report.Net = report.Collected - report.Refunded
+	return report, nil
+}
+
+func scanRefund(row pgx.Row) (*Refund, error) {
+	var rf Refund
+	if err := row.Scan(&rf.ID, &rf.OrderID, &rf.PaymentID, &rf.Amount, &rf.Reason, &rf.Method, &rf.Status, &rf.ExternalID, &rf.Error, &rf.CreatedBy, &rf.CreatedAt, &rf.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &rf, nil
+}
@@ -0,0 +1,385 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/rashidmailru/kabobfood/internal/notifications"
+	"github.com/rashidmailru/kabobfood/internal/orders"
+	"github.com/rashidmailru/kabobfood/internal/products"
+	"github.com/rashidmailru/kabobfood/internal/users"
+)
+
+var (
+	// ErrPaymentNotFound is returned when an order has no payment yet.
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrInvalidTransition is returned when a payment cannot move to the requested status.
+	ErrInvalidTransition = errors.New("payment status transition not allowed")
+	// ErrNotPayable is returned when an online checkout is requested for an order that no longer awaits payment.
+	ErrNotPayable = errors.New("order is not awaiting payment")
+	// ErrNoTelegramChat is returned when an invoice cannot be delivered because the user has no Telegram chat.
+	ErrNoTelegramChat = errors.New("user has no telegram chat")
+)
+
+// allowedFrom lists statuses a payment may be in before moving to the key status.
+// Repeating the target status keeps provider retries idempotent.
+var allowedFrom = map[string][]string{
+	StatusPending:  {StatusIntent, StatusPending},
+	StatusPaid:     {StatusIntent, StatusPending, StatusPaid},
+	StatusFailed:   {StatusIntent, StatusPending, StatusFailed},
+	StatusRefunded: {StatusPaid, StatusRefunded},
+}
+
+// ServiceConfig groups payment service dependencies.
+type ServiceConfig struct {
+	Repo         *Repository
+	OrdersRepo   *orders.Repository
+	ProductsRepo *products.Repository
+	UserRepo     *users.Repository
+	Notifier     *notifications.TelegramNotifier
+	Providers    []Provider
+	Currency     string
+	Logger       *zap.Logger
+}
+
+// Service tracks payments of orders and applies provider callbacks.
+type Service struct {
+	repo         *Repository
+	ordersRepo   *orders.Repository
+	productsRepo *products.Repository
+	userRepo     *users.Repository
+	notifier     *notifications.TelegramNotifier
+	providers    map[string]Provider
+	currency     string
+	log          *zap.Logger
+}
+
+// NewService builds payments service.
+func NewService(cfg ServiceConfig) *Service {
+	providers := make(map[string]Provider, len(cfg.Providers))
+	for _, p := range cfg.Providers {
+		if p != nil {
+			providers[p.Name()] = p
+		}
+	}
+	currency := cfg.Currency
+	if currency == "" {
+		currency = "UZS"
+	}
+	log := cfg.Logger
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &Service{
+		repo:         cfg.Repo,
+		ordersRepo:   cfg.OrdersRepo,
+		productsRepo: cfg.ProductsRepo,
+		userRepo:     cfg.UserRepo,
+		notifier:     cfg.Notifier,
+		providers:    providers,
+		currency:     currency,
+		log:          log,
+	}
+}
+
+func (s *Service) provider(method string) (Provider, error) {
+	p, ok := s.providers[strings.ToLower(strings.TrimSpace(method))]
+	if !ok {
+		return nil, ErrUnknownMethod
+	}
+	return p, nil
+}
+
+// RequiresPrepayment implements orders.PaymentGateway.
+func (s *Service) RequiresPrepayment(method string) (bool, error) {
+	p, err := s.provider(method)
+	if err != nil {
+		return false, err
+	}
+	return p.Online(), nil
+}
+
+// OrderCreated implements orders.PaymentGateway: records the first payment of an order.
+// Replayed order requests keep the existing payment.
+func (s *Service) OrderCreated(ctx context.Context, order *orders.Order) error {
+	p, err := s.provider(order.PaymentMethod)
+	if err != nil {
+		return err
+	}
+	if _, err := s.repo.LatestForOrder(ctx, order.ID); err == nil {
+		return nil
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	status := StatusPending
+	if p.Online() {
+		status = StatusIntent
+	}
+	_, err = s.repo.Insert(ctx, Payment{
+		OrderID:  order.ID,
+		Provider: p.Name(),
+		Status:   status,
+		Amount:   order.TotalPrice,
+		Currency: s.currency,
+	})
+	return err
+}
+
+// OrderStatusChanged implements orders.PaymentGateway: offline payments settle on delivery,
+// unpaid payments fail and paid ones are refunded when the order is canceled.
+func (s *Service) OrderStatusChanged(ctx context.Context, order *orders.Order) error {
+	payment, err := s.repo.LatestForOrder(ctx, order.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	var target string
+	switch order.Status {
+	case orders.StatusDelivered:
+		if p, ok := s.providers[payment.Provider]; ok && !p.Online() {
+			target = StatusPaid
+		}
+	case orders.StatusCanceled:
+		switch payment.Status {
+		case StatusIntent, StatusPending:
+			target = StatusFailed
+		case StatusPaid:
+			return s.refundCanceled(ctx, order.ID)
+		}
+	}
+	if target == "" || target == payment.Status {
+		return nil
+	}
+	if _, err := s.Transition(ctx, payment.ID, target, ""); err != nil {
+		s.log.Warn("payment status sync failed", zap.Int64("order_id", order.ID), zap.String("status", target), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// refundCanceled returns whatever is left of a paid payment of a canceled order.
+func (s *Service) refundCanceled(ctx context.Context, orderID int64) error {
+	_, err := s.Refund(ctx, orderID, 0, RefundInput{Reason: "order canceled"})
+	if err != nil && !errors.Is(err, ErrRefundExceedsPaid) {
+		s.log.Warn("automatic refund failed", zap.Int64("order_id", orderID), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Start returns checkout for the user's order, creating a new attempt after a failed one.
+func (s *Service) Start(ctx context.Context, userID, orderID int64) (*Payment, error) {
+	order, err := s.ordersRepo.GetByID(ctx, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.provider(order.PaymentMethod)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Online() {
+		return nil, ErrOfflineProvider
+	}
+	if order.Status != orders.StatusAwaitingPayment {
+		return nil, ErrNotPayable
+	}
+
+	payment, err := s.repo.LatestForOrder(ctx, order.ID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows) || (err == nil && payment.Status == StatusFailed):
+		payment, err = s.repo.Insert(ctx, Payment{
+			OrderID:  order.ID,
+			Provider: p.Name(),
+			Status:   StatusIntent,
+			Amount:   order.TotalPrice,
+			Currency: s.currency,
+		})
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	}
+	if payment.RedirectURL != "" || payment.Status != StatusIntent {
+		return payment, nil
+	}
+
+	checkout, err := p.CreateCheckout(ctx, *payment)
+	if err != nil {
+		return nil, fmt.Errorf("create checkout: %w", err)
+	}
+	return s.repo.UpdateStatus(ctx, payment.ID, payment.Status, []string{payment.Status}, checkout.ExternalID, checkout.RedirectURL)
+}
+
+// SendInvoice sends a Telegram invoice for the user's order into their chat with the bot.
+func (s *Service) SendInvoice(ctx context.Context, userID, orderID int64) (*Payment, error) {
+	p, ok := s.providers[TelegramProviderName].(*TelegramProvider)
+	if !ok {
+		return nil, ErrUnknownMethod
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TelegramID == 0 {
+		return nil, ErrNoTelegramChat
+	}
+	payment, err := s.Start(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.Provider != TelegramProviderName {
+		return nil, ErrUnknownMethod
+	}
+	if err := p.SendInvoice(ctx, user.TelegramID, *payment); err != nil {
+		return nil, fmt.Errorf("send invoice: %w", err)
+	}
+	return payment, nil
+}
+
+// Get returns the latest payment of the user's order.
+func (s *Service) Get(ctx context.Context, userID, orderID int64) (*Payment, error) {
+	if _, err := s.ordersRepo.GetByID(ctx, orderID, userID); err != nil {
+		return nil, err
+	}
+	return s.PaymentForOrder(ctx, orderID)
+}
+
+// ListForOrder returns every payment attempt of an order for operators.
+func (s *Service) ListForOrder(ctx context.Context, orderID int64) ([]Payment, error) {
+	return s.repo.ListByOrder(ctx, orderID)
+}
+
+// HandleCallback routes provider notification to its provider.
+func (s *Service) HandleCallback(ctx context.Context, providerName string, r *http.Request) (CallbackResponse, error) {
+	p, ok := s.providers[strings.ToLower(providerName)]
+	if !ok || !p.Online() {
+		return CallbackResponse{}, ErrUnknownMethod
+	}
+	return p.HandleCallback(ctx, r, s), nil
+}
+
+// PaymentForOrder implements CallbackStore.
+func (s *Service) PaymentForOrder(ctx context.Context, orderID int64) (*Payment, error) {
+	payment, err := s.repo.LatestForOrder(ctx, orderID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPaymentNotFound
+	}
+	return payment, err
+}
+
+// PaymentByExternalID implements CallbackStore.
+func (s *Service) PaymentByExternalID(ctx context.Context, provider, externalID string) (*Payment, error) {
+	payment, err := s.repo.GetByExternalID(ctx, provider, externalID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPaymentNotFound
+	}
+	return payment, err
+}
+
+// CheckOrder implements CallbackStore: the order must still await payment and every
+// item must be available at the price it was ordered for. Stock was reserved when
+// the order was created, so only the stop-list is checked again.
+func (s *Service) CheckOrder(ctx context.Context, orderID int64) error {
+	order, err := s.ordersRepo.GetAdminByID(ctx, orderID)
+	if err != nil {
+		return errors.New("Заказ не найден")
+	}
+	if order.Status != orders.StatusAwaitingPayment {
+		return errors.New("Заказ уже оплачен или отменён")
+	}
+	ids := make([]int64, 0, len(order.Items))
+	for _, item := range order.Items {
+		ids = append(ids, item.ProductID)
+		for _, c := range item.Components {
+			ids = append(ids, c.ProductID)
+		}
+	}
+	available, err := s.productsRepo.GetActiveByIDs(ctx, ids)
+	if err != nil {
+		return errors.New("Не удалось проверить заказ, попробуйте позже")
+	}
+	now := time.Now()
+	for _, item := range order.Items {
+		product, ok := available[item.ProductID]
+		if !ok || !product.IsActive {
+			return fmt.Errorf("%s больше недоступно", item.ProductName)
+		}
+		if product.StopListed(now) {
+			return fmt.Errorf("%s временно недоступно", item.ProductName)
+		}
+		for _, c := range item.Components {
+			component, ok := available[c.ProductID]
+			if !ok || !component.IsActive || component.StopListed(now) {
+				return fmt.Errorf("%s в составе %s временно недоступно", c.ProductName, item.ProductName)
+			}
+		}
+		// Bundle items were priced with the extra prices of their components.
+		if toMinorUnits(product.Price+item.ExtraPrice()) != toMinorUnits(item.Price) {
+			return fmt.Errorf("Цена на %s изменилась, оформите заказ заново", item.ProductName)
+		}
+	}
+	return nil
+}
+
+// Transition implements CallbackStore: moves payment along the lifecycle and
+// releases a prepaid order to the kitchen once it is paid.
+func (s *Service) Transition(ctx context.Context, paymentID int64, status, externalID string) (*Payment, error) {
+	from, ok := allowedFrom[status]
+	if !ok {
+		return nil, ErrInvalidTransition
+	}
+	payment, err := s.repo.UpdateStatus(ctx, paymentID, status, from, externalID, "")
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidTransition
+		}
+		return nil, err
+	}
+	if status == StatusPaid {
+		s.releaseOrder(ctx, payment.OrderID)
+	}
+	return payment, nil
+}
+
+// releaseOrder moves awaiting order to new and announces it; repeated calls are no-ops.
+func (s *Service) releaseOrder(ctx context.Context, orderID int64) {
+	order, err := s.ordersRepo.TransitionStatus(ctx, orderID, orders.StatusAwaitingPayment, orders.StatusNew)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			s.log.Error("release paid order failed", zap.Int64("order_id", orderID), zap.Error(err))
+		}
+		return
+	}
+	if s.notifier == nil || s.userRepo == nil {
+		return
+	}
+	if user, err := s.userRepo.GetByID(ctx, order.UserID); err == nil && user.TelegramID != 0 {
+		info := notifications.OrderInfo{
+			OrderID:       order.ID,
+			Status:        order.Status,
+			Total:         order.TotalPrice,
+			CustomerName:  order.CustomerName,
+			CustomerPhone: order.CustomerPhone,
+		}
+		s.notifier.NotifyOrderCreated(ctx, info, user.TelegramID)
+	}
+}
+
+// SimulateFake completes a fake checkout; it is available only when the fake provider is enabled.
+func (s *Service) SimulateFake(ctx context.Context, externalID, status string) (CallbackResponse, error) {
+	p, ok := s.providers[FakeProviderName].(*FakeProvider)
+	if !ok {
+		return CallbackResponse{}, ErrUnknownMethod
+	}
+	return p.Simulate(ctx, externalID, status, s), nil
+}
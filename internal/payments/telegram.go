@@ -0,0 +1,223 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TelegramProviderName identifies Telegram Payments invoices.
+const TelegramProviderName = "telegram"
+
+// TelegramSignatureHeader carries hex HMAC-SHA256 of the bot callback body keyed with the bot token.
+const TelegramSignatureHeader = "X-Bot-Signature"
+
+// Telegram callback event types forwarded by the bot.
+const (
+	TelegramEventPreCheckout       = "pre_checkout"
+	TelegramEventSuccessfulPayment = "successful_payment"
+)
+
+const telegramPayloadPrefix = "order:"
+
+// TelegramConfig holds Bot API credentials for invoices.
+type TelegramConfig struct {
+	BotToken      string
+	ProviderToken string
+	APIBaseURL    string
+	HTTPClient    *http.Client
+}
+
+// TelegramProvider issues Telegram invoices and accepts payment events relayed by the bot.
+type TelegramProvider struct {
+	botToken      string
+	providerToken string
+	apiBase       string
+	client        *http.Client
+}
+
+// NewTelegramProvider builds provider.
+func NewTelegramProvider(cfg TelegramConfig) *TelegramProvider {
+	base := strings.TrimRight(cfg.APIBaseURL, "/")
+	if base == "" {
+		base = "https://api.telegram.org"
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &TelegramProvider{botToken: cfg.BotToken, providerToken: cfg.ProviderToken, apiBase: base, client: client}
+}
+
+// TelegramCallback is the event the bot forwards for pre_checkout_query and successful_payment updates.
+type TelegramCallback struct {
+	Type                    string `json:"type"`
+	TelegramID              int64  `json:"telegram_id"`
+	InvoicePayload          string `json:"invoice_payload"`
+	Currency                string `json:"currency"`
+	TotalAmount             int64  `json:"total_amount"`
+	TelegramPaymentChargeID string `json:"telegram_payment_charge_id,omitempty"`
+	ProviderPaymentChargeID string `json:"provider_payment_charge_id,omitempty"`
+}
+
+// TelegramCallbackResult tells the bot whether to accept the checkout.
+type TelegramCallbackResult struct {
+	OK      bool   `json:"ok"`
+	OrderID int64  `json:"order_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// InvoicePayload ties an invoice to an order.
+func InvoicePayload(orderID int64) string {
+	return telegramPayloadPrefix + strconv.FormatInt(orderID, 10)
+}
+
+// ParseInvoicePayload extracts order id from invoice payload.
+func ParseInvoicePayload(payload string) (int64, error) {
+	raw, ok := strings.CutPrefix(payload, telegramPayloadPrefix)
+	if !ok {
+		return 0, errors.New("unknown invoice payload")
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
+
+func (p *TelegramProvider) Name() string { return TelegramProviderName }
+
+func (p *TelegramProvider) Online() bool { return true }
+
+// CreateCheckout creates an invoice link the mini-app opens with Telegram.WebApp.openInvoice.
+func (p *TelegramProvider) CreateCheckout(ctx context.Context, payment Payment) (*Checkout, error) {
+	var link string
+	if err := p.call(ctx, "createInvoiceLink", p.invoiceParams(payment), &link); err != nil {
+		return nil, err
+	}
+	return &Checkout{RedirectURL: link}, nil
+}
+
+// SendInvoice posts the invoice for payment into the customer's chat with the bot.
+func (p *TelegramProvider) SendInvoice(ctx context.Context, chatID int64, payment Payment) error {
+	params := p.invoiceParams(payment)
+	params["chat_id"] = chatID
+	return p.call(ctx, "sendInvoice", params, nil)
+}
+
+func (p *TelegramProvider) invoiceParams(payment Payment) map[string]any {
+	title := fmt.Sprintf("Заказ #%d", payment.OrderID)
+	return map[string]any{
+		"title":          title,
+		"description":    fmt.Sprintf("Оплата заказа #%d в KabobFood", payment.OrderID),
+		"payload":        InvoicePayload(payment.OrderID),
+		"provider_token": p.providerToken,
+		"currency":       payment.Currency,
+		"prices":         []map[string]any{{"label": title, "amount": toMinorUnits(payment.Amount)}},
+	}
+}
+
+func (p *TelegramProvider) call(ctx context.Context, method string, params map[string]any, result any) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/bot%s/%s", p.apiBase, p.botToken, method)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		Description string          `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("telegram %s: %w", method, err)
+	}
+	if !envelope.OK {
+		return fmt.Errorf("telegram %s: %s", method, envelope.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(envelope.Result, result)
+	}
+	return nil
+}
+
+// Sign returns the signature the bot must send for body.
+func (p *TelegramProvider) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(p.botToken))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HandleCallback validates pre-checkout queries against the current order and
+// marks the payment paid on successful_payment. Repeated events are answered with ok.
+func (p *TelegramProvider) HandleCallback(ctx context.Context, r *http.Request, store CallbackStore) CallbackResponse {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<16))
+	if err != nil {
+		return telegramReply(http.StatusBadRequest, 0, "cannot read body")
+	}
+	if !hmac.Equal([]byte(p.Sign(body)), []byte(strings.ToLower(r.Header.Get(TelegramSignatureHeader)))) {
+		return telegramReply(http.StatusUnauthorized, 0, ErrInvalidSignature.Error())
+	}
+	var cb TelegramCallback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		return telegramReply(http.StatusBadRequest, 0, "invalid payload")
+	}
+	orderID, err := ParseInvoicePayload(cb.InvoicePayload)
+	if err != nil {
+		return telegramReply(http.StatusOK, 0, "Заказ не найден")
+	}
+	payment, err := store.PaymentForOrder(ctx, orderID)
+	if err != nil || payment.Provider != TelegramProviderName {
+		return telegramReply(http.StatusOK, orderID, "Заказ не найден")
+	}
+	if !strings.EqualFold(cb.Currency, payment.Currency) || cb.TotalAmount != toMinorUnits(payment.Amount) {
+		return telegramReply(http.StatusOK, orderID, "Сумма заказа изменилась, оформите заказ заново")
+	}
+
+	switch cb.Type {
+	case TelegramEventPreCheckout:
+		if payment.Status != StatusIntent && payment.Status != StatusPending {
+			return telegramReply(http.StatusOK, orderID, "Заказ уже оплачен или отменён")
+		}
+		if err := store.CheckOrder(ctx, orderID); err != nil {
+			return telegramReply(http.StatusOK, orderID, err.Error())
+		}
+		if _, err := store.Transition(ctx, payment.ID, StatusPending, ""); err != nil {
+			return telegramReply(http.StatusOK, orderID, "Не удалось подтвердить оплату")
+		}
+		return telegramReply(http.StatusOK, orderID, "")
+	case TelegramEventSuccessfulPayment:
+		if cb.TelegramPaymentChargeID == "" {
+			return telegramReply(http.StatusBadRequest, orderID, "telegram_payment_charge_id is required")
+		}
+		if payment.Status == StatusPaid && payment.ExternalID == cb.TelegramPaymentChargeID {
+			return telegramReply(http.StatusOK, orderID, "")
+		}
+		if _, err := store.Transition(ctx, payment.ID, StatusPaid, cb.TelegramPaymentChargeID); err != nil {
+			return telegramReply(http.StatusOK, orderID, "Не удалось зачесть оплату, свяжитесь с поддержкой")
+		}
+		return telegramReply(http.StatusOK, orderID, "")
+	default:
+		return telegramReply(http.StatusBadRequest, orderID, "unknown event type")
+	}
+}
+
+func telegramReply(code int, orderID int64, errMsg string) CallbackResponse {
+	return CallbackResponse{StatusCode: code, Body: TelegramCallbackResult{OK: errMsg == "", OrderID: orderID, Error: errMsg}}
+}
@@ -0,0 +1,40 @@
+// Package pricing evaluates scheduled prices and happy-hour discounts.
+package pricing
+
+import "time"
+
+// Rule changes the price of one product or of every product in a category.
+//
+// A rule with Price sets a fixed price (products only); a rule with
+// DiscountPercent takes a percentage off. StartsAt and EndsAt bound the rule
+// in time; Weekdays (ISO, 1 = Monday) and TimeFrom/TimeTo ("HH:MM", local
+// time) make it recurring. A window whose TimeTo is before TimeFrom runs past
+// midnight and belongs to the weekday it starts on.
+type Rule struct {
+	ID              int64      `json:"id"`
+	ProductID       *int64     `json:"product_id,omitempty"`
+	CategoryID      *int64     `json:"category_id,omitempty"`
+	Price           *float64   `json:"price,omitempty"`
+	DiscountPercent *float64   `json:"discount_percent,omitempty"`
+	StartsAt        *time.Time `json:"starts_at,omitempty"`
+	EndsAt          *time.Time `json:"ends_at,omitempty"`
+	Weekdays        []int      `json:"weekdays,omitempty"`
+	TimeFrom        string     `json:"time_from,omitempty"`
+	TimeTo          string     `json:"time_to,omitempty"`
+	Note            string     `json:"note,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// ListParams filters the admin rule listing.
+type ListParams struct {
+	ProductID  int64
+	CategoryID int64
+	// Expired includes rules whose EndsAt has passed.
+	Expired bool
+}
+
+// Quote is the price of a product at a moment.
+type Quote struct {
+	Price    float64
+	OldPrice float64
+}
@@ -0,0 +1,150 @@
+package pricing
+
+import (
+	"context"
+	"time"
+)
+
+// RuleSource loads rules that have not ended by now.
+type RuleSource interface {
+	Current(ctx context.Context, now time.Time) ([]Rule, error)
+}
+
+// Engine evaluates rules in the restaurant's time zone.
+type Engine struct {
+	source RuleSource
+	loc    *time.Location
+	now    func() time.Time
+}
+
+// NewEngine builds an engine; loc defaults to time.Local.
+func NewEngine(source RuleSource, loc *time.Location) *Engine {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &Engine{source: source, loc: loc, now: time.Now}
+}
+
+// Snapshot loads the rules once for pricing many products at the current time.
+// A nil engine yields a snapshot that keeps base prices.
+func (e *Engine) Snapshot(ctx context.Context) (*Snapshot, error) {
+	if e == nil {
+		return NewSnapshot(nil, time.Now()), nil
+	}
+	now := e.now().In(e.loc)
+	rules, err := e.source.Current(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	return NewSnapshot(rules, now), nil
+}
+
+// Snapshot is the set of rules evaluated at one moment.
+type Snapshot struct {
+	at       time.Time
+	rules    []Rule
+	product  map[int64][]int
+	category map[int64][]int
+}
+
+// NewSnapshot indexes rules for evaluation at at.
+func NewSnapshot(rules []Rule, at time.Time) *Snapshot {
+	s := &Snapshot{at: at, rules: rules, product: map[int64][]int{}, category: map[int64][]int{}}
+	for i, r := range rules {
+		switch {
+		case r.ProductID != nil:
+			s.product[*r.ProductID] = append(s.product[*r.ProductID], i)
+		case r.CategoryID != nil:
+			s.category[*r.CategoryID] = append(s.category[*r.CategoryID], i)
+		}
+	}
+	return s
+}
+
+// Price returns the price of a product with base price and manual oldPrice.
+//
+// The active fixed-price rule with the latest StartsAt replaces the base
+// price; the largest active discount then applies on top. When a discount or
+// a temporary fixed price lowers the regular price, OldPrice is set to it for
+// strike-through display; otherwise the manual oldPrice is kept.
+func (s *Snapshot) Price(productID, categoryID int64, base, oldPrice float64) Quote {
+	price := base
+	var fixed *Rule
+	discount := 0.0
+	for _, i := range s.product[productID] {
+		r := &s.rules[i]
+		if !r.activeAt(s.at) {
+			continue
+		}
+		if r.Price != nil && (fixed == nil || laterStart(r, fixed)) {
+			fixed = r
+		}
+		if r.DiscountPercent != nil && *r.DiscountPercent > discount {
+			discount = *r.DiscountPercent
+		}
+	}
+	for _, i := range s.category[categoryID] {
+		r := &s.rules[i]
+		if r.DiscountPercent != nil && r.activeAt(s.at) && *r.DiscountPercent > discount {
+			discount = *r.DiscountPercent
+		}
+	}
+
+	regular := base
+	if fixed != nil {
+		price = *fixed.Price
+		// A permanent price change becomes the new regular price.
+		if fixed.EndsAt == nil && !fixed.recurring() {
+			regular = price
+		}
+	}
+	if discount > 0 {
+		price = round2(price * (100 - discount) / 100)
+	}
+	if price < regular {
+		return Quote{Price: price, OldPrice: regular}
+	}
+	if oldPrice <= price {
+		oldPrice = 0
+	}
+	return Quote{Price: price, OldPrice: oldPrice}
+}
+
+// NextChange returns the first moment after the snapshot at which a price may
+// change, or the zero time when no rule is scheduled.
+func (s *Snapshot) NextChange() time.Time {
+	var next time.Time
+	for i := range s.rules {
+		for _, b := range s.rules[i].boundaries(s.at) {
+			if next.IsZero() || b.Before(next) {
+				next = b
+			}
+		}
+	}
+	return next
+}
+
+// TTL caps ttl so a cached price does not outlive the next change.
+func (s *Snapshot) TTL(ttl time.Duration) time.Duration {
+	next := s.NextChange()
+	if next.IsZero() {
+		return ttl
+	}
+	if until := next.Sub(s.at); until < ttl {
+		return until
+	}
+	return ttl
+}
+
+func laterStart(a, b *Rule) bool {
+	switch {
+	case a.StartsAt == nil:
+		return b.StartsAt == nil && a.ID > b.ID
+	case b.StartsAt == nil:
+		return true
+	case a.StartsAt.Equal(*b.StartsAt):
+		return a.ID > b.ID
+	default:
+		return a.StartsAt.After(*b.StartsAt)
+	}
+}
@@ -0,0 +1,105 @@
+package pricing
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+var tashkent = time.FixedZone("UZT", 5*3600)
+
+func ptr[T any](v T) *T { return &v }
+
+func TestPriceHappyHour(t *testing.T) {
+	rules := []Rule{
+		// Weekdays 15:00-17:00, 20% off the whole category.
+		{ID: 1, CategoryID: ptr(int64(1)), DiscountPercent: ptr(20.0), Weekdays: []int{1, 2, 3, 4, 5}, TimeFrom: "15:00", TimeTo: "17:00"},
+	}
+	monday := time.Date(2025, 3, 3, 16, 0, 0, 0, tashkent)
+
+	q := NewSnapshot(rules, monday).Price(10, 1, 30000, 0)
+	if q.Price != 24000 || q.OldPrice != 30000 {
+		t.Fatalf("expected 24000 instead of 30000, got %+v", q)
+	}
+	if q := NewSnapshot(rules, monday.Add(time.Hour)).Price(10, 1, 30000, 0); q.Price != 30000 || q.OldPrice != 0 {
+		t.Fatalf("happy hour ends at 17:00, got %+v", q)
+	}
+	saturday := monday.AddDate(0, 0, 5)
+	if q := NewSnapshot(rules, saturday).Price(10, 1, 30000, 35000); q.Price != 30000 || q.OldPrice != 35000 {
+		t.Fatalf("weekends keep the manual old price, got %+v", q)
+	}
+	if q := NewSnapshot(rules, monday).Price(10, 2, 30000, 0); q.Price != 30000 {
+		t.Fatalf("other categories are not discounted, got %+v", q)
+	}
+}
+
+func TestPriceScheduledChange(t *testing.T) {
+	now := time.Date(2025, 3, 3, 12, 0, 0, 0, tashkent)
+	rules := []Rule{
+		{ID: 1, ProductID: ptr(int64(10)), Price: ptr(32000.0), StartsAt: ptr(now.Add(-time.Hour))},
+		{ID: 2, ProductID: ptr(int64(10)), Price: ptr(35000.0), StartsAt: ptr(now.Add(time.Hour))},
+		{ID: 3, ProductID: ptr(int64(10)), Price: ptr(25000.0), StartsAt: ptr(now.Add(-time.Hour)), EndsAt: ptr(now.Add(30 * time.Minute))},
+	}
+	snap := NewSnapshot(rules, now)
+	// The temporary promo started last among active fixed prices and is shown as a markdown.
+	if q := snap.Price(10, 1, 30000, 0); q.Price != 25000 || q.OldPrice != 30000 {
+		t.Fatalf("expected promo price, got %+v", q)
+	}
+	if next := snap.NextChange(); !next.Equal(now.Add(30 * time.Minute)) {
+		t.Fatalf("expected next change when the promo ends, got %v", next)
+	}
+	if ttl := snap.TTL(time.Hour); ttl != 30*time.Minute {
+		t.Fatalf("ttl must stop at the boundary, got %v", ttl)
+	}
+
+	later := NewSnapshot(rules, now.Add(45*time.Minute))
+	if q := later.Price(10, 1, 30000, 0); q.Price != 32000 || q.OldPrice != 0 {
+		t.Fatalf("a permanent new price has no strike-through, got %+v", q)
+	}
+	if q := NewSnapshot(rules, now.Add(2*time.Hour)).Price(10, 1, 30000, 0); q.Price != 35000 {
+		t.Fatalf("the future price takes over once started, got %+v", q)
+	}
+}
+
+func TestWindowPastMidnight(t *testing.T) {
+	rule := Rule{ID: 1, CategoryID: ptr(int64(1)), DiscountPercent: ptr(10.0), Weekdays: []int{5}, TimeFrom: "22:00", TimeTo: "02:00"}
+	friday := time.Date(2025, 3, 7, 23, 0, 0, 0, tashkent)
+	cases := map[time.Time]bool{
+		friday:                      true,
+		friday.Add(2 * time.Hour):   true,  // Saturday 01:00 belongs to Friday's window
+		friday.Add(3 * time.Hour):   false, // Saturday 02:00
+		friday.Add(-2 * time.Hour):  false, // Friday 21:00
+		friday.Add(-22 * time.Hour): false, // Friday 01:00 belongs to Thursday
+		friday.AddDate(0, 0, 1):     false, // Saturday 23:00
+	}
+	for at, want := range cases {
+		if got := rule.activeAt(at); got != want {
+			t.Fatalf("activeAt(%v) = %v, want %v", at, got, want)
+		}
+	}
+	if next := NewSnapshot([]Rule{rule}, friday).NextChange(); !next.Equal(friday.Add(3 * time.Hour)) {
+		t.Fatalf("expected the window to close at 02:00, got %v", next)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := Rule{ProductID: ptr(int64(1)), DiscountPercent: ptr(15.0), TimeFrom: "15:00", TimeTo: "17:00", Weekdays: []int{1, 7}}
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	invalid := []Rule{
+		{DiscountPercent: ptr(10.0)},
+		{ProductID: ptr(int64(1)), CategoryID: ptr(int64(1)), DiscountPercent: ptr(10.0)},
+		{CategoryID: ptr(int64(1)), Price: ptr(1000.0)},
+		{ProductID: ptr(int64(1)), Price: ptr(1000.0), DiscountPercent: ptr(10.0)},
+		{ProductID: ptr(int64(1)), DiscountPercent: ptr(100.0)},
+		{ProductID: ptr(int64(1)), DiscountPercent: ptr(10.0), TimeFrom: "15:00"},
+		{ProductID: ptr(int64(1)), DiscountPercent: ptr(10.0), TimeFrom: "25:00", TimeTo: "17:00"},
+		{ProductID: ptr(int64(1)), DiscountPercent: ptr(10.0), Weekdays: []int{0}},
+	}
+	for i, r := range invalid {
+		if err := r.Validate(); !errors.Is(err, ErrInvalidRule) {
+			t.Fatalf("rule %d: expected ErrInvalidRule, got %v", i, err)
+		}
+	}
+}
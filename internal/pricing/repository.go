@@ -0,0 +1,129 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository stores price rules.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository builds a rule repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+var errNilPool = errors.New("pricing repository: nil pool")
+
+const ruleColumns = `id, product_id, category_id, price, discount_percent, starts_at, ends_at, COALESCE(weekdays, '{}'),
+COALESCE(to_char(time_from, 'HH24:MI'), ''), COALESCE(to_char(time_to, 'HH24:MI'), ''), COALESCE(note, ''), created_at`
+
+func scanRule(row pgx.Row) (*Rule, error) {
+	var r Rule
+	if err := row.Scan(&r.ID, &r.ProductID, &r.CategoryID, &r.Price, &r.DiscountPercent, &r.StartsAt, &r.EndsAt,
+		&r.Weekdays, &r.TimeFrom, &r.TimeTo, &r.Note, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	if len(r.Weekdays) == 0 {
+		r.Weekdays = nil
+	}
+	return &r, nil
+}
+
+// Insert stores a validated rule.
+func (r *Repository) Insert(ctx context.Context, rule Rule) (*Rule, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	const query = `
+INSERT INTO price_rules (product_id, category_id, price, discount_percent, starts_at, ends_at, weekdays, time_from, time_to, note)
+VALUES ($1,$2,$3,$4,$5,$6,$7,NULLIF($8,'')::time,NULLIF($9,'')::time,NULLIF($10,''))
+RETURNING ` + ruleColumns + `;
+`
+	var weekdays []int
+	if len(rule.Weekdays) > 0 {
+		weekdays = rule.Weekdays
+	}
+	return scanRule(r.pool.QueryRow(ctx, query, rule.ProductID, rule.CategoryID, rule.Price, rule.DiscountPercent,
+		rule.StartsAt, rule.EndsAt, weekdays, rule.TimeFrom, rule.TimeTo, rule.Note))
+}
+
+// Get returns a rule by id.
+func (r *Repository) Get(ctx context.Context, id int64) (*Rule, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	return scanRule(r.pool.QueryRow(ctx, `SELECT `+ruleColumns+` FROM price_rules WHERE id=$1;`, id))
+}
+
+// Delete removes a rule; unknown ids give pgx.ErrNoRows.
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	if r.pool == nil {
+		return errNilPool
+	}
+	tag, err := r.pool.Exec(ctx, `DELETE FROM price_rules WHERE id=$1;`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// List returns rules matching params, newest first.
+func (r *Repository) List(ctx context.Context, params ListParams) ([]Rule, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	query := `SELECT ` + ruleColumns + ` FROM price_rules WHERE 1=1`
+	args := []interface{}{}
+	idx := 1
+	if params.ProductID != 0 {
+		query += fmt.Sprintf(" AND product_id = $%d", idx)
+		args = append(args, params.ProductID)
+		idx++
+	}
+	if params.CategoryID != 0 {
+		query += fmt.Sprintf(" AND category_id = $%d", idx)
+		args = append(args, params.CategoryID)
+		idx++
+	}
+	if !params.Expired {
+		query += " AND (ends_at IS NULL OR ends_at > NOW())"
+	}
+	query += " ORDER BY id DESC"
+	return r.query(ctx, query, args...)
+}
+
+// Current implements RuleSource.
+func (r *Repository) Current(ctx context.Context, now time.Time) ([]Rule, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	return r.query(ctx, `SELECT `+ruleColumns+` FROM price_rules WHERE ends_at IS NULL OR ends_at > $1 ORDER BY id;`, now)
+}
+
+func (r *Repository) query(ctx context.Context, query string, args ...interface{}) ([]Rule, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	rules := []Rule{}
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
@@ -0,0 +1,140 @@
+package pricing
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrInvalidRule wraps validation failures of a rule.
+var ErrInvalidRule = errors.New("invalid price rule")
+
+const clockLayout = "15:04"
+
+// Validate checks that the rule has one target, one effect and a sane schedule.
+func (r *Rule) Validate() error {
+	switch {
+	case (r.ProductID == nil) == (r.CategoryID == nil):
+		return fmt.Errorf("%w: set exactly one of product_id and category_id", ErrInvalidRule)
+	case (r.Price == nil) == (r.DiscountPercent == nil):
+		return fmt.Errorf("%w: set exactly one of price and discount_percent", ErrInvalidRule)
+	case r.Price != nil && r.ProductID == nil:
+		return fmt.Errorf("%w: a fixed price needs product_id", ErrInvalidRule)
+	case r.Price != nil && *r.Price <= 0:
+		return fmt.Errorf("%w: price must be > 0", ErrInvalidRule)
+	case r.DiscountPercent != nil && (*r.DiscountPercent <= 0 || *r.DiscountPercent >= 100):
+		return fmt.Errorf("%w: discount_percent must be between 0 and 100", ErrInvalidRule)
+	case r.StartsAt != nil && r.EndsAt != nil && !r.EndsAt.After(*r.StartsAt):
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidRule)
+	case (r.TimeFrom == "") != (r.TimeTo == ""):
+		return fmt.Errorf("%w: set both time_from and time_to", ErrInvalidRule)
+	}
+	if r.TimeFrom != "" {
+		from, errFrom := parseClock(r.TimeFrom)
+		to, errTo := parseClock(r.TimeTo)
+		if errFrom != nil || errTo != nil {
+			return fmt.Errorf("%w: time_from and time_to must be HH:MM", ErrInvalidRule)
+		}
+		if from == to {
+			return fmt.Errorf("%w: time_from and time_to must differ", ErrInvalidRule)
+		}
+	}
+	seen := map[int]bool{}
+	for _, d := range r.Weekdays {
+		if d < 1 || d > 7 || seen[d] {
+			return fmt.Errorf("%w: weekdays must be distinct numbers 1 (Monday) to 7 (Sunday)", ErrInvalidRule)
+		}
+		seen[d] = true
+	}
+	return nil
+}
+
+// recurring reports whether the rule only applies on some days or hours.
+func (r *Rule) recurring() bool {
+	return len(r.Weekdays) > 0 || r.TimeFrom != ""
+}
+
+// activeAt reports whether the rule applies at t, which must be in local time.
+func (r *Rule) activeAt(t time.Time) bool {
+	if r.StartsAt != nil && t.Before(*r.StartsAt) {
+		return false
+	}
+	if r.EndsAt != nil && !t.Before(*r.EndsAt) {
+		return false
+	}
+	day := t
+	if r.TimeFrom != "" {
+		from, _ := parseClock(r.TimeFrom)
+		to, _ := parseClock(r.TimeTo)
+		minute := t.Hour()*60 + t.Minute()
+		switch {
+		case from < to:
+			if minute < from || minute >= to {
+				return false
+			}
+		case minute >= from:
+		case minute < to:
+			// Past midnight: the window opened the day before.
+			day = t.AddDate(0, 0, -1)
+		default:
+			return false
+		}
+	}
+	if len(r.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range r.Weekdays {
+		if d == isoWeekday(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// boundaries returns moments after t at which activeAt may change.
+func (r *Rule) boundaries(t time.Time) []time.Time {
+	var out []time.Time
+	if r.StartsAt != nil && r.StartsAt.After(t) {
+		out = append(out, *r.StartsAt)
+	}
+	if r.EndsAt != nil && r.EndsAt.After(t) {
+		out = append(out, *r.EndsAt)
+	}
+	if r.TimeFrom != "" {
+		from, _ := parseClock(r.TimeFrom)
+		to, _ := parseClock(r.TimeTo)
+		out = append(out, nextClock(t, from), nextClock(t, to))
+	} else if len(r.Weekdays) > 0 {
+		out = append(out, nextClock(t, 0))
+	}
+	return out
+}
+
+func parseClock(s string) (int, error) {
+	c, err := time.Parse(clockLayout, s)
+	if err != nil {
+		return 0, err
+	}
+	return c.Hour()*60 + c.Minute(), nil
+}
+
+// nextClock returns the first local time after t at minute of the day.
+func nextClock(t time.Time, minute int) time.Time {
+	next := time.Date(t.Year(), t.Month(), t.Day(), minute/60, minute%60, 0, 0, t.Location())
+	if !next.After(t) {
+		next = time.Date(t.Year(), t.Month(), t.Day()+1, minute/60, minute%60, 0, 0, t.Location())
+	}
+	return next
+}
+
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
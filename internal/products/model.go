@@ -1,11 +1,34 @@
 package products
 
+import "time"
+
 // Product represents a product with price info used for orders.
 type Product struct {
-	ID          int64   `json:"id"`
-	CategoryID  int64   `json:"category_id"`
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	IsActive    bool    `json:"is_active"`
+	ID               int64      `json:"id"`
+	CategoryID       int64      `json:"category_id"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	Price            float64    `json:"price"`
+	IsActive         bool       `json:"is_active"`
+	Stock            *int       `json:"stock,omitempty"`
+	UnavailableUntil *time.Time `json:"unavailable_until,omitempty"`
+}
+
+// StopListed reports whether the product is on the stop-list at now.
+func (p Product) StopListed(now time.Time) bool {
+	return p.UnavailableUntil != nil && p.UnavailableUntil.After(now)
+}
+
+// BundleSlot is a component of a bundle product; a slot with one option is fixed.
+type BundleSlot struct {
+	ID      int64
+	Name    string
+	Qty     int32
+	Options []BundleOption
+}
+
+// BundleOption is a product that can fill a slot for ExtraPrice on top of the bundle price.
+type BundleOption struct {
+	ProductID  int64
+	ExtraPrice float64
 }
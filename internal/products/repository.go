@@ -5,21 +5,31 @@ import (
 	"errors"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/rashidmailru/kabobfood/internal/pricing"
 )
 
 // Repository fetches product data.
 type Repository struct {
-	pool *pgxpool.Pool
+	pool   *pgxpool.Pool
+	prices *pricing.Engine
 }
 
-// NewRepository creates repository.
-func NewRepository(pool *pgxpool.Pool) *Repository {
-	return &Repository{pool: pool}
+// NewRepository creates repository; prices is optional and applies price rules.
+func NewRepository(pool *pgxpool.Pool, prices *pricing.Engine) *Repository {
+	return &Repository{pool: pool, prices: prices}
 }
 
 var errNilPool = errors.New("products repository: nil pool")
 
-// GetActiveByIDs returns active products with ids.
+const activeByIDsQuery = `
+SELECT id, category_id, name, COALESCE(description,''), price, is_active AND archived_at IS NULL, stock, unavailable_until
+FROM products
+WHERE id = ANY($1)
+`
+
+// GetActiveByIDs returns products with ids priced at the current time; IsActive is
+// false for hidden or archived ones. Stock and the stop-list are reported as is.
 func (r *Repository) GetActiveByIDs(ctx context.Context, ids []int64) (map[int64]Product, error) {
 	if r.pool == nil {
 		return nil, errNilPool
@@ -28,10 +38,49 @@ func (r *Repository) GetActiveByIDs(ctx context.Context, ids []int64) (map[int64
 		return map[int64]Product{}, nil
 	}
 
+	prices, err := r.prices.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.pool.Query(ctx, activeByIDsQuery, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int64]Product)
+	for rows.Next() {
+		var p Product
+		if err := rows.Scan(&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.Price, &p.IsActive, &p.Stock, &p.UnavailableUntil); err != nil {
+			return nil, err
+		}
+		p.Price = prices.Price(p.ID, p.CategoryID, p.Price, 0).Price
+		result[p.ID] = p
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetBundleSlots returns slots of the products with ids that are bundles, keyed by bundle id.
+func (r *Repository) GetBundleSlots(ctx context.Context, ids []int64) (map[int64][]BundleSlot, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	result := make(map[int64][]BundleSlot)
+	if len(ids) == 0 {
+		return result, nil
+	}
+
 	const query = `
-SELECT id, category_id, name, description, price, is_active
-FROM products
-WHERE id = ANY($1)
+SELECT s.bundle_id, s.id, s.name, s.qty, o.product_id, o.extra_price
+FROM bundle_slots s
+JOIN bundle_slot_options o ON o.slot_id = s.id
+WHERE s.bundle_id = ANY($1)
+ORDER BY s.bundle_id, s.sort_order, s.id, o.sort_order, o.product_id
 `
 
 	rows, err := r.pool.Query(ctx, query, ids)
@@ -40,13 +89,21 @@ WHERE id = ANY($1)
 	}
 	defer rows.Close()
 
-	result := make(map[int64]Product)
 	for rows.Next() {
-		var p Product
-		if err := rows.Scan(&p.ID, &p.CategoryID, &p.Name, &p.Description, &p.Price, &p.IsActive); err != nil {
+		var (
+			bundleID int64
+			slot     BundleSlot
+			opt      BundleOption
+		)
+		if err := rows.Scan(&bundleID, &slot.ID, &slot.Name, &slot.Qty, &opt.ProductID, &opt.ExtraPrice); err != nil {
 			return nil, err
 		}
-		result[p.ID] = p
+		slots := result[bundleID]
+		if n := len(slots); n == 0 || slots[n-1].ID != slot.ID {
+			slots = append(slots, slot)
+		}
+		slots[len(slots)-1].Options = append(slots[len(slots)-1].Options, opt)
+		result[bundleID] = slots
 	}
 
 	if err := rows.Err(); err != nil {
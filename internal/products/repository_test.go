@@ -0,0 +1,12 @@
+package products
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestActiveByIDsQueryTreatsArchivedAsInactive(t *testing.T) {
+	if !strings.Contains(activeByIDsQuery, "is_active AND archived_at IS NULL") {
+		t.Fatalf("archived products must come back inactive: %q", activeByIDsQuery)
+	}
+}
@@ -0,0 +1,168 @@
+package receipt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rashidmailru/kabobfood/internal/orders"
+)
+
+type alignment byte
+
+const (
+	alignLeft alignment = iota
+	alignCenter
+	alignRight
+)
+
+// line is a single printable row with its styling.
+type line struct {
+	text  string
+	align alignment
+	bold  bool
+	large bool
+}
+
+// document is a format-agnostic receipt that writers turn into bytes.
+type document struct {
+	width int
+	lines []line
+}
+
+func (d *document) add(text string, align alignment, bold, large bool) {
+	limit := d.width
+	if large {
+		limit = d.width / 2
+	}
+	for _, chunk := range wrap(text, limit) {
+		d.lines = append(d.lines, line{text: chunk, align: align, bold: bold, large: large})
+	}
+}
+
+func (d *document) text(text string) {
+	d.add(text, alignLeft, false, false)
+}
+
+func (d *document) separator() {
+	d.lines = append(d.lines, line{text: strings.Repeat("-", d.width)})
+}
+
+func (d *document) columns(left, right string, bold bool) {
+	gap := d.width - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	if gap < 1 {
+		d.add(left, alignLeft, bold, false)
+		d.add(right, alignRight, bold, false)
+		return
+	}
+	d.lines = append(d.lines, line{text: left + strings.Repeat(" ", gap) + right, bold: bold})
+}
+
+func (d *document) blank() {
+	d.lines = append(d.lines, line{})
+}
+
+var orderTypeTitles = map[string]string{
+	"delivery": "Доставка",
+	"pickup":   "Самовывоз",
+}
+
+func buildKitchenTicket(order *orders.Order, opts Options) *document {
+	doc := &document{width: opts.Width}
+	doc.add("КУХНЯ", alignCenter, true, false)
+	doc.add(fmt.Sprintf("ЗАКАЗ #%d", order.ID), alignCenter, true, true)
+	doc.add(orderTypeTitle(order.Type), alignCenter, true, false)
+	doc.add(formatTime(order.CreatedAt, opts.Location), alignCenter, false, false)
+	doc.separator()
+	for _, item := range order.Items {
+		doc.add(fmt.Sprintf("%d x %s", item.Qty, item.ProductName), alignLeft, true, false)
+		for _, c := range item.Components {
+			doc.text(fmt.Sprintf("   - %d x %s", c.Qty*item.Qty, c.ProductName))
+		}
+	}
+	doc.separator()
+	if strings.TrimSpace(order.Comment) != "" {
+		doc.text("Комментарий: " + order.Comment)
+	}
+	return doc
+}
+
+func buildCustomerReceipt(order *orders.Order, opts Options) *document {
+	doc := &document{width: opts.Width}
+	if opts.Header != "" {
+		doc.add(opts.Header, alignCenter, true, true)
+		doc.blank()
+	}
+	doc.add(fmt.Sprintf("Заказ #%d", order.ID), alignCenter, true, false)
+	doc.add(formatTime(order.CreatedAt, opts.Location), alignCenter, false, false)
+	doc.text(orderTypeTitle(order.Type))
+	if order.CustomerName != "" {
+		doc.text("Клиент: " + order.CustomerName)
+	}
+	if order.CustomerPhone != "" {
+		doc.text("Телефон: " + order.CustomerPhone)
+	}
+	doc.separator()
+	for _, item := range order.Items {
+		doc.text(item.ProductName)
+		for _, c := range item.Components {
+			doc.text("  + " + c.ProductName)
+		}
+		doc.columns(fmt.Sprintf("  %d x %s", item.Qty, formatMoney(item.Price)), formatMoney(item.Total), false)
+	}
+	doc.separator()
+	doc.columns("Товары:", formatMoney(order.ItemsTotal), false)
+	if order.DeliveryPrice > 0 {
+		doc.columns("Доставка:", formatMoney(order.DeliveryPrice), false)
+	}
+	doc.columns("ИТОГО:", formatMoney(order.TotalPrice), true)
+	if order.PaymentMethod != "" {
+		doc.text("Оплата: " + order.PaymentMethod)
+	}
+	doc.separator()
+	doc.add("Спасибо за заказ!", alignCenter, false, false)
+	return doc
+}
+
+func orderTypeTitle(orderType string) string {
+	if title, ok := orderTypeTitles[orderType]; ok {
+		return title
+	}
+	return orderType
+}
+
+func formatTime(ts time.Time, loc *time.Location) string {
+	if loc != nil {
+		ts = ts.In(loc)
+	}
+	return ts.Format("02.01.2006 15:04")
+}
+
+func formatMoney(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+// wrap splits text into chunks no longer than width runes, preferring spaces.
+func wrap(text string, width int) []string {
+	if width <= 0 || utf8.RuneCountInString(text) <= width {
+		return []string{text}
+	}
+	var out []string
+	runes := []rune(text)
+	for len(runes) > width {
+		cut := width
+		for i := width; i > width/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		out = append(out, strings.TrimRight(string(runes[:cut]), " "))
+		runes = []rune(strings.TrimLeft(string(runes[cut:]), " "))
+	}
+	if len(runes) > 0 {
+		out = append(out, string(runes))
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package receipt
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// ESC/POS command bytes.
+const (
+	esc = 0x1B
+	gs  = 0x1D
+)
+
+// uzbekFallback maps Uzbek Cyrillic letters missing from legacy code pages to their closest Russian ones.
+var uzbekFallback = strings.NewReplacer(
+	"Қ", "К", "қ", "к",
+	"Ғ", "Г", "ғ", "г",
+	"Ҳ", "Х", "ҳ", "х",
+)
+
+func encodeESCPOS(doc *document, codePage byte) []byte {
+	var buf bytes.Buffer
+	enc := encoding.ReplaceUnsupported(charmap.CodePage866.NewEncoder())
+
+	buf.Write([]byte{esc, '@'})
+	buf.Write([]byte{esc, 't', codePage})
+	for _, ln := range doc.lines {
+		buf.Write([]byte{esc, 'a', byte(ln.align)})
+		buf.Write([]byte{esc, 'E', boolByte(ln.bold)})
+		if ln.large {
+			buf.Write([]byte{gs, '!', 0x11})
+		} else {
+			buf.Write([]byte{gs, '!', 0x00})
+		}
+		encoded, err := enc.String(uzbekFallback.Replace(ln.text))
+		if err != nil {
+			encoded = ln.text
+		}
+		buf.WriteString(encoded)
+		buf.WriteByte('\n')
+	}
+	buf.Write([]byte{esc, 'E', 0})
+	buf.Write([]byte{gs, '!', 0x00})
+	buf.Write([]byte{esc, 'd', 4})
+	buf.Write([]byte{gs, 'V', 66, 0})
+	return buf.Bytes()
+}
+
+func boolByte(v bool) byte {
+	if v {
+		return 1
+	}
+	return 0
+}
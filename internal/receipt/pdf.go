@@ -0,0 +1,127 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const (
+	pdfFontSize      = 9.0
+	pdfLargeFontSize = 14.0
+	pdfCharWidth     = 0.6 // Courier advance width relative to font size.
+	pdfMargin        = 12.0
+)
+
+// pdfCyrillicEncoding maps Windows-1251 bytes to Adobe glyph names so standard Courier renders Cyrillic.
+var pdfCyrillicEncoding = buildCyrillicDifferences()
+
+func buildCyrillicDifferences() string {
+	var b strings.Builder
+	b.WriteString("[161 /afii10062 /afii10110 168 /afii10023 184 /afii10071 185 /afii61352 192")
+	upper := []int{10017, 10018, 10019, 10020, 10021, 10022}
+	for n := 10024; n <= 10049; n++ {
+		upper = append(upper, n)
+	}
+	lower := []int{10065, 10066, 10067, 10068, 10069, 10070}
+	for n := 10072; n <= 10097; n++ {
+		lower = append(lower, n)
+	}
+	for _, n := range append(upper, lower...) {
+		fmt.Fprintf(&b, " /afii%d", n)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// encodePDF writes a single-page PDF sized to the receipt using built-in Courier fonts.
+func encodePDF(doc *document) []byte {
+	lineHeights := make([]float64, len(doc.lines))
+	height := 2 * pdfMargin
+	for i, ln := range doc.lines {
+		size := pdfFontSize
+		if ln.large {
+			size = pdfLargeFontSize
+		}
+		lineHeights[i] = size * 1.3
+		height += lineHeights[i]
+	}
+	width := float64(doc.width)*pdfFontSize*pdfCharWidth + 2*pdfMargin
+
+	enc := encoding.ReplaceUnsupported(charmap.Windows1251.NewEncoder())
+	var content bytes.Buffer
+	y := height - pdfMargin
+	for i, ln := range doc.lines {
+		y -= lineHeights[i]
+		if ln.text == "" {
+			continue
+		}
+		size := pdfFontSize
+		if ln.large {
+			size = pdfLargeFontSize
+		}
+		font := "F1"
+		if ln.bold {
+			font = "F2"
+		}
+		textWidth := float64(len([]rune(ln.text))) * size * pdfCharWidth
+		x := pdfMargin
+		switch ln.align {
+		case alignCenter:
+			x = (width - textWidth) / 2
+		case alignRight:
+			x = width - pdfMargin - textWidth
+		}
+		encoded, err := enc.String(uzbekFallback.Replace(ln.text))
+		if err != nil {
+			encoded = ln.text
+		}
+		fmt.Fprintf(&content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, escapePDFString(encoded))
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 7 0 R >>", width, height),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding 6 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding 6 0 R >>",
+		"<< /Type /Encoding /BaseEncoding /WinAnsiEncoding /Differences " + pdfCyrillicEncoding + " >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// escapePDFString escapes delimiters and writes non-ASCII bytes as octal so the file stays 7-bit.
+func escapePDFString(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c > 0x7E:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package receipt
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/rashidmailru/kabobfood/internal/orders"
+)
+
+// NetworkPrinter sends ESC/POS jobs to a raw TCP printer (port 9100 on most models).
+type NetworkPrinter struct {
+	addr     string
+	timeout  time.Duration
+	layouts  []Layout
+	renderer *Renderer
+	log      *zap.Logger
+}
+
+// PrinterConfig holds network printer settings.
+type PrinterConfig struct {
+	Addr     string
+	Timeout  time.Duration
+	Layouts  []Layout
+	Renderer *Renderer
+	Logger   *zap.Logger
+}
+
+// NewNetworkPrinter builds printer; it returns nil when no address is configured.
+func NewNetworkPrinter(cfg PrinterConfig) *NetworkPrinter {
+	if cfg.Addr == "" {
+		return nil
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	layouts := cfg.Layouts
+	if len(layouts) == 0 {
+		layouts = []Layout{LayoutKitchen}
+	}
+	renderer := cfg.Renderer
+	if renderer == nil {
+		renderer = NewRenderer(Options{})
+	}
+	log := cfg.Logger
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &NetworkPrinter{addr: cfg.Addr, timeout: timeout, layouts: layouts, renderer: renderer, log: log}
+}
+
+// PrintOrder renders every configured layout and sends them to the printer.
+func (p *NetworkPrinter) PrintOrder(ctx context.Context, order *orders.Order) error {
+	for _, layout := range p.layouts {
+		data, err := p.renderer.Render(order, layout, FormatESCPOS)
+		if err != nil {
+			return err
+		}
+		if err := p.Send(ctx, data); err != nil {
+			p.log.Warn("receipt print failed",
+				zap.Int64("order_id", order.ID),
+				zap.String("layout", string(layout)),
+				zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// Send writes a raw job to the printer.
+func (p *NetworkPrinter) Send(ctx context.Context, data []byte) error {
+	if len(data) == 0 {
+		return errors.New("receipt: empty print job")
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	}
+	_, err = conn.Write(data)
+	return err
+}
@@ -0,0 +1,101 @@
+package receipt
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/rashidmailru/kabobfood/internal/orders"
+)
+
+func testOrder() *orders.Order {
+	return &orders.Order{
+		ID:            42,
+		Type:          "delivery",
+		PaymentMethod: "cash",
+		DeliveryPrice: 5000,
+		ItemsTotal:    50000,
+		TotalPrice:    55000,
+		CustomerName:  "Алишер",
+		CreatedAt:     time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+		Items: []orders.OrderItem{
+			{ID: 1, ProductName: "Шашлык из баранины", Qty: 2, Price: 25000, Total: 50000},
+		},
+	}
+}
+
+func TestRenderESCPOSUsesCyrillicCodePage(t *testing.T) {
+	t.Parallel()
+
+	r := NewRenderer(Options{Width: 32, Location: time.UTC})
+	data, err := r.Render(testOrder(), LayoutKitchen, FormatESCPOS)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte{esc, '@', esc, 't', 17}) {
+		t.Fatalf("missing init/code page prefix: % x", data[:5])
+	}
+	if !bytes.HasSuffix(data, []byte{gs, 'V', 66, 0}) {
+		t.Fatal("missing paper cut")
+	}
+	// "Шашлык" in CP866.
+	if !bytes.Contains(data, []byte{0x98, 0xA0, 0xE8, 0xAB, 0xEB, 0xAA}) {
+		t.Fatal("product name is not encoded in CP866")
+	}
+}
+
+func TestNetworkPrinterSendsJob(t *testing.T) {
+	t.Parallel()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	received := make(chan []byte, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			data, _ := io.ReadAll(conn)
+			conn.Close()
+			received <- data
+		}
+	}()
+
+	renderer := NewRenderer(Options{Location: time.UTC})
+	printer := NewNetworkPrinter(PrinterConfig{
+		Addr:     ln.Addr().String(),
+		Layouts:  []Layout{LayoutKitchen, LayoutCustomer},
+		Renderer: renderer,
+	})
+	if err := printer.PrintOrder(context.Background(), testOrder()); err != nil {
+		t.Fatalf("print: %v", err)
+	}
+
+	for _, layout := range []Layout{LayoutKitchen, LayoutCustomer} {
+		expected, _ := renderer.Render(testOrder(), layout, FormatESCPOS)
+		select {
+		case got := <-received:
+			if !bytes.Equal(got, expected) {
+				t.Fatalf("%s job mismatch", layout)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%s job not received", layout)
+		}
+	}
+}
+
+func TestNewNetworkPrinterWithoutAddr(t *testing.T) {
+	t.Parallel()
+
+	if p := NewNetworkPrinter(PrinterConfig{}); p != nil {
+		t.Fatal("expected nil printer without address")
+	}
+}
@@ -0,0 +1,147 @@
+package receipt
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rashidmailru/kabobfood/internal/orders"
+)
+
+// Format selects the output encoding of a receipt.
+type Format string
+
+// Supported receipt formats.
+const (
+	FormatESCPOS Format = "escpos"
+	FormatText   Format = "text"
+	FormatPDF    Format = "pdf"
+)
+
+// Layout selects which receipt is printed.
+type Layout string
+
+// Supported receipt layouts.
+const (
+	LayoutKitchen  Layout = "kitchen"
+	LayoutCustomer Layout = "customer"
+)
+
+// ErrUnsupportedFormat is returned for unknown formats or layouts.
+var ErrUnsupportedFormat = errors.New("unsupported receipt format")
+
+// Options configure receipt rendering.
+type Options struct {
+	// Width is the line width in characters (32 for 58mm, 48 for 80mm paper).
+	Width int
+	// CodePage is the ESC t value selecting PC866 Cyrillic on the printer.
+	CodePage byte
+	// Header is printed on top of customer receipts.
+	Header   string
+	Location *time.Location
+}
+
+// Renderer turns orders into printable receipts.
+type Renderer struct {
+	opts Options
+}
+
+// NewRenderer builds renderer applying defaults.
+func NewRenderer(opts Options) *Renderer {
+	if opts.Width <= 0 {
+		opts.Width = 48
+	}
+	if opts.CodePage == 0 {
+		opts.CodePage = 17
+	}
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+	return &Renderer{opts: opts}
+}
+
+// ParseFormat validates a format name.
+func ParseFormat(raw string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(raw))); f {
+	case FormatESCPOS, FormatText, FormatPDF:
+		return f, nil
+	case "":
+		return FormatText, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ParseLayout validates a layout name.
+func ParseLayout(raw string) (Layout, error) {
+	switch l := Layout(strings.ToLower(strings.TrimSpace(raw))); l {
+	case LayoutKitchen, LayoutCustomer:
+		return l, nil
+	case "":
+		return LayoutCustomer, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ContentType returns MIME type for the format.
+func ContentType(format Format) string {
+	switch format {
+	case FormatPDF:
+		return "application/pdf"
+	case FormatESCPOS:
+		return "application/octet-stream"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Render renders order in the requested layout and format.
+func (r *Renderer) Render(order *orders.Order, layout Layout, format Format) ([]byte, error) {
+	if order == nil {
+		return nil, errors.New("receipt: nil order")
+	}
+	var doc *document
+	switch layout {
+	case LayoutKitchen:
+		doc = buildKitchenTicket(order, r.opts)
+	case LayoutCustomer:
+		doc = buildCustomerReceipt(order, r.opts)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	switch format {
+	case FormatESCPOS:
+		return encodeESCPOS(doc, r.opts.CodePage), nil
+	case FormatText:
+		return encodeText(doc), nil
+	case FormatPDF:
+		return encodePDF(doc), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func encodeText(doc *document) []byte {
+	var b strings.Builder
+	for _, ln := range doc.lines {
+		b.WriteString(alignText(ln.text, ln.align, doc.width))
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+func alignText(text string, align alignment, width int) string {
+	pad := width - len([]rune(text))
+	if pad <= 0 {
+		return text
+	}
+	switch align {
+	case alignCenter:
+		return strings.Repeat(" ", pad/2) + text
+	case alignRight:
+		return strings.Repeat(" ", pad) + text
+	default:
+		return text
+	}
+}
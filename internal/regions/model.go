@@ -1,9 +1,12 @@
 package regions
 
+import "time"
+
 // Region describes delivery region metadata.
 type Region struct {
-	ID            int64   `json:"id"`
-	Name          string  `json:"name"`
-	DeliveryPrice float64 `json:"delivery_price"`
-	IsActive      bool    `json:"is_active"`
+	ID            int64      `json:"id"`
+	Name          string     `json:"name"`
+	DeliveryPrice float64    `json:"delivery_price"`
+	IsActive      bool       `json:"is_active"`
+	ArchivedAt    *time.Time `json:"archived_at,omitempty"`
 }
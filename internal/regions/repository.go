@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,6 +20,16 @@ func NewRepository(pool *pgxpool.Pool) *Repository {
 
 var errNilPool = errors.New("regions repository: nil pool")
 
+const regionColumns = `id, name, delivery_price, is_active, archived_at`
+
+func scanRegion(row pgx.Row) (*Region, error) {
+	var res Region
+	if err := row.Scan(&res.ID, &res.Name, &res.DeliveryPrice, &res.IsActive, &res.ArchivedAt); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
 // Insert adds a region.
 func (r *Repository) Insert(ctx context.Context, region Region) (*Region, error) {
 	if r.pool == nil {
@@ -27,14 +38,9 @@ func (r *Repository) Insert(ctx context.Context, region Region) (*Region, error)
 	const query = `
 INSERT INTO regions (name, delivery_price, is_active)
 VALUES ($1,$2,$3)
-RETURNING id, name, delivery_price, is_active;
+RETURNING ` + regionColumns + `;
 `
-	row := r.pool.QueryRow(ctx, query, region.Name, region.DeliveryPrice, region.IsActive)
-	var res Region
-	if err := row.Scan(&res.ID, &res.Name, &res.DeliveryPrice, &res.IsActive); err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return scanRegion(r.pool.QueryRow(ctx, query, region.Name, region.DeliveryPrice, region.IsActive))
 }
 
 // Update modifies region.
@@ -45,40 +51,53 @@ func (r *Repository) Update(ctx context.Context, region Region) (*Region, error)
 	const query = `
 UPDATE regions SET name=$1, delivery_price=$2, is_active=$3
 WHERE id=$4
-RETURNING id, name, delivery_price, is_active;
+RETURNING ` + regionColumns + `;
 `
-	row := r.pool.QueryRow(ctx, query, region.Name, region.DeliveryPrice, region.IsActive, region.ID)
-	var res Region
-	if err := row.Scan(&res.ID, &res.Name, &res.DeliveryPrice, &res.IsActive); err != nil {
-		return nil, err
-	}
-	return &res, nil
+	return scanRegion(r.pool.QueryRow(ctx, query, region.Name, region.DeliveryPrice, region.IsActive, region.ID))
 }
 
-// Delete removes region.
-func (r *Repository) Delete(ctx context.Context, id int64) error {
+// Archive hides a region from GET /regions and new orders; past orders and
+// addresses keep referencing it. Archiving twice keeps the first timestamp.
+func (r *Repository) Archive(ctx context.Context, id int64) (*Region, error) {
 	if r.pool == nil {
-		return errNilPool
+		return nil, errNilPool
 	}
-	const query = `DELETE FROM regions WHERE id = $1;`
-	_, err := r.pool.Exec(ctx, query, id)
-	return err
+	const query = `
+UPDATE regions SET archived_at = COALESCE(archived_at, NOW())
+WHERE id = $1
+RETURNING ` + regionColumns + `;
+`
+	return scanRegion(r.pool.QueryRow(ctx, query, id))
 }
 
-// GetActiveRegions returns currently active regions sorted by name.
-func (r *Repository) GetActiveRegions(ctx context.Context) ([]Region, error) {
+const restoreQuery = `
+UPDATE regions SET archived_at = NULL
+WHERE id = $1
+RETURNING ` + regionColumns + `;
+`
+
+// Restore brings an archived region back.
+func (r *Repository) Restore(ctx context.Context, id int64) (*Region, error) {
 	if r.pool == nil {
 		return nil, errNilPool
 	}
+	return scanRegion(r.pool.QueryRow(ctx, restoreQuery, id))
+}
 
-	const query = `
-SELECT id, name, delivery_price, is_active
+const activeRegionsQuery = `
+SELECT ` + regionColumns + `
 FROM regions
-WHERE is_active = TRUE
+WHERE is_active = TRUE AND archived_at IS NULL
 ORDER BY id;
 `
 
-	rows, err := r.pool.Query(ctx, query)
+// GetActiveRegions returns currently active, non-archived regions sorted by id.
+func (r *Repository) GetActiveRegions(ctx context.Context) ([]Region, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+
+	rows, err := r.pool.Query(ctx, activeRegionsQuery)
 	if err != nil {
 		return nil, err
 	}
@@ -86,11 +105,11 @@ ORDER BY id;
 
 	var result []Region
 	for rows.Next() {
-		var region Region
-		if err := rows.Scan(&region.ID, &region.Name, &region.DeliveryPrice, &region.IsActive); err != nil {
+		region, err := scanRegion(rows)
+		if err != nil {
 			return nil, err
 		}
-		result = append(result, region)
+		result = append(result, *region)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -100,22 +119,49 @@ ORDER BY id;
 	return result, nil
 }
 
-// GetByID returns region by id.
+// GetByID returns region by id, including archived ones so old orders still resolve.
 func (r *Repository) GetByID(ctx context.Context, id int64) (*Region, error) {
 	if r.pool == nil {
 		return nil, errNilPool
 	}
+	return scanRegion(r.pool.QueryRow(ctx, `SELECT `+regionColumns+` FROM regions WHERE id = $1;`, id))
+}
 
-	const query = `
-SELECT id, name, delivery_price, is_active
-FROM regions
-WHERE id = $1;
-`
+// ListAll returns regions for the admin panel, including inactive ones.
+// A nil archived matches both archived and live regions.
+func (r *Repository) ListAll(ctx context.Context, archived *bool) ([]Region, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	rows, err := r.pool.Query(ctx, listQuery(archived))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	row := r.pool.QueryRow(ctx, query, id)
-	var region Region
-	if err := row.Scan(&region.ID, &region.Name, &region.DeliveryPrice, &region.IsActive); err != nil {
+	result := []Region{}
+	for rows.Next() {
+		region, err := scanRegion(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *region)
+	}
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return &region, nil
+	return result, nil
+}
+
+// listQuery builds the ListAll query; a nil archived adds no filter.
+func listQuery(archived *bool) string {
+	query := `SELECT ` + regionColumns + ` FROM regions`
+	if archived != nil {
+		if *archived {
+			query += ` WHERE archived_at IS NOT NULL`
+		} else {
+			query += ` WHERE archived_at IS NULL`
+		}
+	}
+	return query + ` ORDER BY id;`
 }
@@ -0,0 +1,32 @@
+package regions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestArchivedQueries(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		exclude string
+	}{
+		{name: "public regions", query: activeRegionsQuery, want: "archived_at IS NULL"},
+		{name: "admin listing", query: listQuery(nil), exclude: "archived_at IS"},
+		{name: "archived only", query: listQuery(&yes), want: "WHERE archived_at IS NOT NULL"},
+		{name: "live only", query: listQuery(&no), want: "WHERE archived_at IS NULL"},
+		{name: "restore", query: restoreQuery, want: "SET archived_at = NULL"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.want != "" && !strings.Contains(tc.query, tc.want) {
+				t.Errorf("query %q does not contain %q", tc.query, tc.want)
+			}
+			if tc.exclude != "" && strings.Contains(tc.query, tc.exclude) {
+				t.Errorf("query %q must not contain %q", tc.query, tc.exclude)
+			}
+		})
+	}
+}
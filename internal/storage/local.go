@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local stores objects on the filesystem; the API serves them under publicURL.
+type Local struct {
+	dir       string
+	publicURL string
+}
+
+// NewLocal builds filesystem storage rooted at dir.
+func NewLocal(dir, publicURL string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Local{dir: dir, publicURL: publicURL}, nil
+}
+
+// Dir returns the root directory.
+func (l *Local) Dir() string {
+	return l.dir
+}
+
+// Put writes the object atomically via a temporary file.
+func (l *Local) Put(_ context.Context, obj Object) error {
+	path, err := l.path(obj.Key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(obj.Data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Delete removes the object.
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// URL returns the public URL of key.
+func (l *Local) URL(key string) string {
+	return joinURL(l.publicURL, key)
+}
+
+// Key returns the key of an URL built by URL.
+func (l *Local) Key(url string) (string, bool) {
+	return keyFromURL(l.publicURL, url)
+}
+
+// path maps key into dir and rejects keys escaping it.
+func (l *Local) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", errors.New("invalid object key")
+	}
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory keeps objects in a map; it is meant for tests.
+type Memory struct {
+	mu        sync.Mutex
+	objects   map[string]Object
+	publicURL string
+}
+
+// NewMemory builds an empty in-memory storage.
+func NewMemory(publicURL string) *Memory {
+	return &Memory{objects: map[string]Object{}, publicURL: publicURL}
+}
+
+// Put stores a copy of the object.
+func (m *Memory) Put(_ context.Context, obj Object) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj.Data = append([]byte(nil), obj.Data...)
+	m.objects[obj.Key] = obj
+	return nil
+}
+
+// Delete removes the object.
+func (m *Memory) Delete(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.objects[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.objects, key)
+	return nil
+}
+
+// URL returns the public URL of key.
+func (m *Memory) URL(key string) string {
+	return joinURL(m.publicURL, key)
+}
+
+// Key returns the key of an URL built by URL.
+func (m *Memory) Key(url string) (string, bool) {
+	return keyFromURL(m.publicURL, url)
+}
+
+// Get returns the stored object.
+func (m *Memory) Get(key string) (Object, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	obj, ok := m.objects[key]
+	return obj, ok
+}
+
+// Len returns the number of stored objects.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.objects)
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3-compatible bucket (AWS, MinIO, Yandex Object Storage).
+type S3Config struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket as endpoint/bucket instead of bucket.endpoint.
+	PathStyle bool
+	// PublicURL overrides the object URL base, e.g. a CDN in front of the bucket.
+	PublicURL string
+}
+
+// S3 stores objects in an S3-compatible bucket using SigV4-signed requests.
+type S3 struct {
+	cfg    S3Config
+	base   *neturl.URL
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3 builds an S3 storage; client defaults to a 30s timeout.
+func NewS3(cfg S3Config, client *http.Client) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 storage: endpoint and bucket are required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	base, err := neturl.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("s3 storage: invalid endpoint %q", cfg.Endpoint)
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &S3{cfg: cfg, base: base, client: client, now: time.Now}, nil
+}
+
+// Put uploads the object with an immutable Cache-Control header.
+func (s *S3) Put(ctx context.Context, obj Object) error {
+	req, err := s.request(ctx, http.MethodPut, obj.Key, obj.Data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", obj.ContentType)
+	req.Header.Set("Cache-Control", CacheControl)
+	return s.do(req, obj.Data)
+}
+
+// Delete removes the object. S3 reports success for missing keys too.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req, nil)
+}
+
+// URL returns the public URL of key.
+func (s *S3) URL(key string) string {
+	if s.cfg.PublicURL != "" {
+		return joinURL(s.cfg.PublicURL, key)
+	}
+	return s.objectURL(key).String()
+}
+
+// Key returns the key of an URL built by URL.
+func (s *S3) Key(url string) (string, bool) {
+	base := s.cfg.PublicURL
+	if base == "" {
+		base = s.objectURL("").String()
+	}
+	key, ok := keyFromURL(base, url)
+	if !ok {
+		return "", false
+	}
+	if unescaped, err := neturl.PathUnescape(key); err == nil {
+		key = unescaped
+	}
+	return key, true
+}
+
+func (s *S3) objectURL(key string) *neturl.URL {
+	u := *s.base
+	key = strings.TrimLeft(key, "/")
+	prefix := strings.TrimRight(u.Path, "/") + "/"
+	if s.cfg.PathStyle {
+		prefix += s.cfg.Bucket + "/"
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	u.Path = prefix + key
+	u.RawPath = escapePath(prefix) + escapePath(key)
+	return &u
+}
+
+func (s *S3) request(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	u := s.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	return req, nil
+}
+
+func (s *S3) do(req *http.Request, body []byte) error {
+	sign(req, body, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, s.now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// escapePath percent-encodes every segment the way SigV4 canonical URIs expect.
+func escapePath(p string) string {
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		segments[i] = uriEncode(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+func uriEncode(s string) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,44 @@
+// Package storage keeps uploaded files such as product images.
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrNotFound is returned when a key does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// CacheControl is sent with stored objects; keys are content-addressed, so a
+// stored object never changes.
+const CacheControl = "public, max-age=31536000, immutable"
+
+// Object is a file to store under Key.
+type Object struct {
+	Key         string
+	ContentType string
+	Data        []byte
+}
+
+// Storage persists objects and maps keys to public URLs.
+type Storage interface {
+	Put(ctx context.Context, obj Object) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+	// Key reverses URL; ok is false for URLs this storage does not own.
+	Key(url string) (key string, ok bool)
+}
+
+func keyFromURL(publicBase, url string) (string, bool) {
+	prefix := strings.TrimRight(publicBase, "/") + "/"
+	key, ok := strings.CutPrefix(url, prefix)
+	if !ok || key == "" {
+		return "", false
+	}
+	return key, true
+}
+
+func joinURL(base, key string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(key, "/")
+}
@@ -0,0 +1,75 @@
+package tokens
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Denylist remembers revoked access token ids until the tokens expire.
+// Entries go to Redis so every instance sees them, and to process memory
+// which answers when Redis is absent or failing.
+type Denylist struct {
+	client *redis.Client
+	prefix string
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+// NewDenylist builds denylist; client may be nil.
+func NewDenylist(client *redis.Client, now func() time.Time) *Denylist {
+	if now == nil {
+		now = time.Now
+	}
+	return &Denylist{client: client, prefix: "jwt:deny:", now: now, entries: make(map[string]time.Time)}
+}
+
+// Revoke denies jti until expiresAt.
+func (d *Denylist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := expiresAt.Sub(d.now())
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	d.mu.Lock()
+	d.sweep()
+	d.entries[jti] = expiresAt
+	d.mu.Unlock()
+	if d.client == nil {
+		return nil
+	}
+	return d.client.Set(ctx, d.prefix+jti, 1, ttl).Err()
+}
+
+// IsRevoked reports whether jti was revoked.
+func (d *Denylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	d.mu.Lock()
+	until, ok := d.entries[jti]
+	d.mu.Unlock()
+	if ok && d.now().Before(until) {
+		return true, nil
+	}
+	if d.client == nil {
+		return false, nil
+	}
+	n, err := d.client.Exists(ctx, d.prefix+jti).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (d *Denylist) sweep() {
+	now := d.now()
+	for jti, until := range d.entries {
+		if !now.Before(until) {
+			delete(d.entries, jti)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrUnknownKey is returned for tokens signed with a kid the keyring does not hold.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// Key is a JWT signing or verification key identified by kid.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// NotAfter retires the key: tokens signed with it are rejected afterwards. Zero keeps it forever.
+	NotAfter time.Time
+
+	signKey   any
+	verifyKey any
+}
+
+// CanSign reports whether the key holds private material.
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// HMACSecret returns the shared secret of an HS256 key.
+func (k *Key) HMACSecret() (string, bool) {
+	secret, ok := k.verifyKey.([]byte)
+	return string(secret), ok
+}
+
+// NewHMACKey builds an HS256 key; kid defaults to a fingerprint of the secret.
+func NewHMACKey(kid, secret string) *Key {
+	if kid == "" {
+		sum := sha256.Sum256([]byte(secret))
+		kid = "hs-" + hex.EncodeToString(sum[:4])
+	}
+	return &Key{ID: kid, Method: jwt.SigningMethodHS256, signKey: []byte(secret), verifyKey: []byte(secret)}
+}
+
+// ParseKeySpec parses "kid=ALG:material[@until]". ALG is HS256, EdDSA or RS256.
+// Material is the HMAC secret or "file:/path" to a PEM private key (or public key
+// for verify-only keys); until is an RFC 3339 time after which the key is retired.
+func ParseKeySpec(spec string) (*Key, error) {
+	kid, rest, ok := strings.Cut(strings.TrimSpace(spec), "=")
+	if !ok || kid == "" {
+		return nil, fmt.Errorf("key spec %q: expected kid=ALG:material", spec)
+	}
+	alg, material, ok := strings.Cut(rest, ":")
+	if !ok || material == "" {
+		return nil, fmt.Errorf("key %s: expected ALG:material", kid)
+	}
+	// Only an RFC 3339 suffix retires the key, so HMAC secrets may contain "@".
+	var notAfter time.Time
+	if i := strings.LastIndex(material, "@"); i > 0 {
+		if t, err := time.Parse(time.RFC3339, material[i+1:]); err == nil {
+			notAfter, material = t, material[:i]
+		}
+	}
+	data := []byte(material)
+	if path, isFile := strings.CutPrefix(material, "file:"); isFile {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		data = raw
+	}
+
+	key := &Key{ID: kid, NotAfter: notAfter}
+	switch strings.ToUpper(alg) {
+	case "HS256":
+		secret := strings.TrimSpace(string(data))
+		if secret == "" {
+			return nil, fmt.Errorf("key %s: empty secret", kid)
+		}
+		key.Method, key.signKey, key.verifyKey = jwt.SigningMethodHS256, []byte(secret), []byte(secret)
+	case "EDDSA", "ED25519":
+		key.Method = jwt.SigningMethodEdDSA
+		if priv, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+			signer, ok := priv.(crypto.Signer)
+			if !ok {
+				return nil, fmt.Errorf("key %s: unsupported Ed25519 key", kid)
+			}
+			key.signKey, key.verifyKey = priv, signer.Public()
+		} else if pub, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+			key.verifyKey = pub
+		} else {
+			return nil, fmt.Errorf("key %s: invalid Ed25519 PEM", kid)
+		}
+	case "RS256":
+		key.Method = jwt.SigningMethodRS256
+		if priv, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			key.signKey, key.verifyKey = priv, &priv.PublicKey
+		} else if pub, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+			key.verifyKey = pub
+		} else {
+			return nil, fmt.Errorf("key %s: invalid RSA PEM", kid)
+		}
+	default:
+		return nil, fmt.Errorf("key %s: unsupported algorithm %q", kid, alg)
+	}
+	return key, nil
+}
+
+// Keyring signs tokens with its active key and verifies tokens signed by any
+// of its keys, pinning issuer and audience.
+type Keyring struct {
+	issuer   string
+	audience string
+	active   *Key
+	keys     map[string]*Key
+	methods  []string
+	legacy   *Key
+	now      func() time.Time
+}
+
+// NewKeyring builds a keyring; the first key signs new tokens, the rest only verify.
+func NewKeyring(issuer, audience string, keys []*Key, now func() time.Time) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+	if !keys[0].CanSign() {
+		return nil, fmt.Errorf("active key %s has no private material", keys[0].ID)
+	}
+	if now == nil {
+		now = time.Now
+	}
+	k := &Keyring{issuer: issuer, audience: audience, active: keys[0], keys: make(map[string]*Key, len(keys)), now: now}
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if _, dup := k.keys[key.ID]; dup {
+			return nil, fmt.Errorf("duplicate key id %s", key.ID)
+		}
+		k.keys[key.ID] = key
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			k.methods = append(k.methods, alg)
+		}
+	}
+	return k, nil
+}
+
+// Sign sets iss and aud on claims and signs them with the active key.
+func (k *Keyring) Sign(claims jwt.MapClaims) (string, error) {
+	if k.issuer != "" {
+		claims["iss"] = k.issuer
+	}
+	if k.audience != "" {
+		claims["aud"] = k.audience
+	}
+	token := jwt.NewWithClaims(k.active.Method, claims)
+	token.Header["kid"] = k.active.ID
+	return token.SignedString(k.active.signKey)
+}
+
+// AcceptLegacy verifies tokens issued before key ids existed, which carry no
+// kid, iss or aud, against secret until the cutoff.
+func (k *Keyring) AcceptLegacy(secret string, until time.Time) {
+	k.legacy = NewHMACKey("", secret)
+	k.legacy.NotAfter = until
+}
+
+// Parse verifies signature, kid, expiry, issuer and audience and returns the claims.
+func (k *Keyring) Parse(tokenString string) (jwt.MapClaims, error) {
+	if k.legacy != nil && k.now().Before(k.legacy.NotAfter) {
+		if token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{}); err == nil {
+			if _, hasKid := token.Header["kid"]; !hasKid {
+				return k.parseLegacy(tokenString)
+			}
+		}
+	}
+	opts := []jwt.ParserOption{jwt.WithValidMethods(k.methods), jwt.WithExpirationRequired()}
+	if k.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(k.issuer))
+	}
+	if k.audience != "" {
+		opts = append(opts, jwt.WithAudience(k.audience))
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, k.keyFunc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// parseLegacy verifies a kid-less token with the legacy secret, without pinning
+// issuer and audience.
+func (k *Keyring) parseLegacy(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	keyFunc := func(*jwt.Token) (any, error) { return k.legacy.verifyKey, nil }
+	_, err := jwt.ParseWithClaims(tokenString, claims, keyFunc, jwt.WithValidMethods([]string{k.legacy.Method.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (k *Keyring) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("signing method does not match key")
+	}
+	if !key.NotAfter.IsZero() && k.now().After(key.NotAfter) {
+		return nil, fmt.Errorf("signing key %s retired", kid)
+	}
+	return key.verifyKey, nil
+}
@@ -0,0 +1,181 @@
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func claimsFor(now time.Time) jwt.MapClaims {
+	return jwt.MapClaims{"sub": 1, "exp": now.Add(time.Hour).Unix()}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	oldKey := NewHMACKey("old", "old-secret")
+	oldRing, err := NewKeyring("kabobfood", "users", []*Key{oldKey}, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued, err := oldRing.Sign(claimsFor(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldKey.NotAfter = now.Add(time.Hour)
+	ring, err := NewKeyring("kabobfood", "users", []*Key{NewHMACKey("new", "new-secret"), oldKey}, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ring.Parse(issued); err != nil {
+		t.Fatalf("token signed with previous key must verify during grace period: %v", err)
+	}
+
+	fresh, err := ring.Sign(claimsFor(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oldRing.Parse(fresh); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected unknown key, got %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	issued, _ = oldRing.Sign(claimsFor(now))
+	if _, err := ring.Parse(issued); err == nil {
+		t.Fatal("retired key must be rejected")
+	}
+}
+
+func TestKeyringAudience(t *testing.T) {
+	key := NewHMACKey("shared", "secret")
+	users, _ := NewKeyring("kabobfood", "kabobfood-users", []*Key{key}, nil)
+	staff, _ := NewKeyring("kabobfood", "kabobfood-admin", []*Key{key}, nil)
+
+	token, err := users.Sign(claimsFor(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := staff.Parse(token); err == nil {
+		t.Fatal("user token must not be accepted by the admin keyring")
+	}
+	claims, err := users.Parse(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims["iss"] != "kabobfood" {
+		t.Fatalf("unexpected issuer %v", claims["iss"])
+	}
+}
+
+func TestParseKeySpecEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writePEM := func(name, typ string, der []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	privPath := writePEM("signing.pem", "PRIVATE KEY", privDER)
+	pubPath := writePEM("verify.pem", "PUBLIC KEY", pubDER)
+
+	signer, err := ParseKeySpec("ed1=EdDSA:file:" + privPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := ParseKeySpec("ed1=EdDSA:file:" + pubPath + "@2030-01-01T00:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verifier.CanSign() || verifier.NotAfter.Year() != 2030 {
+		t.Fatalf("unexpected verify-only key %+v", verifier)
+	}
+	if _, err := NewKeyring("kabobfood", "users", []*Key{verifier}, nil); err == nil {
+		t.Fatal("verify-only key must not become the active key")
+	}
+
+	issuer, _ := NewKeyring("kabobfood", "users", []*Key{signer}, nil)
+	token, err := issuer.Sign(claimsFor(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmac := NewHMACKey("hs", "secret")
+	checker, _ := NewKeyring("kabobfood", "users", []*Key{hmac, verifier}, nil)
+	if _, err := checker.Parse(token); err != nil {
+		t.Fatalf("expected Ed25519 token to verify: %v", err)
+	}
+}
+
+func TestParseKeySpecErrors(t *testing.T) {
+	for _, spec := range []string{"", "nokid", "k=HS256:", "k=XX99:secret", "k=EdDSA:not-a-pem"} {
+		if _, err := ParseKeySpec(spec); err == nil {
+			t.Fatalf("expected error for %q", spec)
+		}
+	}
+}
+
+func TestParseKeySpecSecretWithAt(t *testing.T) {
+	key, err := ParseKeySpec("k=HS256:p@ss@word")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret, _ := key.HMACSecret(); secret != "p@ss@word" || !key.NotAfter.IsZero() {
+		t.Fatalf("expected the whole secret and no retirement, got %q %v", secret, key.NotAfter)
+	}
+	key, err = ParseKeySpec("k=HS256:p@ss@2030-01-01T00:00:00Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret, _ := key.HMACSecret(); secret != "p@ss" || key.NotAfter.Year() != 2030 {
+		t.Fatalf("expected retirement split off, got %q %v", secret, key.NotAfter)
+	}
+}
+
+func TestKeyringLegacyTokens(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	ring, err := NewKeyring("kabobfood", "users", []*Key{NewHMACKey("k2", "new-secret")}, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claimsFor(now)).SignedString([]byte("old-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ring.Parse(legacy); err == nil {
+		t.Fatal("kid-less token must be rejected without a legacy secret")
+	}
+
+	ring.AcceptLegacy("old-secret", now.Add(time.Hour))
+	if _, err := ring.Parse(legacy); err != nil {
+		t.Fatalf("expected legacy token to verify before the cutoff: %v", err)
+	}
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claimsFor(now)).SignedString([]byte("other-secret"))
+	if _, err := ring.Parse(forged); err == nil {
+		t.Fatal("kid-less token signed with another secret must be rejected")
+	}
+	current, _ := ring.Sign(claimsFor(now))
+	if _, err := ring.Parse(current); err != nil {
+		t.Fatalf("expected current token to verify: %v", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, err := ring.Parse(legacy); err == nil {
+		t.Fatal("legacy token must be rejected after the cutoff")
+	}
+}
@@ -0,0 +1,45 @@
+package tokens
+
+import "time"
+
+// Subject kinds that can hold refresh tokens.
+const (
+	SubjectUser  = "user"
+	SubjectAdmin = "admin"
+)
+
+// Subject identifies a token owner.
+type Subject struct {
+	Kind string
+	ID   int64
+}
+
+// Access is a signed short-lived JWT and the data needed to revoke it.
+type Access struct {
+	Token     string
+	JTI       string
+	ExpiresAt time.Time
+}
+
+// Session is returned to clients after login or refresh.
+type Session struct {
+	Token            string     `json:"token"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	RefreshToken     string     `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time `json:"refresh_expires_at,omitempty"`
+}
+
+// RefreshToken is a stored refresh token; only the hash of the secret is kept.
+// Tokens rotated from the same login share FamilyID.
+type RefreshToken struct {
+	ID              int64
+	SubjectKind     string
+	SubjectID       int64
+	FamilyID        string
+	TokenHash       string
+	AccessJTI       string
+	AccessExpiresAt time.Time
+	ExpiresAt       time.Time
+	RevokedAt       *time.Time
+	CreatedAt       time.Time
+}
@@ -0,0 +1,133 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists refresh tokens.
+type Repository struct {
+	pool *pgxpool.Pool
+}
+
+// NewRepository builds repository.
+func NewRepository(pool *pgxpool.Pool) *Repository {
+	return &Repository{pool: pool}
+}
+
+var (
+	errNilPool = errors.New("tokens repository: nil pool")
+	// ErrTokenNotFound is returned when no refresh token matches the hash.
+	ErrTokenNotFound = errors.New("refresh token not found")
+	// errAlreadyRevoked is returned by Replace when another request rotated the token first.
+	errAlreadyRevoked = errors.New("refresh token already revoked")
+)
+
+const tokenColumns = `id, subject_kind, subject_id, family_id, token_hash, access_jti, access_expires_at, expires_at, revoked_at, created_at`
+
+// Create stores a refresh token.
+func (r *Repository) Create(ctx context.Context, token RefreshToken) (*RefreshToken, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	return scanToken(r.pool.QueryRow(ctx, insertTokenQuery, token.SubjectKind, token.SubjectID, token.FamilyID, token.TokenHash, token.AccessJTI, token.AccessExpiresAt, token.ExpiresAt))
+}
+
+const insertTokenQuery = `
+INSERT INTO refresh_tokens (subject_kind, subject_id, family_id, token_hash, access_jti, access_expires_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING ` + tokenColumns + `;
+`
+
+// GetByHash returns the refresh token with hash.
+func (r *Repository) GetByHash(ctx context.Context, hash string) (*RefreshToken, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	return scanToken(r.pool.QueryRow(ctx, `SELECT `+tokenColumns+` FROM refresh_tokens WHERE token_hash = $1;`, hash))
+}
+
+// Replace revokes oldID and stores next in one transaction. It fails with
+// errAlreadyRevoked if oldID was revoked concurrently.
+func (r *Repository) Replace(ctx context.Context, oldID int64, next RefreshToken) (*RefreshToken, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL;`, oldID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, errAlreadyRevoked
+	}
+	created, err := scanToken(tx.QueryRow(ctx, insertTokenQuery, next.SubjectKind, next.SubjectID, next.FamilyID, next.TokenHash, next.AccessJTI, next.AccessExpiresAt, next.ExpiresAt))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// RevokeFamily revokes every token of family and returns all its tokens
+// whose access token may still be valid.
+func (r *Repository) RevokeFamily(ctx context.Context, familyID string, now time.Time) ([]RefreshToken, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	if _, err := r.pool.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL;`, familyID); err != nil {
+		return nil, err
+	}
+	return r.query(ctx, `SELECT `+tokenColumns+` FROM refresh_tokens WHERE family_id = $1 AND access_expires_at > $2;`, familyID, now)
+}
+
+// RevokeSubject revokes every token of subject and returns those whose access token may still be valid.
+func (r *Repository) RevokeSubject(ctx context.Context, subject Subject, now time.Time) ([]RefreshToken, error) {
+	if r.pool == nil {
+		return nil, errNilPool
+	}
+	if _, err := r.pool.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE subject_kind = $1 AND subject_id = $2 AND revoked_at IS NULL;`, subject.Kind, subject.ID); err != nil {
+		return nil, err
+	}
+	return r.query(ctx, `SELECT `+tokenColumns+` FROM refresh_tokens WHERE subject_kind = $1 AND subject_id = $2 AND access_expires_at > $3;`, subject.Kind, subject.ID, now)
+}
+
+func (r *Repository) query(ctx context.Context, query string, args ...any) ([]RefreshToken, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []RefreshToken{}
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *token)
+	}
+	return list, rows.Err()
+}
+
+func scanToken(row pgx.Row) (*RefreshToken, error) {
+	var t RefreshToken
+	if err := row.Scan(&t.ID, &t.SubjectKind, &t.SubjectID, &t.FamilyID, &t.TokenHash, &t.AccessJTI, &t.AccessExpiresAt, &t.ExpiresAt, &t.RevokedAt, &t.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
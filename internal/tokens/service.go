@@ -0,0 +1,250 @@
+package tokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrInvalidRefresh is returned for unknown, expired or revoked refresh tokens.
+	ErrInvalidRefresh = errors.New("invalid refresh token")
+	// ErrRefreshReused is returned when a rotated refresh token is presented again;
+	// the whole family is revoked because the token was probably stolen.
+	ErrRefreshReused = errors.New("refresh token reuse detected")
+)
+
+// Store persists refresh tokens; implemented by Repository.
+type Store interface {
+	Create(ctx context.Context, token RefreshToken) (*RefreshToken, error)
+	GetByHash(ctx context.Context, hash string) (*RefreshToken, error)
+	Replace(ctx context.Context, oldID int64, next RefreshToken) (*RefreshToken, error)
+	RevokeFamily(ctx context.Context, familyID string, now time.Time) ([]RefreshToken, error)
+	RevokeSubject(ctx context.Context, subject Subject, now time.Time) ([]RefreshToken, error)
+}
+
+// Minter signs a fresh access token for a subject, refusing disabled or deleted accounts.
+type Minter interface {
+	IssueAccess(ctx context.Context, subjectID int64) (Access, error)
+}
+
+// Service issues, rotates and revokes refresh tokens.
+type Service struct {
+	store      Store
+	denylist   *Denylist
+	refreshTTL time.Duration
+	minters    map[string]Minter
+	ttls       map[string]time.Duration
+	log        *zap.Logger
+	now        func() time.Time
+}
+
+// Config contains dependencies.
+type Config struct {
+	Store      Store
+	Denylist   *Denylist
+	RefreshTTL time.Duration
+	Logger     *zap.Logger
+	Now        func() time.Time
+}
+
+// NewService builds service.
+func NewService(cfg Config) (*Service, error) {
+	if cfg.Store == nil {
+		return nil, errors.New("token store is required")
+	}
+	if cfg.RefreshTTL <= 0 {
+		cfg.RefreshTTL = 30 * 24 * time.Hour
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Denylist == nil {
+		cfg.Denylist = NewDenylist(nil, cfg.Now)
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	return &Service{
+		store:      cfg.Store,
+		denylist:   cfg.Denylist,
+		refreshTTL: cfg.RefreshTTL,
+		minters:    make(map[string]Minter),
+		ttls:       make(map[string]time.Duration),
+		log:        cfg.Logger,
+		now:        cfg.Now,
+	}, nil
+}
+
+// RegisterMinter sets who signs access tokens when refresh tokens of kind are rotated;
+// ttl overrides the refresh token lifetime for kind when positive.
+func (s *Service) RegisterMinter(kind string, m Minter, ttl time.Duration) {
+	s.minters[kind] = m
+	if ttl > 0 {
+		s.ttls[kind] = ttl
+	}
+}
+
+// NewJTI returns a random token id.
+func NewJTI() string {
+	return randomString(16)
+}
+
+// Issue starts a new refresh token family for a freshly signed access token.
+func (s *Service) Issue(ctx context.Context, subject Subject, access Access) (*Session, error) {
+	secret := randomString(32)
+	stored, err := s.store.Create(ctx, RefreshToken{
+		SubjectKind:     subject.Kind,
+		SubjectID:       subject.ID,
+		FamilyID:        randomString(16),
+		TokenHash:       hashToken(secret),
+		AccessJTI:       access.JTI,
+		AccessExpiresAt: access.ExpiresAt,
+		ExpiresAt:       s.now().Add(s.ttl(subject.Kind)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newSession(access, secret, stored.ExpiresAt), nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token.
+// The old refresh token stops working; presenting it again revokes the family.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*Session, error) {
+	current, err := s.store.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return nil, ErrInvalidRefresh
+		}
+		return nil, err
+	}
+	if current.RevokedAt != nil {
+		s.reuseDetected(ctx, current)
+		return nil, ErrRefreshReused
+	}
+	if !s.now().Before(current.ExpiresAt) {
+		return nil, ErrInvalidRefresh
+	}
+	minter, ok := s.minters[current.SubjectKind]
+	if !ok {
+		return nil, fmt.Errorf("no access token minter for %q", current.SubjectKind)
+	}
+	access, err := minter.IssueAccess(ctx, current.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := randomString(32)
+	next, err := s.store.Replace(ctx, current.ID, RefreshToken{
+		SubjectKind:     current.SubjectKind,
+		SubjectID:       current.SubjectID,
+		FamilyID:        current.FamilyID,
+		TokenHash:       hashToken(secret),
+		AccessJTI:       access.JTI,
+		AccessExpiresAt: access.ExpiresAt,
+		ExpiresAt:       s.now().Add(s.ttl(current.SubjectKind)),
+	})
+	if err != nil {
+		if errors.Is(err, errAlreadyRevoked) {
+			s.deny(ctx, []RefreshToken{{AccessJTI: access.JTI, AccessExpiresAt: access.ExpiresAt}})
+			s.reuseDetected(ctx, current)
+			return nil, ErrRefreshReused
+		}
+		return nil, err
+	}
+	return newSession(access, secret, next.ExpiresAt), nil
+}
+
+// Logout revokes the refresh token family and the access tokens issued with it.
+func (s *Service) Logout(ctx context.Context, refreshToken string) error {
+	current, err := s.store.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return ErrInvalidRefresh
+		}
+		return err
+	}
+	revoked, err := s.store.RevokeFamily(ctx, current.FamilyID, s.now())
+	if err != nil {
+		return err
+	}
+	s.deny(ctx, revoked)
+	return nil
+}
+
+// LogoutAll revokes every session of the subject owning refreshToken.
+func (s *Service) LogoutAll(ctx context.Context, refreshToken string) error {
+	current, err := s.store.GetByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, ErrTokenNotFound) {
+			return ErrInvalidRefresh
+		}
+		return err
+	}
+	return s.RevokeSubject(ctx, Subject{Kind: current.SubjectKind, ID: current.SubjectID})
+}
+
+// RevokeSubject revokes all refresh tokens of subject and denies their live access tokens.
+func (s *Service) RevokeSubject(ctx context.Context, subject Subject) error {
+	revoked, err := s.store.RevokeSubject(ctx, subject, s.now())
+	if err != nil {
+		return err
+	}
+	s.deny(ctx, revoked)
+	return nil
+}
+
+// IsRevoked reports whether access token jti was revoked; used by auth middleware.
+func (s *Service) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return s.denylist.IsRevoked(ctx, jti)
+}
+
+func (s *Service) ttl(kind string) time.Duration {
+	if ttl, ok := s.ttls[kind]; ok {
+		return ttl
+	}
+	return s.refreshTTL
+}
+
+func (s *Service) reuseDetected(ctx context.Context, token *RefreshToken) {
+	s.log.Warn("refresh token reuse detected", zap.String("subject_kind", token.SubjectKind), zap.Int64("subject_id", token.SubjectID))
+	revoked, err := s.store.RevokeFamily(ctx, token.FamilyID, s.now())
+	if err != nil {
+		s.log.Error("failed to revoke refresh token family", zap.Error(err))
+		return
+	}
+	s.deny(ctx, revoked)
+}
+
+// deny puts access tokens on the denylist; a Redis failure still leaves them denied on this instance.
+func (s *Service) deny(ctx context.Context, tokens []RefreshToken) {
+	for _, t := range tokens {
+		if err := s.denylist.Revoke(ctx, t.AccessJTI, t.AccessExpiresAt); err != nil {
+			s.log.Warn("failed to store revoked token id", zap.Error(err))
+		}
+	}
+}
+
+func newSession(access Access, refresh string, refreshExpires time.Time) *Session {
+	return &Session{Token: access.Token, ExpiresAt: access.ExpiresAt, RefreshToken: refresh, RefreshExpiresAt: &refreshExpires}
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,177 @@
+package tokens
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryStore struct {
+	mu     sync.Mutex
+	nextID int64
+	rows   []*RefreshToken
+}
+
+func (m *memoryStore) Create(_ context.Context, t RefreshToken) (*RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	t.ID = m.nextID
+	m.rows = append(m.rows, &t)
+	out := t
+	return &out, nil
+}
+
+func (m *memoryStore) GetByHash(_ context.Context, hash string) (*RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.rows {
+		if r.TokenHash == hash {
+			out := *r
+			return &out, nil
+		}
+	}
+	return nil, ErrTokenNotFound
+}
+
+func (m *memoryStore) Replace(ctx context.Context, oldID int64, next RefreshToken) (*RefreshToken, error) {
+	m.mu.Lock()
+	for _, r := range m.rows {
+		if r.ID == oldID {
+			if r.RevokedAt != nil {
+				m.mu.Unlock()
+				return nil, errAlreadyRevoked
+			}
+			now := time.Now()
+			r.RevokedAt = &now
+		}
+	}
+	m.mu.Unlock()
+	return m.Create(ctx, next)
+}
+
+func (m *memoryStore) revoke(match func(*RefreshToken) bool, now time.Time) []RefreshToken {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var live []RefreshToken
+	for _, r := range m.rows {
+		if !match(r) {
+			continue
+		}
+		if r.RevokedAt == nil {
+			t := now
+			r.RevokedAt = &t
+		}
+		if r.AccessExpiresAt.After(now) {
+			live = append(live, *r)
+		}
+	}
+	return live
+}
+
+func (m *memoryStore) RevokeFamily(_ context.Context, familyID string, now time.Time) ([]RefreshToken, error) {
+	return m.revoke(func(r *RefreshToken) bool { return r.FamilyID == familyID }, now), nil
+}
+
+func (m *memoryStore) RevokeSubject(_ context.Context, subject Subject, now time.Time) ([]RefreshToken, error) {
+	return m.revoke(func(r *RefreshToken) bool { return r.SubjectKind == subject.Kind && r.SubjectID == subject.ID }, now), nil
+}
+
+type minter struct {
+	now      func() time.Time
+	disabled bool
+}
+
+func (m *minter) IssueAccess(_ context.Context, id int64) (Access, error) {
+	if m.disabled {
+		return Access{}, errors.New("disabled")
+	}
+	return Access{Token: "access", JTI: NewJTI(), ExpiresAt: m.now().Add(15 * time.Minute)}, nil
+}
+
+func newTestService(t *testing.T) (*Service, *minter, *time.Time) {
+	t.Helper()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	svc, err := NewService(Config{Store: &memoryStore{}, RefreshTTL: time.Hour, Now: clock})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &minter{now: clock}
+	svc.RegisterMinter(SubjectUser, m, 0)
+	return svc, m, &now
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	ctx := context.Background()
+	svc, _, _ := newTestService(t)
+	first := Access{Token: "a1", JTI: "jti-1", ExpiresAt: time.Date(2025, 1, 1, 12, 15, 0, 0, time.UTC)}
+	session, err := svc.Issue(ctx, Subject{Kind: SubjectUser, ID: 7}, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated, err := svc.Refresh(ctx, session.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.RefreshToken == session.RefreshToken {
+		t.Fatal("expected a new refresh token")
+	}
+
+	if _, err := svc.Refresh(ctx, session.RefreshToken); !errors.Is(err, ErrRefreshReused) {
+		t.Fatalf("expected reuse detection, got %v", err)
+	}
+	if _, err := svc.Refresh(ctx, rotated.RefreshToken); !errors.Is(err, ErrRefreshReused) {
+		t.Fatalf("expected the whole family to be revoked, got %v", err)
+	}
+	if revoked, _ := svc.IsRevoked(ctx, "jti-1"); !revoked {
+		t.Fatal("expected access tokens of the family to be denied")
+	}
+}
+
+func TestRefreshRejectsExpiredAndUnknownTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, _, now := newTestService(t)
+	session, err := svc.Issue(ctx, Subject{Kind: SubjectUser, ID: 7}, Access{JTI: "jti", ExpiresAt: now.Add(time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Refresh(ctx, "unknown"); !errors.Is(err, ErrInvalidRefresh) {
+		t.Fatalf("expected invalid refresh, got %v", err)
+	}
+	*now = now.Add(2 * time.Hour)
+	if _, err := svc.Refresh(ctx, session.RefreshToken); !errors.Is(err, ErrInvalidRefresh) {
+		t.Fatalf("expected expired refresh to fail, got %v", err)
+	}
+}
+
+func TestLogoutAndRevokeSubjectDenyAccessTokens(t *testing.T) {
+	ctx := context.Background()
+	svc, _, now := newTestService(t)
+	subject := Subject{Kind: SubjectUser, ID: 9}
+	phone, _ := svc.Issue(ctx, subject, Access{JTI: "phone", ExpiresAt: now.Add(time.Minute)})
+	laptop, _ := svc.Issue(ctx, subject, Access{JTI: "laptop", ExpiresAt: now.Add(time.Minute)})
+
+	if err := svc.Logout(ctx, phone.RefreshToken); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := svc.IsRevoked(ctx, "phone"); !revoked {
+		t.Fatal("expected logged out access token to be denied")
+	}
+	if revoked, _ := svc.IsRevoked(ctx, "laptop"); revoked {
+		t.Fatal("other session must survive a single logout")
+	}
+
+	if err := svc.RevokeSubject(ctx, subject); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := svc.IsRevoked(ctx, "laptop"); !revoked {
+		t.Fatal("expected all sessions to be denied")
+	}
+	if _, err := svc.Refresh(ctx, laptop.RefreshToken); !errors.Is(err, ErrRefreshReused) {
+		t.Fatalf("expected revoked refresh token to fail, got %v", err)
+	}
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS orders_status_idx;
+ALTER TABLE order_items DROP COLUMN IF EXISTS done_at;
+ALTER TABLE admin_users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,4 @@
+ALTER TABLE admin_users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'admin';
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS done_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS orders_status_idx ON orders(status);
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS payments (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('intent', 'pending', 'paid', 'failed', 'refunded')),
+    amount NUMERIC(10, 2) NOT NULL,
+    currency TEXT NOT NULL DEFAULT 'UZS',
+    external_id TEXT,
+    redirect_url TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS payments_order_id_idx ON payments(order_id);
+CREATE UNIQUE INDEX IF NOT EXISTS payments_provider_external_id_idx ON payments(provider, external_id) WHERE external_id IS NOT NULL;
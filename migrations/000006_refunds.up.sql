@@ -0,0 +1,28 @@
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS paid_at TIMESTAMPTZ;
+UPDATE payments SET paid_at = updated_at WHERE status IN ('paid', 'refunded') AND paid_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id BIGSERIAL PRIMARY KEY,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    payment_id BIGINT NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    amount NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL DEFAULT '',
+    method TEXT NOT NULL CHECK (method IN ('provider', 'manual')),
+    status TEXT NOT NULL CHECK (status IN ('pending', 'succeeded', 'failed')),
+    external_id TEXT,
+    error TEXT,
+    created_by BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS refunds_order_id_idx ON refunds(order_id);
+CREATE INDEX IF NOT EXISTS refunds_payment_id_idx ON refunds(payment_id);
+
+CREATE TABLE IF NOT EXISTS refund_items (
+    refund_id BIGINT NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    order_item_id BIGINT NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    qty INT NOT NULL CHECK (qty > 0),
+    amount NUMERIC(10, 2) NOT NULL,
+    PRIMARY KEY (refund_id, order_item_id)
+);
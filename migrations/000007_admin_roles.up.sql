@@ -0,0 +1,4 @@
+UPDATE admin_users SET role = 'owner' WHERE role = 'admin';
+ALTER TABLE admin_users ALTER COLUMN role SET DEFAULT 'operator';
+ALTER TABLE admin_users ADD CONSTRAINT admin_users_role_check
+    CHECK (role IN ('owner', 'manager', 'operator', 'kitchen', 'courier', 'content_editor'));
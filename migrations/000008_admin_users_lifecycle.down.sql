@@ -0,0 +1,5 @@
+ALTER TABLE admin_users
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS token_version,
+    DROP COLUMN IF EXISTS disabled_at,
+    DROP COLUMN IF EXISTS must_change_password;
@@ -0,0 +1,5 @@
+ALTER TABLE admin_users
+    ADD COLUMN IF NOT EXISTS must_change_password BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS token_version BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
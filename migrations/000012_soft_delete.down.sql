@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_products_live;
+
+ALTER TABLE regions DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE products DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE categories DROP COLUMN IF EXISTS archived_at;
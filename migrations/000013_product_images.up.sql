@@ -0,0 +1,2 @@
+-- Resized copies of the uploaded image: {"thumbnail": url, "medium": url, "large": url}.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS images JSONB;
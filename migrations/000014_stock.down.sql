@@ -0,0 +1,2 @@
+ALTER TABLE products DROP COLUMN IF EXISTS unavailable_until;
+ALTER TABLE products DROP COLUMN IF EXISTS stock;
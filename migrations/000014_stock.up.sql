@@ -0,0 +1,4 @@
+-- stock is NULL for products that are not counted; unavailable_until puts a
+-- product on the stop-list until that moment.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS stock INT CHECK (stock >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS unavailable_until TIMESTAMPTZ;
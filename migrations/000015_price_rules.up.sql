@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS price_rules (
+    id BIGSERIAL PRIMARY KEY,
+    product_id BIGINT REFERENCES products(id) ON DELETE CASCADE,
+    category_id BIGINT REFERENCES categories(id) ON DELETE CASCADE,
+    price NUMERIC(10, 2) CHECK (price > 0),
+    discount_percent NUMERIC(5, 2) CHECK (discount_percent > 0 AND discount_percent < 100),
+    starts_at TIMESTAMPTZ,
+    ends_at TIMESTAMPTZ,
+    weekdays INT[],
+    time_from TIME,
+    time_to TIME,
+    note TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK ((product_id IS NULL) <> (category_id IS NULL)),
+    CHECK ((price IS NULL) <> (discount_percent IS NULL)),
+    CHECK (price IS NULL OR product_id IS NOT NULL),
+    CHECK (ends_at IS NULL OR starts_at IS NULL OR ends_at > starts_at),
+    CHECK ((time_from IS NULL) = (time_to IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_rules_product ON price_rules (product_id) WHERE product_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_price_rules_category ON price_rules (category_id) WHERE category_id IS NOT NULL;
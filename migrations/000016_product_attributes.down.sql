@@ -0,0 +1,4 @@
+ALTER TABLE products DROP COLUMN IF EXISTS weight;
+ALTER TABLE products DROP COLUMN IF EXISTS kcal;
+ALTER TABLE products DROP COLUMN IF EXISTS allergens;
+ALTER TABLE products DROP COLUMN IF EXISTS tags;
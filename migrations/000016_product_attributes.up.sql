@@ -0,0 +1,6 @@
+-- tags are admin-managed badges (spicy, halal, ...); allergens are free-form
+-- lowercase names. kcal and weight (grams) describe one serving.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS allergens TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE products ADD COLUMN IF NOT EXISTS kcal INT CHECK (kcal >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS weight INT CHECK (weight > 0);
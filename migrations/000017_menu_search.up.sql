@@ -0,0 +1,26 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- menu_search_normalize folds Russian and Uzbek Cyrillic and Uzbek Latin into
+-- one lowercase Latin spelling, so "шашлык", "шашлик" and "shashlik" match.
+-- Keep the mapping in sync with menu.NormalizeQuery.
+CREATE OR REPLACE FUNCTION menu_search_normalize(input TEXT) RETURNS TEXT
+LANGUAGE SQL IMMUTABLE STRICT PARALLEL SAFE AS $$
+SELECT btrim(regexp_replace(
+    translate(replace(replace(replace(replace(replace(replace(replace(replace(lower(input), 'kh', 'x'), 'ё', 'yo'), 'ц', 'ts'), 'ч', 'ch'), 'ш', 'sh'), 'щ', 'sh'), 'ю', 'yu'), 'я', 'ya'),
+        'абвгдежзийклмнопрстуфхыэўқғҳqъьʻʼ’‘''`',
+        'abvgdejziyklmnoprstufxieokghk'),
+    '[^a-z0-9]+', ' ', 'g'))
+$$;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_name TEXT
+    GENERATED ALWAYS AS (menu_search_normalize(name)) STORED;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_text TEXT
+    GENERATED ALWAYS AS (menu_search_normalize(name || ' ' || COALESCE(description, ''))) STORED;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector TSVECTOR
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', menu_search_normalize(name)), 'A') ||
+        setweight(to_tsvector('simple', menu_search_normalize(COALESCE(description, ''))), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS products_search_vector_idx ON products USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS products_search_text_trgm_idx ON products USING GIN (search_text gin_trgm_ops);
@@ -0,0 +1,3 @@
+ALTER TABLE order_items DROP COLUMN IF EXISTS components;
+DROP TABLE IF EXISTS bundle_slot_options;
+DROP TABLE IF EXISTS bundle_slots;
@@ -0,0 +1,22 @@
+-- A product with slots is a bundle (combo). Each slot lists the products that
+-- can fill it: one option makes the slot fixed, several make it a choice group.
+CREATE TABLE IF NOT EXISTS bundle_slots (
+    id BIGSERIAL PRIMARY KEY,
+    bundle_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    qty INT NOT NULL DEFAULT 1 CHECK (qty > 0),
+    sort_order INT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS bundle_slots_bundle_id_idx ON bundle_slots(bundle_id);
+
+CREATE TABLE IF NOT EXISTS bundle_slot_options (
+    slot_id BIGINT NOT NULL REFERENCES bundle_slots(id) ON DELETE CASCADE,
+    product_id BIGINT NOT NULL REFERENCES products(id),
+    extra_price NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (extra_price >= 0),
+    sort_order INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (slot_id, product_id)
+);
+
+-- components snapshots the products chosen for a bundle item.
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS components JSONB;
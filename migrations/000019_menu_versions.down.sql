@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS menu_drafts;
+DROP TABLE IF EXISTS menu_versions;
@@ -0,0 +1,16 @@
+-- menu_versions keeps a snapshot of the live categories and products after
+-- every publish or rollback; the highest version is the current one.
+CREATE TABLE IF NOT EXISTS menu_versions (
+    version BIGSERIAL PRIMARY KEY,
+    snapshot JSONB NOT NULL,
+    restored_from BIGINT REFERENCES menu_versions(version),
+    published_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- menu_drafts holds the single staged workspace, based on base_version.
+CREATE TABLE IF NOT EXISTS menu_drafts (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    base_version BIGINT NOT NULL DEFAULT 0,
+    document JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1 @@
+ALTER TABLE menu_drafts DROP COLUMN IF EXISTS base_revision;
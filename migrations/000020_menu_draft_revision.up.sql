@@ -0,0 +1,3 @@
+-- base_revision fingerprints the live menu a draft was started from, so
+-- publishing can detect direct edits made since.
+ALTER TABLE menu_drafts ADD COLUMN IF NOT EXISTS base_revision TEXT NOT NULL DEFAULT '';
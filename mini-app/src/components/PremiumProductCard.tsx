@@ -10,6 +10,7 @@ interface Props {
 }
 
 export function PremiumProductCard({ product, onAdd, accentEmoji = '🌭' }: Props) {
+  const soldOut = product.available === false;
   return (
     <div className="flex flex-col gap-4 rounded-[28px] bg-[#fcfcfc] p-4 shadow-[0_20px_45px_rgba(15,23,42,0.08)]">
       <div
@@ -17,7 +18,14 @@ export function PremiumProductCard({ product, onAdd, accentEmoji = '🌭' }: Pro
         style={{ aspectRatio: '4/3' }}
       >
         {product.image_url ? (
-          <Image src={product.image_url} alt={product.name} fill className="object-cover" />
+          // Uploaded images come pre-resized, so they skip the Next.js optimizer.
+          <Image
+            src={product.images?.medium ?? product.image_url}
+            alt={product.name}
+            fill
+            unoptimized={Boolean(product.images)}
+            className="object-cover"
+          />
         ) : (
           <div className="flex h-full items-center justify-center text-4xl text-slate-300">
             {accentEmoji}
@@ -30,14 +38,25 @@ export function PremiumProductCard({ product, onAdd, accentEmoji = '🌭' }: Pro
           <span className="mr-1">{accentEmoji}</span>
           {product.name}
         </p>
+        {Boolean(product.weight || product.kcal) && (
+          <p className="text-xs text-slate-400">
+            {[product.weight && `${product.weight} г`, product.kcal && `${product.kcal} ккал`].filter(Boolean).join(' · ')}
+          </p>
+        )}
       </div>
-      <button
-        type="button"
-        onClick={onAdd}
-        className="flex items-center justify-center gap-2 rounded-full bg-white py-3 text-sm font-semibold text-slate-900 shadow-[0_10px_30px_rgba(15,23,42,0.12)]"
-      >
-        <PlusIcon className="h-4 w-4" /> Добавить
-      </button>
+      {soldOut ? (
+        <div className="flex items-center justify-center rounded-full bg-slate-100 py-3 text-sm font-semibold text-slate-400">
+          Нет в наличии
+        </div>
+      ) : (
+        <button
+          type="button"
+          onClick={onAdd}
+          className="flex items-center justify-center gap-2 rounded-full bg-white py-3 text-sm font-semibold text-slate-900 shadow-[0_10px_30px_rgba(15,23,42,0.12)]"
+        >
+          <PlusIcon className="h-4 w-4" /> Добавить
+        </button>
+      )}
     </div>
   );
 }
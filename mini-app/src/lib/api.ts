@@ -8,37 +8,92 @@ import type {
   Order,
   Profile,
   RegionsResponse,
+  SessionTokens,
 } from '@/types/api';
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080';
 
 type RequestOptions = RequestInit & { auth?: boolean };
 
-let authToken: string | null = null;
+// Access tokens are refreshed this long before they expire.
+const REFRESH_MARGIN_MS = 30_000;
 
-export function setAuthToken(token: string | null) {
-  authToken = token;
+let session: SessionTokens | null = null;
+let refreshing: Promise<boolean> | null = null;
+let sessionListener: ((session: SessionTokens | null) => void) | null = null;
+
+export function setAuthSession(next: SessionTokens | null) {
+  session = next;
+}
+
+// onSessionRefresh is called with every rotated session, or null once refreshing fails,
+// so the caller can persist it.
+export function onSessionRefresh(listener: ((session: SessionTokens | null) => void) | null) {
+  sessionListener = listener;
+}
+
+function sessionExpiring(): boolean {
+  if (!session?.expires_at) return false;
+  return new Date(session.expires_at).getTime() - REFRESH_MARGIN_MS <= Date.now();
+}
+
+// refreshSession rotates the refresh token once for all concurrent callers.
+function refreshSession(): Promise<boolean> {
+  const refreshToken = session?.refresh_token;
+  if (!refreshToken) return Promise.resolve(false);
+  if (!refreshing) {
+    refreshing = (async () => {
+      try {
+        const res = await fetch(`${API_URL}/auth/refresh`, {
+          method: 'POST',
+          headers: { 'Content-Type': 'application/json' },
+          body: JSON.stringify({ refresh_token: refreshToken }),
+          cache: 'no-store',
+        });
+        if (!res.ok) {
+          session = null;
+          sessionListener?.(null);
+          return false;
+        }
+        session = (await res.json()) as SessionTokens;
+        sessionListener?.(session);
+        return true;
+      } catch {
+        return false;
+      } finally {
+        refreshing = null;
+      }
+    })();
+  }
+  return refreshing;
 }
 
-async function request<T>(path: string, init?: RequestOptions): Promise<T> {
-  const { auth, headers: initHeaders, ...rest } = init || {};
+async function request<T>(path: string, init?: RequestOptions, retried = false): Promise<T> {
+  const { auth, cache, headers: initHeaders, ...rest } = init || {};
   const headers = new Headers(initHeaders ?? {});
   if (!headers.has('Content-Type')) {
     headers.set('Content-Type', 'application/json');
   }
   if (auth) {
-    if (!authToken) {
+    if (sessionExpiring()) {
+      await refreshSession();
+    }
+    if (!session?.token) {
       throw new Error('auth token missing');
     }
-    headers.set('Authorization', `Bearer ${authToken}`);
+    headers.set('Authorization', `Bearer ${session.token}`);
   }
 
   const res = await fetch(`${API_URL}${path}`, {
     ...rest,
     headers,
-    cache: 'no-store',
+    cache: cache ?? 'no-store',
   });
 
+  if (auth && res.status === 401 && !retried && (await refreshSession())) {
+    return request<T>(path, init, true);
+  }
+
   if (!res.ok) {
     throw new Error(`API error: ${res.status}`);
   }
@@ -64,8 +119,9 @@ export const api = {
         body: JSON.stringify({ init_data: initData }),
       }
     ),
-  getMenu: () => request<MenuResponse>('/menu'),
-  getRegions: () => request<RegionsResponse>('/regions'),
+  // Menu and regions carry an ETag, so the browser cache revalidates them cheaply.
+  getMenu: () => request<MenuResponse>('/menu', { cache: 'default' }),
+  getRegions: () => request<RegionsResponse>('/regions', { cache: 'default' }),
   getProfile: () => request<Profile>('/profile', { auth: true }),
   getAddresses: () => request<AddressesResponse>('/addresses', { auth: true }),
   createAddress: (body: AddressInput) =>
@@ -1,9 +1,9 @@
 'use client';
 
 import { createContext, useCallback, useContext, useEffect, useMemo, useState } from 'react';
-import { api, setAuthToken } from '@/lib/api';
+import { api, onSessionRefresh, setAuthSession } from '@/lib/api';
 import { getTelegramWebApp } from '@/lib/telegram';
-import type { Profile } from '@/types/api';
+import type { Profile, SessionTokens } from '@/types/api';
 
 interface AuthContextValue {
   token: string | null;
@@ -17,6 +17,9 @@ interface AuthContextValue {
 
 const AuthContext = createContext<AuthContextValue | undefined>(undefined);
 const TOKEN_STORAGE_KEY = 'auth_token';
+// The session keeps the refresh token next to the access token, so the API client
+// can renew short-lived access tokens without a new Telegram login.
+const SESSION_STORAGE_KEY = 'auth_session';
 
 type BrowserWindow = Window & typeof globalThis;
 
@@ -41,6 +44,30 @@ function getQueryParam(name: string): string | null {
   return new URLSearchParams(search).get(name);
 }
 
+function readSession(storage: Storage): SessionTokens | null {
+  const raw = storage.getItem(SESSION_STORAGE_KEY);
+  if (raw) {
+    try {
+      const parsed = JSON.parse(raw) as SessionTokens;
+      if (parsed.token) return parsed;
+    } catch {
+      storage.removeItem(SESSION_STORAGE_KEY);
+    }
+  }
+  const token = storage.getItem(TOKEN_STORAGE_KEY);
+  return token ? { token } : null;
+}
+
+function writeSession(storage: Storage | null, session: SessionTokens | null) {
+  if (!storage) return;
+  storage.removeItem(TOKEN_STORAGE_KEY);
+  if (session) {
+    storage.setItem(SESSION_STORAGE_KEY, JSON.stringify(session));
+  } else {
+    storage.removeItem(SESSION_STORAGE_KEY);
+  }
+}
+
 function removeQueryParam(name: string) {
   const win = getBrowserWindow();
   if (!win) return;
@@ -61,12 +88,19 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     setProfile(data);
   }, []);
 
+  useEffect(() => {
+    onSessionRefresh((session) => {
+      writeSession(getLocalStorage(), session);
+      setToken(session?.token ?? null);
+    });
+    return () => onSessionRefresh(null);
+  }, []);
+
   const finishAuth = useCallback(
-    async (jwt: string, profileOverride?: Profile | null) => {
-      const storage = getLocalStorage();
-      storage?.setItem(TOKEN_STORAGE_KEY, jwt);
-      setAuthToken(jwt);
-      setToken(jwt);
+    async (session: SessionTokens, profileOverride?: Profile | null) => {
+      writeSession(getLocalStorage(), session);
+      setAuthSession(session);
+      setToken(session.token);
       if (profileOverride) {
         setProfile(profileOverride);
       } else {
@@ -84,7 +118,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
 
     const storage = getLocalStorage();
     if (!storage) {
-      setAuthToken(null);
+      setAuthSession(null);
       setToken(null);
       setStatus('error');
       setError('localStorage недоступен. Откройте мини-апп в браузере или через Telegram.');
@@ -95,27 +129,31 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     const devToken = process.env.NEXT_PUBLIC_DEV_TOKEN || null;
     const incomingToken = urlToken || devToken;
     if (incomingToken) {
-      storage.setItem(TOKEN_STORAGE_KEY, incomingToken);
+      writeSession(storage, { token: incomingToken });
       if (urlToken) {
         removeQueryParam('token');
       }
     }
 
-    let storedToken = storage.getItem(TOKEN_STORAGE_KEY);
-    if (!storedToken) {
-      const webApp = getTelegramWebApp();
-      const initData = webApp?.initData;
-      if (initData) {
-        try {
-          const authResult = await api.authenticateTelegram(initData);
-          storedToken = authResult.token;
-          await finishAuth(storedToken, authResult.profile);
-          return;
-        } catch (err) {
-          console.error('Telegram init auth failed', err);
-        }
+    const authenticateWithInitData = async () => {
+      const initData = getTelegramWebApp()?.initData;
+      if (!initData) return false;
+      try {
+        const { profile: authProfile, ...session } = await api.authenticateTelegram(initData);
+        await finishAuth(session, authProfile);
+        return true;
+      } catch (err) {
+        console.error('Telegram init auth failed', err);
+        return false;
       }
-      setAuthToken(null);
+    };
+
+    const storedSession = readSession(storage);
+    if (!storedSession) {
+      if (await authenticateWithInitData()) {
+        return;
+      }
+      setAuthSession(null);
       setToken(null);
       setStatus('error');
       setError('Нужна авторизация. Перейдите по ссылке из Telegram-бота.');
@@ -123,11 +161,15 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     }
 
     try {
-      await finishAuth(storedToken);
+      await finishAuth(storedSession);
     } catch (err) {
-      console.error('Stored token is invalid', err);
-      storage.removeItem(TOKEN_STORAGE_KEY);
-      setAuthToken(null);
+      console.error('Stored session is invalid', err);
+      writeSession(storage, null);
+      // The refresh token is gone or expired; inside Telegram a fresh session comes from initData.
+      if (await authenticateWithInitData()) {
+        return;
+      }
+      setAuthSession(null);
       setToken(null);
       setStatus('error');
       setError('Токен недействителен или истёк. Получите новую ссылку в боте.');
@@ -6,8 +6,44 @@ export interface Product {
   price: number;
   old_price?: number;
   image_url?: string;
+  images?: ProductImages;
   is_active: boolean;
   sort_order: number;
+  tags?: ProductTag[];
+  allergens?: string[];
+  kcal?: number;
+  // grams per serving
+  weight?: number;
+  // slots of a combo product
+  bundle?: BundleSlot[];
+  stock?: number;
+  unavailable_until?: string;
+  // false for sold-out and stop-listed products
+  available?: boolean;
+}
+
+export type ProductTag = 'spicy' | 'vegetarian' | 'halal' | 'new' | 'hit';
+
+// A single option makes the slot fixed; several make it a choice.
+export interface BundleSlot {
+  id: number;
+  name: string;
+  qty: number;
+  options: BundleOption[];
+}
+
+export interface BundleOption {
+  product_id: number;
+  name: string;
+  extra_price: number;
+  available: boolean;
+}
+
+// Resized JPEG copies of an uploaded image_url.
+export interface ProductImages {
+  thumbnail: string;
+  medium: string;
+  large: string;
 }
 
 export interface MenuCategory {
@@ -20,6 +56,8 @@ export interface MenuCategory {
 }
 
 export interface MenuResponse {
+  version: number;
+  prices_change_at?: string;
   categories: MenuCategory[];
 }
 
@@ -71,8 +109,15 @@ export interface Profile {
   addresses: Address[];
 }
 
-export interface AuthResult {
+export interface SessionTokens {
   token: string;
+  expires_at?: string;
+  refresh_token?: string;
+  refresh_expires_at?: string;
+}
+
+export interface AuthResult extends SessionTokens {
+  expires_at: string;
   profile: Profile;
 }
 
@@ -85,7 +130,8 @@ export interface CreateOrderPayload {
   customer_name: string;
   customer_phone: string;
   comment?: string;
-  items: Array<{ product_id: number; qty: number }>;
+  // choices pick the options of bundle slots
+  items: Array<{ product_id: number; qty: number; choices?: Array<{ slot_id: number; product_id: number }> }>;
 }
 
 export interface Order extends CreateOrderPayload {